@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -24,9 +25,17 @@ const flowTokenLen = 216
 // RequestLiveToken does a login request for Microsoft Live using the login and password passed. If
 // successful, a token containing the access token, refresh token, expiry and user ID is returned.
 func RequestLiveToken(login, password string) (*TokenPair, error) {
+	return RequestLiveTokenContext(context.Background(), login, password)
+}
+
+// RequestLiveTokenContext does a login request for Microsoft Live using the login and password passed, like
+// RequestLiveToken. The context passed is used for all HTTP requests made, so that cancelling it aborts the
+// login request.
+func RequestLiveTokenContext(ctx context.Context, login, password string) (*TokenPair, error) {
 	// We first create a new http client and send a request to the first URL.
 	c := &http.Client{}
-	resp, err := c.Get(requestURL)
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	resp, err := c.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("GET requestURL: %v", err)
 	}
@@ -91,7 +100,7 @@ func RequestLiveToken(login, password string) (*TokenPair, error) {
 		"isFidoSupported":      false,
 		"flowToken":            flowToken,
 	})
-	request, _ := http.NewRequest("POST", credentialTypeURL, bytes.NewReader(jsonData))
+	request, _ = http.NewRequestWithContext(ctx, "POST", credentialTypeURL, bytes.NewReader(jsonData))
 	request.Header.Set("Content-Type", "application/json; charset=UTF-8")
 	transferCookies(request, resp)
 	resp, err = c.Do(request)
@@ -115,7 +124,7 @@ func RequestLiveToken(login, password string) (*TokenPair, error) {
 		"NewUser":      []string{"1"},
 		"LoginOptions": []string{"1"},
 	}
-	request, _ = http.NewRequest("POST", urlPost, strings.NewReader(postData.Encode()))
+	request, _ = http.NewRequestWithContext(ctx, "POST", urlPost, strings.NewReader(postData.Encode()))
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	transferCookies(request, resp)
 
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/base64"
@@ -18,13 +19,19 @@ const minecraftAuthURL = `https://multiplayer.minecraft.net/authentication`
 // ECDSA private key of the client. This key will later be used to initialise encryption, and must be saved
 // for when packets need to be decrypted/encrypted.
 func RequestMinecraftChain(token *XSTSToken, key *ecdsa.PrivateKey) (string, error) {
+	return RequestMinecraftChainContext(context.Background(), token, key)
+}
+
+// RequestMinecraftChainContext requests a fully processed Minecraft JWT chain like RequestMinecraftChain.
+// The request is made using the context passed, so that it is aborted when the context is cancelled.
+func RequestMinecraftChainContext(ctx context.Context, token *XSTSToken, key *ecdsa.PrivateKey) (string, error) {
 	data, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
 	pubKeyData := base64.StdEncoding.EncodeToString(data)
 
 	// The body of the requests holds a JSON object with one key in it, the 'identityPublicKey', which holds
 	// the public key data of the private key passed.
 	body := fmt.Sprintf(`{"identityPublicKey":"%v"}`, pubKeyData)
-	request, _ := http.NewRequest("POST", minecraftAuthURL, strings.NewReader(body))
+	request, _ := http.NewRequestWithContext(ctx, "POST", minecraftAuthURL, strings.NewReader(body))
 	request.Header.Set("Content-Type", "application/json")
 
 	// The Authorization header is important in particular. It is composed of the 'uhs' found in the XSTS
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -91,6 +92,12 @@ type XSTSToken struct {
 // RequestXSTSToken obtains the XSTS token by using the UserToken, DeviceToken and TitleToken. It appears only
 // one of these tokens is actually required to produce an XSTS token valid to authenticate with Minecraft.
 func RequestXSTSToken(liveToken *TokenPair) (*XSTSToken, error) {
+	return RequestXSTSTokenContext(context.Background(), liveToken)
+}
+
+// RequestXSTSTokenContext requests an XSTS token using the passed Live token pair, like RequestXSTSToken.
+// All requests are made using the context passed, so that they are aborted when the context is cancelled.
+func RequestXSTSTokenContext(ctx context.Context, liveToken *TokenPair) (*XSTSToken, error) {
 	if !liveToken.Valid() {
 		return nil, fmt.Errorf("live token is no longer valid")
 	}
@@ -107,25 +114,25 @@ func RequestXSTSToken(liveToken *TokenPair) (*XSTSToken, error) {
 
 	// All following requests here use the same ECDSA private key. This is required, and failing to do so
 	// means that the signature of the second request will be refused.
-	userToken, err := userToken(c, liveToken.access, key)
+	userToken, err := userToken(ctx, c, liveToken.access, key)
 	if err != nil {
 		return nil, err
 	}
-	deviceToken, err := deviceToken(c, key)
+	deviceToken, err := deviceToken(ctx, c, key)
 	if err != nil {
 		return nil, err
 	}
-	titleToken, err := titleToken(c, liveToken.access, deviceToken.Token, key)
+	titleToken, err := titleToken(ctx, c, liveToken.access, deviceToken.Token, key)
 	if err != nil {
 		return nil, err
 	}
-	return xstsToken(c, userToken.Token, deviceToken.Token, titleToken.Token, key)
+	return xstsToken(ctx, c, userToken.Token, deviceToken.Token, titleToken.Token, key)
 }
 
 // userToken sends a POST request to xblUserAuthURL using the Live access token passed, and the ECDSA private
 // key to sign the request. Signing the request is not actually mandatory, but we do so anyway just to be
 // sure.
-func userToken(c *http.Client, accessToken string, key *ecdsa.PrivateKey) (token *UserToken, err error) {
+func userToken(ctx context.Context, c *http.Client, accessToken string, key *ecdsa.PrivateKey) (token *UserToken, err error) {
 	data, _ := json.Marshal(map[string]interface{}{
 		"RelyingParty": "http://auth.xboxlive.com",
 		"TokenType":    "JWT",
@@ -145,7 +152,7 @@ func userToken(c *http.Client, accessToken string, key *ecdsa.PrivateKey) (token
 			},
 		},
 	})
-	request, _ := http.NewRequest("POST", xblUserAuthURL, bytes.NewReader(data))
+	request, _ := http.NewRequestWithContext(ctx, "POST", xblUserAuthURL, bytes.NewReader(data))
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("x-xbl-contract-version", "1")
 
@@ -169,7 +176,7 @@ func userToken(c *http.Client, accessToken string, key *ecdsa.PrivateKey) (token
 
 // deviceToken sends a POST request to xblDeviceAuthURL using the ECDSA private key passed to sign the
 // request. Note that the device token is not mandatory to obtain a valid XSTS token.
-func deviceToken(c *http.Client, key *ecdsa.PrivateKey) (token *DeviceToken, err error) {
+func deviceToken(ctx context.Context, c *http.Client, key *ecdsa.PrivateKey) (token *DeviceToken, err error) {
 	data, _ := json.Marshal(map[string]interface{}{
 		"RelyingParty": "http://auth.xboxlive.com",
 		"TokenType":    "JWT",
@@ -192,7 +199,7 @@ func deviceToken(c *http.Client, key *ecdsa.PrivateKey) (token *DeviceToken, err
 			},
 		},
 	})
-	request, _ := http.NewRequest("POST", xblDeviceAuthURL, bytes.NewReader(data))
+	request, _ := http.NewRequestWithContext(ctx, "POST", xblDeviceAuthURL, bytes.NewReader(data))
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("x-xbl-contract-version", "1")
 	sign(request, data, key)
@@ -213,7 +220,7 @@ func deviceToken(c *http.Client, key *ecdsa.PrivateKey) (token *DeviceToken, err
 
 // titleToken sends a POST request to xblTitleAuthURL using the device and Live access token passed. The
 // request is signed using the ECDSA private key passed.
-func titleToken(c *http.Client, accessToken, deviceToken string, key *ecdsa.PrivateKey) (token *TitleToken, err error) {
+func titleToken(ctx context.Context, c *http.Client, accessToken, deviceToken string, key *ecdsa.PrivateKey) (token *TitleToken, err error) {
 	data, _ := json.Marshal(map[string]interface{}{
 		"RelyingParty": "http://auth.xboxlive.com",
 		"TokenType":    "JWT",
@@ -232,7 +239,7 @@ func titleToken(c *http.Client, accessToken, deviceToken string, key *ecdsa.Priv
 			},
 		},
 	})
-	request, _ := http.NewRequest("POST", xblTitleAuthURL, bytes.NewReader(data))
+	request, _ := http.NewRequestWithContext(ctx, "POST", xblTitleAuthURL, bytes.NewReader(data))
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("x-xbl-contract-version", "1")
 	sign(request, data, key)
@@ -254,7 +261,7 @@ func titleToken(c *http.Client, accessToken, deviceToken string, key *ecdsa.Priv
 // xstsToken sends a POST request to xblAuthorizeURL using the user, device and title token passed, and the
 // ECDSA private key to sign the request. The device token, title token and signature are not mandatory to
 // produce a valid XSTS token, but we require them here just in case.
-func xstsToken(c *http.Client, userToken, deviceToken, titleToken string, key *ecdsa.PrivateKey) (token *XSTSToken, err error) {
+func xstsToken(ctx context.Context, c *http.Client, userToken, deviceToken, titleToken string, key *ecdsa.PrivateKey) (token *XSTSToken, err error) {
 	data, _ := json.Marshal(map[string]interface{}{
 		// RelyingParty MUST be this URL to produce an XSTS token which may be used for Minecraft
 		// authentication.
@@ -269,7 +276,7 @@ func xstsToken(c *http.Client, userToken, deviceToken, titleToken string, key *e
 			"SandboxId":  "RETAIL",
 		},
 	})
-	request, _ := http.NewRequest("POST", xblAuthorizeURL, bytes.NewReader(data))
+	request, _ := http.NewRequestWithContext(ctx, "POST", xblAuthorizeURL, bytes.NewReader(data))
 	request.Header.Set("Content-Type", "application/json; charset=UTF-8")
 	request.Header.Set("x-xbl-contract-version", "1")
 
@@ -0,0 +1,233 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// testTimeout is the maximum duration that a connection sequence in a test may take.
+const testTimeout = time.Second * 10
+
+// connect listens on the memory network with the Listener passed and dials it. The game is started for the
+// connection accepted using the GameData passed, after which both ends of the connection are returned.
+func connect(t *testing.T, listener *minecraft.Listener, data minecraft.GameData) (client, server *minecraft.Conn) {
+	listener.AuthenticationDisabled = true
+	listener.ErrorLog = minecraft.NopLogger()
+	if err := listener.Listen("memory", t.Name()); err != nil {
+		t.Fatalf("error listening on memory network: %v", err)
+	}
+	conns, errs := make(chan *minecraft.Conn, 1), make(chan error, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+		conn := c.(*minecraft.Conn)
+		if err := conn.StartGame(data); err != nil {
+			errs <- err
+			return
+		}
+		conns <- conn
+	}()
+	client, err := minecraft.Dialer{ErrorLog: minecraft.NopLogger()}.DialTimeout("memory", t.Name(), testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	select {
+	case server = <-conns:
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+	return client, server
+}
+
+// readTexts reads the messages of n Text packets from the Conn passed.
+func readTexts(t *testing.T, conn *minecraft.Conn, n int) []string {
+	_ = conn.SetReadDeadline(time.Now().Add(testTimeout))
+	var messages []string
+	for len(messages) < n {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading text packet: %v", err)
+		}
+		if text, ok := pk.(*packet.Text); ok {
+			messages = append(messages, text.Message)
+		}
+	}
+	return messages
+}
+
+func TestWriterReader(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf)
+	if err != nil {
+		t.Fatalf("error creating writer: %v", err)
+	}
+	listener := &minecraft.Listener{PacketFunc: w.PacketFunc}
+	defer listener.Close()
+	client, server := connect(t, listener, minecraft.GameData{WorldName: "capture world", EntityRuntimeID: 1})
+	defer server.Close()
+
+	_ = client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "serverbound"})
+	readTexts(t, server, 1)
+	_ = server.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "clientbound"})
+	readTexts(t, client, 1)
+	_ = client.Close()
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing writer: %v", err)
+	}
+
+	r, err := NewReader(buf, nil)
+	if err != nil {
+		t.Fatalf("error creating reader: %v", err)
+	}
+	texts := map[string]Direction{}
+	var ids []uint32
+	for {
+		entry, pk, err := r.ReadPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading packet: %v", err)
+		}
+		if entry.Connection != 0 {
+			t.Fatalf("packet recorded for connection %v, expected only connection 0", entry.Connection)
+		}
+		ids = append(ids, entry.Header.PacketID)
+		if text, ok := pk.(*packet.Text); ok {
+			texts[text.Message] = entry.Direction
+		}
+	}
+	if len(ids) == 0 || ids[0] != packet.IDLogin {
+		t.Fatalf("expected the Login packet to be recorded first, got packets %v", ids)
+	}
+	if dir, ok := texts["serverbound"]; !ok || dir != DirectionServerbound {
+		t.Errorf("serverbound text recorded: %v, with direction %v", ok, dir)
+	}
+	if dir, ok := texts["clientbound"]; !ok || dir != DirectionClientbound {
+		t.Errorf("clientbound text recorded: %v, with direction %v", ok, dir)
+	}
+
+	conn, ok := r.Connection(0)
+	if !ok {
+		t.Fatalf("connection 0 was not recorded")
+	}
+	if conn.ClientAddress != client.LocalAddr().String() || conn.ServerAddress != client.RemoteAddr().String() {
+		t.Errorf("recorded client %v and server %v, expected %v and %v", conn.ClientAddress, conn.ServerAddress, client.LocalAddr(), client.RemoteAddr())
+	}
+	if conn.Protocol != protocol.CurrentProtocol || conn.Version != protocol.CurrentVersion {
+		t.Errorf("recorded protocol %v and version %v, expected %v and %v", conn.Protocol, conn.Version, protocol.CurrentProtocol, protocol.CurrentVersion)
+	}
+	if conn.GameData.WorldName != "capture world" {
+		t.Errorf("recorded world name %q, expected %q", conn.GameData.WorldName, "capture world")
+	}
+}
+
+// testAddr is a net.Addr used to record packets using a PacketFunc directly.
+type testAddr string
+
+// Network ...
+func (testAddr) Network() string {
+	return "test"
+}
+
+// String ...
+func (addr testAddr) String() string {
+	return string(addr)
+}
+
+// recorder is a value that records packets passed to its PacketFunc, such as a Writer or a PcapWriter.
+type recorder interface {
+	PacketFunc(header packet.Header, payload []byte, src, dst net.Addr)
+}
+
+// record records the packet passed using the PacketFunc of the recorder passed, as if sent from src to dst.
+func record(w recorder, pk packet.Packet, src, dst net.Addr) {
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(buf)
+	w.PacketFunc(packet.Header{PacketID: pk.ID()}, buf.Bytes(), src, dst)
+}
+
+func TestWriterDisconnect(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, _ := NewWriter(buf)
+	client, server := testAddr("client"), testAddr("server")
+
+	record(w, &packet.Login{}, client, server)
+	record(w, &packet.Disconnect{}, server, client)
+	// The addresses of a connection that was disconnected may be used by a new connection.
+	record(w, &packet.Text{}, client, server)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing writer: %v", err)
+	}
+
+	r, _ := NewReader(buf, nil)
+	for i, expected := range []uint32{0, 0, 1} {
+		entry, err := r.Next()
+		if err != nil {
+			t.Fatalf("error reading packet %v: %v", i, err)
+		}
+		if entry.Connection != expected {
+			t.Errorf("packet %v recorded for connection %v, expected %v", i, entry.Connection, expected)
+		}
+	}
+}
+
+func TestReplayerSpeed(t *testing.T) {
+	const interval = time.Millisecond * 150
+
+	buf := bytes.NewBuffer(nil)
+	w, _ := NewWriter(buf)
+	clientAddr, serverAddr := testAddr("client"), testAddr("server")
+	record(w, &packet.Login{}, clientAddr, serverAddr)
+	record(w, &packet.PlayStatus{Status: packet.PlayStatusPlayerSpawn}, serverAddr, clientAddr)
+	for _, msg := range []string{"a", "b", "c"} {
+		record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: msg}, serverAddr, clientAddr)
+		time.Sleep(interval)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing writer: %v", err)
+	}
+	data := buf.Bytes()
+
+	listener := &minecraft.Listener{}
+	defer listener.Close()
+	client, server := connect(t, listener, minecraft.GameData{EntityRuntimeID: 1})
+	defer client.Close()
+	defer server.Close()
+
+	// The three packets are recorded two intervals apart from the first to the last, so replaying them
+	// twice as fast takes one interval, and replaying them without delay takes no time at all.
+	for _, test := range []struct {
+		speed    float64
+		min, max time.Duration
+	}{
+		{speed: 2, min: interval * 3 / 4, max: interval * 2},
+		{speed: -1, min: 0, max: interval / 2},
+	} {
+		r, _ := NewReader(bytes.NewReader(data), nil)
+		start := time.Now()
+		if err := (Replayer{Speed: test.speed}).Replay(context.Background(), r, server); err != nil {
+			t.Fatalf("error replaying at speed %v: %v", test.speed, err)
+		}
+		if d := time.Since(start); d < test.min || d > test.max {
+			t.Errorf("replaying at speed %v took %v, expected between %v and %v", test.speed, d, test.min, test.max)
+		}
+		if messages := readTexts(t, client, 3); messages[0] != "a" || messages[1] != "b" || messages[2] != "c" {
+			t.Errorf("client read messages %v replayed at speed %v, expected [a b c]", messages, test.speed)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package capture implements the recording of Minecraft connections to captures, the reading of packets from
+// captures and the replaying of recorded connections.
+//
+// Captures are written in the gophertunnel capture format, for which the .gtcap file extension is used. A
+// capture starts with the magic bytes 'GTCAP', followed by a single byte holding the version of the format,
+// which is currently 1. The rest of the capture consists of records, which are encoded as follows:
+//
+//	Type       byte       The type of the record: 1 for a connection record, 2 for a packet record.
+//	Connection varuint32  The number of the connection that the record belongs to, starting at 0.
+//	Time       varint64   The time at which the record was made, in nanoseconds since the Unix epoch.
+//	Length     varuint32  The length of the body of the record in bytes.
+//	Body       [Length]byte
+//
+// Varints and strings are encoded as they are in the Minecraft protocol: Strings are prefixed with their
+// length as a varuint32. Records of an unknown type must be skipped, so that new types may be added without
+// changing the version of the format.
+//
+// A connection record is written before the first packet of every connection. Its body holds:
+//
+//	ClientAddress string    The address of the client of the connection.
+//	ServerAddress string    The address of the server of the connection.
+//	Protocol      varint32  The protocol number of the connection, as sent by the client in its Login packet.
+//	                        The protocol is negotiated during login: If the server does not accept it, the
+//	                        connection is closed.
+//	Version       string    The Minecraft version of the client, such as '1.16.0'.
+//
+// A packet record is written for every packet sent over a connection. Its body holds:
+//
+//	Direction byte       The direction of the packet: 0 if the server sent it to the client, 1 if the client
+//	                     sent it to the server.
+//	Header    varuint32  The header of the packet, holding its ID and the IDs of the sub-clients it was sent
+//	                     by and to, as encoded in the Minecraft protocol.
+//	Payload   []byte     The rest of the body, which is the payload of the packet as sent over the
+//	                     connection, encoded using the protocol of the connection.
+//
+// The GameData of a connection is held by the StartGame packet that the server sent. A Reader decodes it into
+// the GameData of the Connection once it reads the packet.
+//
+// Connections may also be recorded in the pcapng format using a PcapWriter, so that the decrypted packets may
+// be inspected using tools such as Wireshark.
+package capture
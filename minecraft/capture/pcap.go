@@ -0,0 +1,385 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// pcapBlockSectionHeader, pcapBlockInterfaceDescription and pcapBlockEnhancedPacket are the types of the
+	// pcapng blocks written by a PcapWriter.
+	pcapBlockSectionHeader        = 0x0a0d0d0a
+	pcapBlockInterfaceDescription = 0x00000001
+	pcapBlockEnhancedPacket       = 0x00000006
+
+	// pcapLinkTypeRaw is the link type of raw IPv4 and IPv6 packets, without a link layer header.
+	pcapLinkTypeRaw = 101
+
+	pcapOptionEnd     = 0
+	pcapOptionComment = 1
+	// pcapOptionTimestampResolution is the if_tsresol option of an interface. A value of 9 means that
+	// timestamps are in nanoseconds.
+	pcapOptionTimestampResolution = 9
+
+	// syntheticPort is the port used for addresses that have no port, such as those of the memory network.
+	syntheticPort = 19132
+)
+
+const (
+	// raknetDatagram is the header of a RakNet datagram holding frames, which has only the bit set that marks
+	// it as a valid datagram.
+	raknetDatagram = 0x80
+	// raknetReliableOrdered is the reliability of the frames written, shifted into its position in the flags
+	// of a frame.
+	raknetReliableOrdered = 3 << 5
+	// raknetSplit is the flag of a frame holding one fragment of a batch that was split over multiple frames.
+	raknetSplit = 0x10
+	// maxFragmentSize is the maximum size of a batch, or a fragment of it, that is held by a single frame.
+	// Larger batches are split over multiple frames, each sent in its own datagram, like RakNet does for
+	// batches that exceed the MTU of a connection.
+	maxFragmentSize = 1400
+)
+
+// PcapWriter writes the packets of one or more connections to an io.Writer in the pcapng format, so that
+// they may be inspected using tools such as Wireshark. Like a Writer, its PacketFunc method may be set as the
+// PacketFunc of a minecraft.Dialer or minecraft.Listener. Packets passed to a PacketFunc are already decrypted
+// and decompressed, so the key of the connection is not needed to read them.
+// Every packet is written as a batch holding only that packet, without compression. The batch starts with
+// the 0xfe byte, like the batches sent by Minecraft, and is sent in a reliable ordered RakNet frame, held by
+// a RakNet datagram that is the payload of a synthetic IP/UDP packet sent between the addresses of the
+// connection. Batches larger than 1400 bytes are split over multiple frames and datagrams, which RakNet
+// dissectors reassemble. Every packet in the capture has a comment naming the type of the packet.
+// Addresses that are not IP addresses, such as those of the memory network, are given an IP address in the
+// 10.0.0.0/8 range. Records are buffered before they are written, so Flush must be called once recording is
+// done.
+type PcapWriter struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	err error
+
+	pool packet.Pool
+	// addresses holds the synthetic IP addresses given to addresses that were not IP addresses.
+	addresses map[string]net.IP
+	// streams holds the RakNet state of the datagrams sent from one address to another, by the source and
+	// destination address. The streams of a connection are removed once a Disconnect packet is sent over it.
+	streams map[[2]string]*raknetStream
+
+	batch, datagram, data, block *bytes.Buffer
+}
+
+// raknetStream holds the indices of the RakNet datagrams and frames sent from one address to another.
+type raknetStream struct {
+	sequence, messageIndex, orderIndex uint32
+	splitID                            uint16
+}
+
+// NewPcapWriter returns a PcapWriter that writes a pcapng capture to the io.Writer passed. The section header
+// and the description of the interface of the capture are written immediately, and an error is returned if
+// writing them fails. The packet.Pool passed is used to name the packets written. If it is nil,
+// packet.NewPool() is used.
+func NewPcapWriter(w io.Writer, pool packet.Pool) (*PcapWriter, error) {
+	if pool == nil {
+		pool = packet.NewPool()
+	}
+	writer := &PcapWriter{
+		w:         bufio.NewWriter(w),
+		pool:      pool,
+		addresses: make(map[string]net.IP),
+		streams:   make(map[[2]string]*raknetStream),
+		batch:     bytes.NewBuffer(make([]byte, 0, 4096)),
+		datagram:  bytes.NewBuffer(make([]byte, 0, 2048)),
+		data:      bytes.NewBuffer(make([]byte, 0, 4096)),
+		block:     bytes.NewBuffer(make([]byte, 0, 4096)),
+	}
+	// Section Header Block: Byte order magic, version 1.0 and an unspecified section length.
+	_ = binary.Write(writer.data, binary.LittleEndian, uint32(0x1a2b3c4d))
+	_ = binary.Write(writer.data, binary.LittleEndian, uint16(1))
+	_ = binary.Write(writer.data, binary.LittleEndian, uint16(0))
+	_ = binary.Write(writer.data, binary.LittleEndian, int64(-1))
+	writer.writeBlock(pcapBlockSectionHeader)
+
+	// Interface Description Block: Raw IP packets without a snapshot length, with timestamps in nanoseconds.
+	writer.data.Reset()
+	_ = binary.Write(writer.data, binary.LittleEndian, uint16(pcapLinkTypeRaw))
+	_ = binary.Write(writer.data, binary.LittleEndian, uint16(0))
+	_ = binary.Write(writer.data, binary.LittleEndian, uint32(0))
+	writeOption(writer.data, pcapOptionTimestampResolution, []byte{9})
+	writeOption(writer.data, pcapOptionEnd, nil)
+	writer.writeBlock(pcapBlockInterfaceDescription)
+
+	if writer.err != nil {
+		return nil, writer.err
+	}
+	return writer, nil
+}
+
+// PacketFunc writes the packet passed, which was sent from the source to the destination address passed. It
+// has the signature of the PacketFunc fields of minecraft.Dialer and minecraft.Listener.
+// Errors that occur while writing are returned by the next call to Flush, after which nothing is written
+// anymore.
+func (w *PcapWriter) PacketFunc(header packet.Header, payload []byte, src, dst net.Addr) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+
+	// The batch holds the header 0xfe, followed by the packet prefixed with its length.
+	w.data.Reset()
+	_ = header.Write(w.data)
+	_, _ = w.data.Write(payload)
+	w.batch.Reset()
+	_ = w.batch.WriteByte(0xfe)
+	_ = protocol.WriteVaruint32(w.batch, uint32(w.data.Len()))
+	_, _ = w.batch.Write(w.data.Bytes())
+
+	srcIP, srcPort := w.ipPort(src)
+	dstIP, dstPort := w.ipPort(dst)
+	key := [2]string{src.String(), dst.String()}
+	stream, ok := w.streams[key]
+	if !ok {
+		stream = &raknetStream{}
+		w.streams[key] = stream
+	}
+
+	batch, name := w.batch.Bytes(), w.name(header)
+	count := (len(batch) + maxFragmentSize - 1) / maxFragmentSize
+	for i := 0; i < count; i++ {
+		fragment := batch[i*maxFragmentSize:]
+		if len(fragment) > maxFragmentSize {
+			fragment = fragment[:maxFragmentSize]
+		}
+		comment := name
+		if count > 1 {
+			comment += fmt.Sprintf(", fragment %v of %v", i+1, count)
+		}
+		stream.writeDatagram(w.datagram, fragment, i, count)
+		w.writePacket(now, udpPacket(srcIP, dstIP, srcPort, dstPort, w.datagram.Bytes()), comment)
+	}
+	stream.orderIndex++
+	if count > 1 {
+		stream.splitID++
+	}
+
+	if header.PacketID == packet.IDDisconnect {
+		// The connection is closed after a Disconnect packet, so we no longer need its streams.
+		delete(w.streams, key)
+		delete(w.streams, [2]string{key[1], key[0]})
+	}
+}
+
+// writePacket writes an Enhanced Packet Block holding the IP packet passed, with the time and comment passed.
+// writePacket must only be called while holding the mutex of the PcapWriter.
+func (w *PcapWriter) writePacket(t time.Time, ipPacket []byte, comment string) {
+	w.data.Reset()
+	ts := uint64(t.UnixNano())
+	_ = binary.Write(w.data, binary.LittleEndian, uint32(0))
+	_ = binary.Write(w.data, binary.LittleEndian, uint32(ts>>32))
+	_ = binary.Write(w.data, binary.LittleEndian, uint32(ts))
+	_ = binary.Write(w.data, binary.LittleEndian, uint32(len(ipPacket)))
+	_ = binary.Write(w.data, binary.LittleEndian, uint32(len(ipPacket)))
+	_, _ = w.data.Write(ipPacket)
+	_, _ = w.data.Write(make([]byte, padding(len(ipPacket))))
+	writeOption(w.data, pcapOptionComment, []byte(comment))
+	writeOption(w.data, pcapOptionEnd, nil)
+	w.writeBlock(pcapBlockEnhancedPacket)
+}
+
+// writeDatagram writes a RakNet datagram holding a single reliable ordered frame with the content passed to
+// the buffer passed. If count is larger than 1, the content is the fragment with the index passed of a batch
+// that was split into count fragments.
+func (s *raknetStream) writeDatagram(buf *bytes.Buffer, content []byte, index, count int) {
+	buf.Reset()
+	_ = buf.WriteByte(raknetDatagram)
+	writeUint24(buf, s.sequence)
+	s.sequence++
+
+	flags := byte(raknetReliableOrdered)
+	if count > 1 {
+		flags |= raknetSplit
+	}
+	_ = buf.WriteByte(flags)
+	// The length of the content is written in bits.
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(content)*8))
+	writeUint24(buf, s.messageIndex)
+	s.messageIndex++
+	writeUint24(buf, s.orderIndex)
+	// The order channel, which is always 0 for Minecraft.
+	_ = buf.WriteByte(0)
+	if count > 1 {
+		_ = binary.Write(buf, binary.BigEndian, uint32(count))
+		_ = binary.Write(buf, binary.BigEndian, s.splitID)
+		_ = binary.Write(buf, binary.BigEndian, uint32(index))
+	}
+	_, _ = buf.Write(content)
+}
+
+// writeUint24 writes the lower 24 bits of the value passed to the buffer in little endian byte order, as
+// RakNet encodes sequence numbers and indices.
+func writeUint24(buf *bytes.Buffer, v uint32) {
+	_, _ = buf.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+}
+
+// Flush writes all packets buffered to the underlying io.Writer. If an error occurred while writing a packet
+// earlier, that error is returned.
+func (w *PcapWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.w.Flush(); err != nil {
+		w.err = fmt.Errorf("error flushing pcap capture: %v", err)
+	}
+	return w.err
+}
+
+// name returns the comment written for a packet with the header passed, which names the type of the packet
+// and the sub-clients it was sent by and to, if any.
+func (w *PcapWriter) name(header packet.Header) string {
+	name := fmt.Sprintf("Unknown (ID %v)", header.PacketID)
+	if pk, ok := w.pool[header.PacketID]; ok {
+		name = fmt.Sprintf("%v (ID %v)", reflect.TypeOf(pk).Elem().Name(), header.PacketID)
+	}
+	if header.SenderSubClient != 0 || header.TargetSubClient != 0 {
+		name += fmt.Sprintf(", sub-client %v to %v", header.SenderSubClient, header.TargetSubClient)
+	}
+	return name
+}
+
+// ipPort returns the IP address and port of the net.Addr passed. If the address is not an IP address, a
+// synthetic IP address is returned, which is the same for every call with the same address.
+func (w *PcapWriter) ipPort(addr net.Addr) (net.IP, uint16) {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP, uint16(addr.Port)
+	case *net.TCPAddr:
+		return addr.IP, uint16(addr.Port)
+	}
+	s := addr.String()
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+				return ip, uint16(p)
+			}
+		}
+	}
+	ip, ok := w.addresses[s]
+	if !ok {
+		n := len(w.addresses) + 1
+		ip = net.IPv4(10, byte(n>>16), byte(n>>8), byte(n))
+		w.addresses[s] = ip
+	}
+	return ip, syntheticPort
+}
+
+// writeBlock writes a pcapng block with the type passed, holding the current content of the data buffer.
+// writeBlock must only be called while holding the mutex of the PcapWriter, or before it is returned.
+func (w *PcapWriter) writeBlock(blockType uint32) {
+	length := uint32(w.data.Len() + 12)
+	w.block.Reset()
+	_ = binary.Write(w.block, binary.LittleEndian, blockType)
+	_ = binary.Write(w.block, binary.LittleEndian, length)
+	_, _ = w.block.Write(w.data.Bytes())
+	_ = binary.Write(w.block, binary.LittleEndian, length)
+	if _, err := w.w.Write(w.block.Bytes()); err != nil {
+		w.err = fmt.Errorf("error writing pcap block: %v", err)
+	}
+}
+
+// writeOption writes a pcapng option with the code and value passed to the buffer, padded to 32 bits.
+func writeOption(buf *bytes.Buffer, code uint16, value []byte) {
+	_ = binary.Write(buf, binary.LittleEndian, code)
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(value)))
+	_, _ = buf.Write(value)
+	_, _ = buf.Write(make([]byte, padding(len(value))))
+}
+
+// padding returns the amount of bytes needed to pad data of the length passed to 32 bits.
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
+
+// udpPacket returns an IP packet holding a UDP packet with the payload passed, sent from the source to the
+// destination address passed. An IPv4 packet is returned if both addresses are IPv4 addresses. Otherwise, an
+// IPv6 packet is returned, in which IPv4 addresses are mapped to IPv6 addresses.
+func udpPacket(srcIP, dstIP net.IP, srcPort, dstPort uint16, payload []byte) []byte {
+	udpLength := 8 + len(payload)
+	src4, dst4 := srcIP.To4(), dstIP.To4()
+
+	var b, pseudoHeader []byte
+	if src4 != nil && dst4 != nil {
+		b = make([]byte, 20, 20+udpLength)
+		b[0] = 0x45
+		binary.BigEndian.PutUint16(b[2:], uint16(20+udpLength))
+		// Don't fragment, a TTL of 64 and the UDP protocol.
+		b[6], b[8], b[9] = 0x40, 64, 17
+		copy(b[12:16], src4)
+		copy(b[16:20], dst4)
+		binary.BigEndian.PutUint16(b[10:], checksum(0, b))
+
+		pseudoHeader = make([]byte, 12)
+		copy(pseudoHeader, b[12:20])
+		pseudoHeader[9] = 17
+		binary.BigEndian.PutUint16(pseudoHeader[10:], uint16(udpLength))
+	} else {
+		b = make([]byte, 40, 40+udpLength)
+		b[0] = 0x60
+		binary.BigEndian.PutUint16(b[4:], uint16(udpLength))
+		// The UDP protocol and a hop limit of 64.
+		b[6], b[7] = 17, 64
+		copy(b[8:24], srcIP.To16())
+		copy(b[24:40], dstIP.To16())
+
+		pseudoHeader = make([]byte, 40)
+		copy(pseudoHeader, b[8:40])
+		binary.BigEndian.PutUint32(pseudoHeader[32:], uint32(udpLength))
+		pseudoHeader[39] = 17
+	}
+	udp := make([]byte, 8, udpLength)
+	binary.BigEndian.PutUint16(udp[0:], srcPort)
+	binary.BigEndian.PutUint16(udp[2:], dstPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpLength))
+	udp = append(udp, payload...)
+	// A checksum of 0 means that no checksum was calculated, so a checksum of 0 is written as 0xffff.
+	sum := checksum(checksumSum(pseudoHeader), udp)
+	if sum == 0 {
+		sum = 0xffff
+	}
+	binary.BigEndian.PutUint16(udp[6:], sum)
+	return append(b, udp...)
+}
+
+// checksum returns the internet checksum (RFC 1071) of the data passed, continuing from the partial sum
+// passed.
+func checksum(sum uint32, data []byte) uint16 {
+	sum += checksumSum(data)
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+// checksumSum returns the sum of the 16-bit words in the data passed, as used to calculate the internet
+// checksum.
+func checksumSum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	return sum
+}
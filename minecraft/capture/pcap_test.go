@@ -0,0 +1,241 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// pcapPacket is a packet parsed from a pcapng capture written by a PcapWriter.
+type pcapPacket struct {
+	src, dst         net.IP
+	srcPort, dstPort uint16
+	comment          string
+	// sequence is the sequence number of the RakNet datagram, and frame the single frame it holds.
+	sequence uint32
+	frame    raknetFrame
+}
+
+// raknetFrame is a reliable ordered RakNet frame parsed from a datagram.
+type raknetFrame struct {
+	messageIndex, orderIndex uint32
+	split                    bool
+	splitCount, splitIndex   uint32
+	splitID                  uint16
+	content                  []byte
+}
+
+// parsePcap parses the pcapng capture passed, checking the checksums of every IP and UDP packet in it.
+func parsePcap(data []byte) ([]pcapPacket, error) {
+	var packets []pcapPacket
+	for i := 0; len(data) != 0; i++ {
+		if len(data) < 12 {
+			return nil, fmt.Errorf("block %v: %v bytes left, expected at least 12", i, len(data))
+		}
+		blockType, length := binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[4:])
+		if length%4 != 0 || int(length) > len(data) || binary.LittleEndian.Uint32(data[length-4:]) != length {
+			return nil, fmt.Errorf("block %v: invalid length %v", i, length)
+		}
+		body := data[8 : length-4]
+		data = data[length:]
+
+		switch {
+		case i == 0:
+			if blockType != pcapBlockSectionHeader || binary.LittleEndian.Uint32(body) != 0x1a2b3c4d {
+				return nil, fmt.Errorf("capture does not start with a section header block")
+			}
+		case i == 1:
+			if blockType != pcapBlockInterfaceDescription || binary.LittleEndian.Uint16(body) != pcapLinkTypeRaw {
+				return nil, fmt.Errorf("section header block is not followed by a raw interface description block")
+			}
+		case blockType == pcapBlockEnhancedPacket:
+			capturedLength, originalLength := binary.LittleEndian.Uint32(body[12:]), binary.LittleEndian.Uint32(body[16:])
+			if capturedLength != originalLength {
+				return nil, fmt.Errorf("block %v: packet truncated from %v to %v bytes", i, originalLength, capturedLength)
+			}
+			pk, err := parseIP(body[20 : 20+capturedLength])
+			if err != nil {
+				return nil, fmt.Errorf("block %v: %v", i, err)
+			}
+			options := body[20+capturedLength+uint32(padding(int(capturedLength))):]
+			if binary.LittleEndian.Uint16(options) == pcapOptionComment {
+				pk.comment = string(options[4 : 4+binary.LittleEndian.Uint16(options[2:])])
+			}
+			packets = append(packets, pk)
+		default:
+			return nil, fmt.Errorf("block %v: unexpected block type %x", i, blockType)
+		}
+	}
+	return packets, nil
+}
+
+// parseIP parses an IPv4 or IPv6 packet holding a UDP packet, checking its checksums, and parses the RakNet
+// datagram it holds.
+func parseIP(b []byte) (pcapPacket, error) {
+	var pk pcapPacket
+	var pseudoHeader, udp []byte
+	switch b[0] >> 4 {
+	case 4:
+		if checksum(0, b[:20]) != 0 {
+			return pk, fmt.Errorf("invalid IPv4 header checksum %x", b[10:12])
+		}
+		if int(binary.BigEndian.Uint16(b[2:])) != len(b) || b[9] != 17 {
+			return pk, fmt.Errorf("invalid IPv4 header %x", b[:20])
+		}
+		pk.src, pk.dst, udp = net.IP(b[12:16]), net.IP(b[16:20]), b[20:]
+		pseudoHeader = append(append([]byte(nil), b[12:20]...), 0, 17, 0, 0)
+		binary.BigEndian.PutUint16(pseudoHeader[10:], uint16(len(udp)))
+	case 6:
+		if int(binary.BigEndian.Uint16(b[4:]))+40 != len(b) || b[6] != 17 {
+			return pk, fmt.Errorf("invalid IPv6 header %x", b[:40])
+		}
+		pk.src, pk.dst, udp = net.IP(b[8:24]), net.IP(b[24:40]), b[40:]
+		pseudoHeader = append(append([]byte(nil), b[8:40]...), 0, 0, 0, 0, 0, 0, 0, 17)
+		binary.BigEndian.PutUint32(pseudoHeader[32:], uint32(len(udp)))
+	default:
+		return pk, fmt.Errorf("unknown IP version %v", b[0]>>4)
+	}
+	if int(binary.BigEndian.Uint16(udp[4:])) != len(udp) {
+		return pk, fmt.Errorf("UDP length %v does not match %v bytes", binary.BigEndian.Uint16(udp[4:]), len(udp))
+	}
+	if binary.BigEndian.Uint16(udp[6:]) == 0 || checksum(checksumSum(pseudoHeader), udp) != 0 {
+		return pk, fmt.Errorf("invalid UDP checksum %x", udp[6:8])
+	}
+	pk.srcPort, pk.dstPort = binary.BigEndian.Uint16(udp), binary.BigEndian.Uint16(udp[2:])
+
+	datagram := udp[8:]
+	if datagram[0] != raknetDatagram {
+		return pk, fmt.Errorf("invalid RakNet datagram header %x", datagram[0])
+	}
+	pk.sequence = readUint24(datagram[1:])
+	flags := datagram[4]
+	if flags&^raknetSplit != raknetReliableOrdered {
+		return pk, fmt.Errorf("unexpected RakNet frame flags %x", flags)
+	}
+	f := raknetFrame{
+		messageIndex: readUint24(datagram[7:]),
+		orderIndex:   readUint24(datagram[10:]),
+		split:        flags&raknetSplit != 0,
+	}
+	content := datagram[14:]
+	if f.split {
+		f.splitCount, f.splitID, f.splitIndex = binary.BigEndian.Uint32(content), binary.BigEndian.Uint16(content[4:]), binary.BigEndian.Uint32(content[6:])
+		content = content[10:]
+	}
+	if bits := int(binary.BigEndian.Uint16(datagram[5:])); bits != len(content)*8 {
+		return pk, fmt.Errorf("RakNet frame length of %v bits does not match %v bytes", bits, len(content))
+	}
+	f.content = content
+	pk.frame = f
+	return pk, nil
+}
+
+// readUint24 reads a little endian 24-bit integer from the data passed.
+func readUint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// decodeBatches reassembles the batches held by the frames of the packets passed and decodes the packet in
+// each of them.
+func decodeBatches(packets []pcapPacket) ([]packet.Packet, error) {
+	var decoded []packet.Packet
+	var batch []byte
+	for i, pk := range packets {
+		batch = append(batch, pk.frame.content...)
+		if pk.frame.split && pk.frame.splitIndex != pk.frame.splitCount-1 {
+			continue
+		}
+		buf := protocol.NewReader(bytes.NewBuffer(batch), protocol.DecodeLimits{})
+		batch = nil
+
+		var length uint32
+		if b, _ := buf.ReadByte(); b != 0xfe {
+			return nil, fmt.Errorf("packet %v: batch does not start with 0xfe", i)
+		}
+		if err := protocol.Varuint32(buf, &length); err != nil || int(length) != buf.Len() {
+			return nil, fmt.Errorf("packet %v: batch length %v does not match %v bytes", i, length, buf.Len())
+		}
+		var header packet.Header
+		if err := header.Read(buf); err != nil {
+			return nil, fmt.Errorf("packet %v: %v", i, err)
+		}
+		// A new pool is created for every packet, so that the packets decoded are not re-used.
+		p := packet.NewPool()[header.PacketID]
+		if err := p.Unmarshal(buf); err != nil {
+			return nil, fmt.Errorf("packet %v: error decoding %T: %v", i, p, err)
+		}
+		decoded = append(decoded, p)
+	}
+	return decoded, nil
+}
+
+func TestPcapWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewPcapWriter(buf, nil)
+	if err != nil {
+		t.Fatalf("error creating pcap writer: %v", err)
+	}
+	client, server := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 50000}, &net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 19132}
+	large := strings.Repeat("large message ", 250)
+
+	record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: "small"}, server, client)
+	record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: large}, server, client)
+	record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: "serverbound"}, client, server)
+	record(w, &packet.Disconnect{Message: "bye"}, server, client)
+	// Addresses that are not IPv4 addresses are written in IPv6 packets, or given a synthetic IP address.
+	record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: "ipv6"}, &net.UDPAddr{IP: net.IPv6loopback, Port: 1}, server)
+	record(w, &packet.Text{TextType: packet.TextTypeRaw, Message: "memory"}, testAddr("memory"), testAddr("other"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing pcap writer: %v", err)
+	}
+
+	packets, err := parsePcap(buf.Bytes())
+	if err != nil {
+		t.Fatalf("error parsing pcap capture: %v", err)
+	}
+	// The large message is split over three fragments.
+	if len(packets) != 8 {
+		t.Fatalf("parsed %v packets, expected %v", len(packets), 8)
+	}
+	decoded, err := decodeBatches(packets)
+	if err != nil {
+		t.Fatalf("error decoding batches: %v", err)
+	}
+	var messages []string
+	for _, pk := range decoded {
+		switch pk := pk.(type) {
+		case *packet.Text:
+			messages = append(messages, pk.Message)
+		case *packet.Disconnect:
+			messages = append(messages, pk.Message)
+		}
+	}
+	if expected := []string{"small", large, "serverbound", "bye", "ipv6", "memory"}; fmt.Sprint(messages) != fmt.Sprint(expected) {
+		t.Fatalf("decoded messages %q, expected %q", messages, expected)
+	}
+
+	first, fragment, serverbound := packets[0], packets[2], packets[4]
+	if !first.src.Equal(server.IP) || first.srcPort != 19132 || !first.dst.Equal(client.IP) || first.dstPort != 50000 {
+		t.Errorf("packet sent from %v:%v to %v:%v, expected %v to %v", first.src, first.srcPort, first.dst, first.dstPort, server, client)
+	}
+	if first.comment != "Text (ID 9)" || fragment.comment != "Text (ID 9), fragment 2 of 3" {
+		t.Errorf("unexpected comments %q and %q", first.comment, fragment.comment)
+	}
+	// The indices of datagrams and frames are counted separately for both directions.
+	if fragment.sequence != 2 || fragment.frame.messageIndex != 2 || fragment.frame.orderIndex != 1 || fragment.frame.splitIndex != 1 {
+		t.Errorf("fragment has sequence number %v, message index %v, order index %v and split index %v, expected 2, 2, 1 and 1",
+			fragment.sequence, fragment.frame.messageIndex, fragment.frame.orderIndex, fragment.frame.splitIndex)
+	}
+	if serverbound.sequence != 0 || serverbound.frame.orderIndex != 0 {
+		t.Errorf("first serverbound packet has sequence number %v and order index %v, expected 0 and 0", serverbound.sequence, serverbound.frame.orderIndex)
+	}
+	if packets[6].src.To4() != nil || !packets[7].src.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("got source addresses %v and %v, expected an IPv6 address and 10.0.0.1", packets[6].src, packets[7].src)
+	}
+}
@@ -0,0 +1,224 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// maxRecordSize is the maximum size in bytes of the body of a record that a Reader reads. Packets are never
+// larger than the batches they are sent in, which are limited to a few megabytes.
+const maxRecordSize = 1024 * 1024 * 64
+
+// Connection holds information on a connection recorded in a capture.
+type Connection struct {
+	// ID is the number of the connection in the capture.
+	ID uint32
+	// Start is the time at which the connection was first recorded.
+	Start time.Time
+	// ClientAddress and ServerAddress are the addresses of the client and the server of the connection.
+	ClientAddress, ServerAddress string
+	// Protocol is the protocol number of the connection, and Version is the Minecraft version of the client.
+	Protocol int32
+	Version  string
+	// GameData is the GameData that the server sent to the client in the StartGame packet. It is only set
+	// once the StartGame packet of the connection has been read.
+	GameData minecraft.GameData
+}
+
+// Entry is a packet recorded in a capture.
+type Entry struct {
+	// Connection is the number of the connection that the packet was sent over.
+	Connection uint32
+	// Time is the time at which the packet was recorded.
+	Time time.Time
+	// Direction is the direction in which the packet was sent.
+	Direction Direction
+	// Header is the header of the packet, holding its ID and the sub-clients it was sent by and to.
+	Header packet.Header
+	// Payload is the payload of the packet, encoded using the protocol of the connection.
+	Payload []byte
+}
+
+// Reader reads the packets recorded in a capture. Packets read are decoded into packets from a packet.Pool.
+type Reader struct {
+	r     *bufio.Reader
+	pool  packet.Pool
+	conns map[uint32]*Connection
+}
+
+// NewReader returns a Reader that reads a capture from the io.Reader passed. The header of the capture is read
+// immediately, and an error is returned if it is invalid. Packets read are decoded into the packets of the
+// packet.Pool passed, which should be the pool of the protocol that the connections in the capture used. If
+// the pool is nil, packet.NewPool() is used.
+func NewReader(r io.Reader, pool packet.Pool) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("error reading capture header: %v", err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("invalid capture header %x: expected %x", header[:len(magic)], magic)
+	}
+	if v := header[len(magic)]; v != version {
+		return nil, fmt.Errorf("unsupported capture version %v: expected %v", v, version)
+	}
+	if pool == nil {
+		pool = packet.NewPool()
+	}
+	return &Reader{r: br, pool: pool, conns: make(map[uint32]*Connection)}, nil
+}
+
+// Connection returns the connection in the capture with the ID passed. Only connections of which the record
+// was read are returned: The connection of an Entry returned by Next is always known.
+func (r *Reader) Connection(id uint32) (Connection, bool) {
+	conn, ok := r.conns[id]
+	if !ok {
+		return Connection{}, false
+	}
+	return *conn, true
+}
+
+// Next reads the next packet recorded in the capture. Other records read, such as those of connections, are
+// processed so that the information they hold may be obtained using Reader.Connection. Next returns io.EOF
+// once the end of the capture is reached.
+func (r *Reader) Next() (Entry, error) {
+	for {
+		recordType, err := r.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return Entry{}, io.EOF
+			}
+			return Entry{}, fmt.Errorf("error reading record type: %v", err)
+		}
+		id, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return Entry{}, fmt.Errorf("error reading record connection: %v", unexpectedEOF(err))
+		}
+		if id > math.MaxUint32 {
+			return Entry{}, fmt.Errorf("record connection %v exceeds the maximum of %v", id, uint32(math.MaxUint32))
+		}
+		t, err := binary.ReadVarint(r.r)
+		if err != nil {
+			return Entry{}, fmt.Errorf("error reading record time: %v", unexpectedEOF(err))
+		}
+		length, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return Entry{}, fmt.Errorf("error reading record length: %v", unexpectedEOF(err))
+		}
+		if length > maxRecordSize {
+			return Entry{}, fmt.Errorf("record of %v bytes exceeds the maximum of %v bytes", length, maxRecordSize)
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r.r, body); err != nil {
+			return Entry{}, fmt.Errorf("error reading record body: %v", unexpectedEOF(err))
+		}
+
+		switch recordType {
+		case recordConnection:
+			if err := r.readConnection(uint32(id), time.Unix(0, t), &protocol.Reader{Buffer: bytes.NewBuffer(body)}); err != nil {
+				return Entry{}, fmt.Errorf("error reading connection record: %v", err)
+			}
+		case recordPacket:
+			entry, err := r.readPacket(uint32(id), time.Unix(0, t), &protocol.Reader{Buffer: bytes.NewBuffer(body)})
+			if err != nil {
+				return Entry{}, fmt.Errorf("error reading packet record: %v", err)
+			}
+			return entry, nil
+		}
+		// Records of an unknown type are skipped.
+	}
+}
+
+// ReadPacket reads the next packet recorded in the capture, like Next, and decodes it into a new packet of
+// the type found in the packet.Pool of the Reader. If the pool holds no packet with the ID of the packet read,
+// a *packet.Unknown is returned.
+func (r *Reader) ReadPacket() (Entry, packet.Packet, error) {
+	entry, err := r.Next()
+	if err != nil {
+		return entry, nil, err
+	}
+	pk, err := r.Decode(entry)
+	return entry, pk, err
+}
+
+// Decode decodes the payload of the Entry passed into a new packet of the type found in the packet.Pool of
+// the Reader. If the pool holds no packet with the ID of the Entry, a *packet.Unknown is returned.
+func (r *Reader) Decode(entry Entry) (packet.Packet, error) {
+	var pk packet.Packet = &packet.Unknown{PacketID: entry.Header.PacketID}
+	if poolPk, ok := r.pool[entry.Header.PacketID]; ok {
+		pk = reflect.New(reflect.TypeOf(poolPk).Elem()).Interface().(packet.Packet)
+	}
+	buf := protocol.NewReader(bytes.NewBuffer(entry.Payload), protocol.DecodeLimits{})
+	if err := protocol.Decode(buf, func() error { return pk.Unmarshal(buf) }); err != nil {
+		return nil, fmt.Errorf("error decoding packet %T: %v", pk, err)
+	}
+	if buf.Len() != 0 {
+		return nil, fmt.Errorf("%v unread bytes left in packet %T", buf.Len(), pk)
+	}
+	return pk, nil
+}
+
+// readConnection reads the body of a connection record and stores the connection it holds.
+func (r *Reader) readConnection(id uint32, t time.Time, buf *protocol.Reader) error {
+	conn := &Connection{ID: id, Start: t}
+	if err := protocol.String(buf, &conn.ClientAddress); err != nil {
+		return err
+	}
+	if err := protocol.String(buf, &conn.ServerAddress); err != nil {
+		return err
+	}
+	if err := protocol.Varint32(buf, &conn.Protocol); err != nil {
+		return err
+	}
+	if err := protocol.String(buf, &conn.Version); err != nil {
+		return err
+	}
+	r.conns[id] = conn
+	return nil
+}
+
+// readPacket reads the body of a packet record into an Entry. If the packet is a StartGame packet sent by
+// the server, the GameData of the connection is set.
+func (r *Reader) readPacket(id uint32, t time.Time, buf *protocol.Reader) (Entry, error) {
+	conn, ok := r.conns[id]
+	if !ok {
+		return Entry{}, fmt.Errorf("packet of unknown connection %v", id)
+	}
+	entry := Entry{Connection: id, Time: t}
+	dir, err := buf.ReadByte()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Direction = Direction(dir)
+	if err := entry.Header.Read(buf); err != nil {
+		return Entry{}, err
+	}
+	entry.Payload = buf.Bytes()
+
+	if entry.Header.PacketID == packet.IDStartGame && entry.Direction == DirectionClientbound {
+		if pk, err := r.Decode(entry); err == nil {
+			if startGame, ok := pk.(*packet.StartGame); ok {
+				conn.GameData = minecraft.GameDataFromStartGame(startGame)
+			}
+		}
+	}
+	return entry, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if the error passed is io.EOF, as the end of the capture was
+// reached in the middle of a record.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"time"
+)
+
+// Replayer replays a connection recorded in a capture by writing its packets to a minecraft.Conn, at the same
+// pace at which they were recorded or faster.
+type Replayer struct {
+	// Connection is the ID of the connection in the capture that is replayed. The packets of all other
+	// connections are skipped.
+	Connection uint32
+	// Direction is the direction of the packets that are replayed. If DirectionClientbound, which is the
+	// default, the packets that the server sent are written to the Conn, which should then be a Conn obtained
+	// using a minecraft.Listener. If DirectionServerbound, the packets that the client sent are written, in
+	// which case the Conn should be one obtained using minecraft.Dial.
+	Direction Direction
+	// Speed is the speed at which the connection is replayed, relative to the speed at which it was recorded.
+	// A Speed of 2 replays the connection twice as fast as it was recorded. If zero, the connection is
+	// replayed in real time. If negative, packets are written without any delay between them.
+	Speed float64
+	// StartGame specifies if the Replayer should start the game for the Conn using the GameData of the
+	// recorded connection, by calling Conn.StartGame once the StartGame packet of the connection is reached.
+	// It only has an effect if Direction is DirectionClientbound. If false, the Conn must already be spawned
+	// before Replay is called.
+	StartGame bool
+}
+
+// Replay replays the connection of the Replayer found in the capture read by the Reader passed, by writing
+// its packets to the minecraft.Conn passed. Packets sent during the login sequence of the connection, up to
+// the moment at which the player spawned, are skipped, as are packets sent to or by sub-clients.
+// Replay blocks until the end of the capture is reached, after which nil is returned, or until the context
+// passed is cancelled or an error occurs.
+func (r Replayer) Replay(ctx context.Context, reader *Reader, conn *minecraft.Conn) error {
+	speed := r.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	spawned := false
+	var start, first time.Time
+
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				return conn.Flush()
+			}
+			return err
+		}
+		if entry.Connection != r.Connection {
+			continue
+		}
+		if !spawned {
+			spawned, err = r.handleLogin(reader, entry, conn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if entry.Direction != r.Direction || entry.Header.SenderSubClient != 0 || entry.Header.TargetSubClient != 0 {
+			continue
+		}
+		pk, err := reader.Decode(entry)
+		if err != nil {
+			return err
+		}
+
+		if first.IsZero() {
+			start, first = time.Now(), entry.Time
+		} else if speed > 0 {
+			delay := time.Duration(float64(entry.Time.Sub(first))/speed) - time.Since(start)
+			if delay > 0 {
+				if err := r.wait(ctx, conn, delay); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := conn.WritePacket(pk); err != nil {
+			return fmt.Errorf("error writing packet %T: %v", pk, err)
+		}
+	}
+}
+
+// handleLogin handles an Entry recorded before the player of the connection spawned. It returns true if the
+// Entry marks the moment at which the player spawned: For the clientbound direction, this is the PlayStatus
+// packet with the PlayerSpawn status, and for the serverbound direction, the SetLocalPlayerAsInitialised
+// packet.
+func (r Replayer) handleLogin(reader *Reader, entry Entry, conn *minecraft.Conn) (bool, error) {
+	switch entry.Header.PacketID {
+	case packet.IDStartGame:
+		if !r.StartGame || r.Direction != DirectionClientbound || entry.Direction != DirectionClientbound {
+			return false, nil
+		}
+		c, _ := reader.Connection(entry.Connection)
+		if err := conn.StartGame(c.GameData); err != nil {
+			return false, fmt.Errorf("error starting game: %v", err)
+		}
+		return false, nil
+	case packet.IDPlayStatus:
+		if r.Direction != DirectionClientbound || entry.Direction != DirectionClientbound {
+			return false, nil
+		}
+		pk, err := reader.Decode(entry)
+		if err != nil {
+			return false, err
+		}
+		status, ok := pk.(*packet.PlayStatus)
+		return ok && status.Status == packet.PlayStatusPlayerSpawn, nil
+	case packet.IDSetLocalPlayerAsInitialised:
+		return r.Direction == DirectionServerbound && entry.Direction == DirectionServerbound, nil
+	}
+	return false, nil
+}
+
+// wait flushes the packets written to the Conn passed and waits for the delay passed, or until the context is
+// cancelled.
+func (r Replayer) wait(ctx context.Context, conn *minecraft.Conn, delay time.Duration) error {
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
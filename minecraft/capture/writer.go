@@ -0,0 +1,205 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// magic is the sequence of bytes that every capture starts with.
+	magic = "GTCAP"
+	// version is the version of the capture format written by a Writer.
+	version = 1
+)
+
+const (
+	recordConnection = 1
+	recordPacket     = 2
+)
+
+// Direction is the direction in which a packet was sent over a connection.
+type Direction byte
+
+const (
+	// DirectionClientbound is the direction of packets sent by the server to the client.
+	DirectionClientbound Direction = iota
+	// DirectionServerbound is the direction of packets sent by the client to the server.
+	DirectionServerbound
+)
+
+// String ...
+func (d Direction) String() string {
+	switch d {
+	case DirectionClientbound:
+		return "clientbound"
+	case DirectionServerbound:
+		return "serverbound"
+	}
+	return fmt.Sprintf("Direction(%v)", byte(d))
+}
+
+// Writer records the packets of one or more connections to an io.Writer. Its PacketFunc method may be set as
+// the PacketFunc of a minecraft.Dialer or minecraft.Listener, so that all packets sent and received over its
+// connections are recorded. A Writer may be used by multiple goroutines simultaneously.
+// Records are buffered before they are written to the io.Writer, so Flush must be called once recording is
+// done.
+type Writer struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	err error
+
+	// conns holds the connections recorded by the addresses of their ends, ordered. Connections are removed
+	// once a Disconnect packet is recorded for them, or once no packets were recorded for them for
+	// connIdleTimeout.
+	conns map[[2]string]*writerConn
+	// count is the amount of connections recorded so far.
+	count uint32
+
+	buf, body *bytes.Buffer
+}
+
+// connIdleTimeout is the duration after which a Writer considers a connection closed if no packets were
+// recorded for it. Clients send packets every tick while playing, so a connection that stays silent this
+// long is not alive anymore.
+const connIdleTimeout = time.Minute
+
+// writerConn is a connection recorded by a Writer.
+type writerConn struct {
+	id     uint32
+	client string
+	// last is the time at which the last packet of the connection was recorded.
+	last time.Time
+}
+
+// NewWriter returns a Writer that writes a capture to the io.Writer passed. The header of the capture is
+// written immediately, and an error is returned if writing it fails.
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(magic); err != nil {
+		return nil, fmt.Errorf("error writing capture header: %v", err)
+	}
+	if err := bw.WriteByte(version); err != nil {
+		return nil, fmt.Errorf("error writing capture header: %v", err)
+	}
+	return &Writer{
+		w:     bw,
+		conns: make(map[[2]string]*writerConn),
+		buf:   bytes.NewBuffer(make([]byte, 0, 4096)),
+		body:  bytes.NewBuffer(make([]byte, 0, 4096)),
+	}, nil
+}
+
+// PacketFunc records the packet passed, which was sent from the source to the destination address passed. It
+// has the signature of the PacketFunc fields of minecraft.Dialer and minecraft.Listener.
+// The Login packet is the first packet of every connection, so a new connection is recorded for every Login
+// packet, of which the source address is taken to be the address of the client. A connection is no longer
+// tracked after a Disconnect packet was recorded for it, or after no packets were recorded for it for a
+// minute: Packets recorded for its addresses after that are recorded as a new connection. Errors that occur
+// while writing are returned by the next call to Flush, after which nothing is recorded anymore.
+func (w *Writer) PacketFunc(header packet.Header, payload []byte, src, dst net.Addr) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+
+	srcAddr, dstAddr := src.String(), dst.String()
+	key := [2]string{srcAddr, dstAddr}
+	if srcAddr > dstAddr {
+		key = [2]string{dstAddr, srcAddr}
+	}
+	conn, ok := w.conns[key]
+	if !ok || header.PacketID == packet.IDLogin {
+		// If the first packet of the connection recorded is not a Login packet, the Writer was only set after
+		// the login, in which case we can only guess that the source is the client.
+		w.evict(now)
+		conn = &writerConn{id: w.count, client: srcAddr}
+		w.count++
+		w.conns[key] = conn
+
+		protocolID, ver := loginVersion(header, payload)
+		w.body.Reset()
+		_ = protocol.WriteString(w.body, srcAddr)
+		_ = protocol.WriteString(w.body, dstAddr)
+		_ = protocol.WriteVarint32(w.body, protocolID)
+		_ = protocol.WriteString(w.body, ver)
+		w.writeRecord(recordConnection, conn.id, now)
+	}
+
+	dir := DirectionClientbound
+	if srcAddr == conn.client {
+		dir = DirectionServerbound
+	}
+	w.body.Reset()
+	_ = w.body.WriteByte(byte(dir))
+	_ = header.Write(w.body)
+	_, _ = w.body.Write(payload)
+	w.writeRecord(recordPacket, conn.id, now)
+
+	conn.last = now
+	if header.PacketID == packet.IDDisconnect {
+		delete(w.conns, key)
+	}
+}
+
+// evict stops tracking the connections of which no packets were recorded for connIdleTimeout before the
+// time passed. evict must only be called while holding the mutex of the Writer.
+func (w *Writer) evict(now time.Time) {
+	for key, conn := range w.conns {
+		if now.Sub(conn.last) > connIdleTimeout {
+			delete(w.conns, key)
+		}
+	}
+}
+
+// Flush writes all records buffered to the underlying io.Writer. If an error occurred while recording a
+// packet earlier, that error is returned.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.w.Flush(); err != nil {
+		w.err = fmt.Errorf("error flushing capture: %v", err)
+	}
+	return w.err
+}
+
+// writeRecord writes a record with the type, connection and time passed, holding the current content of the
+// body buffer. writeRecord must only be called while holding the mutex of the Writer.
+func (w *Writer) writeRecord(recordType byte, conn uint32, t time.Time) {
+	w.buf.Reset()
+	_ = w.buf.WriteByte(recordType)
+	_ = protocol.WriteVaruint32(w.buf, conn)
+	_ = protocol.WriteVarint64(w.buf, t.UnixNano())
+	_ = protocol.WriteVaruint32(w.buf, uint32(w.body.Len()))
+	_, _ = w.buf.Write(w.body.Bytes())
+	if _, err := w.w.Write(w.buf.Bytes()); err != nil {
+		w.err = fmt.Errorf("error writing capture record: %v", err)
+	}
+}
+
+// loginVersion returns the protocol number and Minecraft version held by the payload of a Login packet. If
+// the header passed is not that of a Login packet, or if the payload could not be decoded, the fields that
+// could not be found are returned empty.
+func loginVersion(header packet.Header, payload []byte) (int32, string) {
+	if header.PacketID != packet.IDLogin {
+		return 0, ""
+	}
+	pk := &packet.Login{}
+	if err := pk.Unmarshal(protocol.NewReader(bytes.NewBuffer(payload), protocol.DecodeLimits{})); err != nil {
+		return 0, ""
+	}
+	_, clientData, _ := login.Decode(pk.ConnectionRequest)
+	return pk.ClientProtocol, clientData.GameVersion
+}
@@ -0,0 +1,75 @@
+package minecraft
+
+import (
+	"compress/flate"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"math"
+)
+
+// CompressionPolicy specifies how the batches of packets sent over a connection are compressed. A Listener
+// sends its CompressionPolicy to clients in the NetworkSettings packet, after which both ends compress the
+// batches they send using the algorithm and threshold of the policy. The zero value of CompressionPolicy
+// compresses batches of 512 bytes and larger using flate at its default level.
+type CompressionPolicy struct {
+	// Algorithm is the compression algorithm that batches are compressed with. If nil,
+	// packet.FlateCompression is used. Other algorithms, such as Snappy, are sent to the client in a field of
+	// the NetworkSettings packet that is not part of the Minecraft protocol, so setting one breaks
+	// compatibility with Minecraft clients. They should only be set for Listeners that only gophertunnel
+	// Dialers connect to, such as those of internal proxies. The algorithm must be registered on the side of
+	// the Dialer using packet.RegisterCompression.
+	Algorithm packet.Compression
+	// Level is the level of compression, following the levels of compress/flate, such as flate.BestSpeed.
+	// Algorithms without levels may ignore it. If zero, flate.DefaultCompression is used. The level is not
+	// sent to the client, which compresses at its own level. Listener.Listen returns an error if the level
+	// is not between flate.HuffmanOnly and flate.BestCompression.
+	Level int
+	// Threshold is the minimum size in bytes of a batch, before compression, for it to be compressed. Smaller
+	// batches are sent without compression. Thresholds higher than 65535 are capped to 65535. If zero, a
+	// threshold of 512 bytes is used. If negative, no batches are compressed at all.
+	Threshold int
+}
+
+// algorithm returns the compression algorithm of the CompressionPolicy, or packet.FlateCompression if none
+// was set.
+func (policy CompressionPolicy) algorithm() packet.Compression {
+	if policy.Algorithm == nil {
+		return packet.FlateCompression
+	}
+	return policy.Algorithm
+}
+
+// level returns the level of the CompressionPolicy, or flate.DefaultCompression if none was set.
+func (policy CompressionPolicy) level() int {
+	return compressionLevel(policy.Level)
+}
+
+// validate checks if the level of the CompressionPolicy is one of the levels of compress/flate.
+func (policy CompressionPolicy) validate() error {
+	if level := policy.level(); level < flate.HuffmanOnly || level > flate.BestCompression {
+		return fmt.Errorf("invalid compression level %v: must be between %v and %v", level, flate.HuffmanOnly, flate.BestCompression)
+	}
+	return nil
+}
+
+// threshold returns the threshold of the CompressionPolicy as sent in the NetworkSettings packet, in which a
+// threshold of 0 means that no batches are compressed.
+func (policy CompressionPolicy) threshold() uint16 {
+	switch {
+	case policy.Threshold == 0:
+		return packet.DefaultCompressionThreshold
+	case policy.Threshold < 0:
+		return 0
+	case policy.Threshold > math.MaxUint16:
+		return math.MaxUint16
+	}
+	return uint16(policy.Threshold)
+}
+
+// compressionLevel returns the compression level passed, or flate.DefaultCompression if it is zero.
+func compressionLevel(level int) int {
+	if level == 0 {
+		return flate.DefaultCompression
+	}
+	return level
+}
@@ -9,7 +9,6 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/google/uuid"
@@ -20,8 +19,8 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"io"
-	"log"
 	"net"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -51,17 +50,32 @@ var exemptedPacks = []exemptedResourcePack{
 // Conn represents a Minecraft (Bedrock Edition) connection over a specific net.Conn transport layer. Its
 // methods (Read, Write etc.) are safe to be called from multiple goroutines simultaneously, except for the
 // ReadPacket function. (See its documentation.)
+//
+// A Conn may also represent a sub-client: An additional local player that joined over the connection of
+// another Conn, as happens during split screen game play. Such a Conn shares the underlying connection with
+// the Conn it joined over, but otherwise behaves like any other Conn. Sub-clients are accepted by a Listener
+// like other connections, and may be dialed using Dialer.DialSubClient.
 type Conn struct {
 	conn        net.Conn
-	log         *log.Logger
+	log         Logger
 	authEnabled bool
 
-	pool    packet.Pool
-	encoder *packet.Encoder
-	decoder *packet.Decoder
+	// proto is the Protocol used to communicate over the connection. The packets in pool are those of this
+	// protocol. For connections accepted by a Listener, it is negotiated during login from acceptedProto.
+	// protoMu guards proto and pool, as they are changed during login while packets may be written to the
+	// connection by other goroutines.
+	protoMu       sync.RWMutex
+	proto         Protocol
+	acceptedProto []Protocol
+	pool          packet.Pool
+	encoder       *packet.Encoder
+	decoder       *packet.Decoder
 
 	identityData login.IdentityData
 	clientData   login.ClientData
+	// xuid holds the XUID of the identity data as a string once it is known. It is added to the context of
+	// errors logged, which may happen on any goroutine.
+	xuid atomic.Value
 	// authenticated represents if player's login data
 	// was verified to be signed with Mojang's key.
 	authenticated bool
@@ -84,23 +98,59 @@ type Conn struct {
 	// were not used by the connection yet. These packets are read the first when calling to Read or
 	// ReadPacket after being connected.
 	pushedBackPackets [][]byte
-	readDeadline      <-chan time.Time
+	// additionalPackets holds packets converted by the Protocol of the connection that were not yet returned
+	// by ReadPacket, as a single packet read may be converted to multiple packets.
+	additionalPackets []packet.Packet
+	// readDeadline is the deadline set using SetReadDeadline. Calls to Read and ReadPacket fail once it passes.
+	readDeadline *deadline
 
 	sendMutex sync.Mutex
 	// bufferedSend is a slice of byte slices containing packets that are 'written'. They are buffered until
-	// they are sent each 20th of a second.
+	// they are flushed according to the flushPolicy.
 	bufferedSend [][]byte
+	// bufferedBytes is the total size in bytes of the packets in bufferedSend.
+	bufferedBytes int
+	flushPolicy   FlushPolicy
+	// compression is the CompressionPolicy of the Conn. On the server side, the full policy is sent to the
+	// client. On the client side, only its level is used, as the algorithm and threshold are set by the
+	// server.
+	compression CompressionPolicy
+	// flushSignal is sent a value when a packet is buffered while no other packets were, so that the flush
+	// timer is started.
+	flushSignal chan struct{}
+	// sendQueue holds the batches flushed that were not yet written to the underlying connection. They are
+	// written by a separate goroutine, which closes writerDone when it stops.
+	sendQueue  *sendQueue
+	writerDone chan struct{}
+	// batchBuf is the buffer that the encoder writes every batch to before it is pushed to the sendQueue.
+	batchBuf *bytes.Buffer
+	// sendBufferSize is the maximum amount of bytes of packets buffered and queued. Writing more packets
+	// blocks until the queue is drained or the write deadline is reached.
+	sendBufferSize int
+	writeDeadline  *deadline
+	// closeTimeout is the maximum duration that Close spends writing the packets that remain, after which the
+	// underlying connection is closed regardless.
+	closeTimeout time.Duration
+	// wire is the writer that batches are written to by the sendQueue. It writes to the underlying
+	// connection.
+	wire io.Writer
 	// writeBuf is used to write packets to, without having to re-allocate for each extra byte written.
 	writeBuf *bytes.Buffer
 
 	// loggedIn is a bool indicating if the connection was logged in. It is set to true after the entire login
 	// sequence is completed.
 	loggedIn bool
+	// dialStage is the DialStage that a client side connection has currently reached. It is accessed
+	// atomically.
+	dialStage int32
 	// spawn is a bool channel indicating if the connection is currently waiting for its spawning in
 	// the world: It is completing a sequence that will result in the spawning.
 	spawn           chan bool
 	waitingForSpawn atomic.Value
 
+	// stats holds the statistics of the traffic of the connection, which may be obtained using Stats().
+	stats *connStats
+
 	// expectedIDs is a slice of packet identifiers that are next expected to arrive, until the connection is
 	// logged in.
 	expectedIDs atomic.Value
@@ -112,10 +162,34 @@ type Conn struct {
 	// texturePacksRequired specifies if clients that join must accept the texture pack in order for them to
 	// be able to join the server. If they don't accept, they can only leave the server.
 	texturePacksRequired bool
-	packQueue            *resourcePackQueue
+	// packStack is the resource pack stack sent or received during login. resourcePacks is ordered according
+	// to it once it is set.
+	packStack ResourcePackStack
+	packQueue *resourcePackQueue
+	// packChunkConcurrency is the maximum amount of resource pack chunk requests that a client has in flight
+	// for a single resource pack.
+	packChunkConcurrency int
+	// packChunkTimeout is the time after which a client requests a resource pack chunk again if it was not
+	// yet received.
+	packChunkTimeout time.Duration
+	// packDownloadDir is the directory that a client downloads resource packs into. If empty, resource packs
+	// are downloaded into memory.
+	packDownloadDir string
+	// packMaxSize is the maximum size of a single resource pack that a client downloads.
+	packMaxSize uint64
+	// packCache is a cache of resource packs used by a client. Resource packs found in it are not downloaded
+	// again, and packs that are downloaded are stored in it. It may be nil.
+	packCache PackCache
+	// resourcePacksFor is an optional function set by a Listener. If set, it is called during login to obtain
+	// the resource packs for this connection specifically, overwriting resourcePacks.
+	resourcePacksFor func(conn *Conn) []*resource.Pack
 
 	cacheEnabled bool
 
+	// admitFunc is an optional function set by a Listener. If set, it is called after the login request of
+	// the connection is decoded to decide if the connection is allowed to join.
+	admitFunc func(identity login.IdentityData, client login.ClientData, addr net.Addr) (ok bool, reason string)
+
 	// packetFunc is an optional function passed to a Dial() call. If set, each packet read from and written
 	// to this connection will call this function.
 	packetFunc func(header packet.Header, payload []byte, src, dst net.Addr)
@@ -125,53 +199,139 @@ type Conn struct {
 	disconnectMessage atomic.Value
 
 	sendPacketViolations bool
+	// ownedPackets specifies if every packet read should be a new packet that owns its data, rather than a
+	// packet from the pool of the connection.
+	ownedPackets bool
+	// decodeLimits holds the limits that apply to decoding every packet read from the connection.
+	decodeLimits protocol.DecodeLimits
+	// reader is the protocol.Reader that packets read from the connection are decoded with. It is created
+	// with the decodeLimits of the connection when the first packet is decoded and reused for every packet
+	// after that, like the packets in the pool.
+	reader *protocol.Reader
+
+	// clientSide specifies if the Conn was obtained using a Dialer. It decides which of the sub-client IDs in
+	// the header of a packet identifies the sub-client that the packet belongs to.
+	clientSide bool
+	// subClientID is the ID of the sub-client that the Conn represents, if it is a sub-client of another Conn.
+	// In that case, parent holds that Conn, which owns the underlying connection. For other connections,
+	// subClientID is 0 and parent is nil.
+	subClientID byte
+	parent      *Conn
+	// login is sent the result of the login sequence of a sub-client: nil once it is logged in, or the error
+	// that caused it to fail.
+	login chan error
+	// disconnectSent is set to 1 once a sub-client wrote a Disconnect packet, so that Close does not send
+	// another one.
+	disconnectSent int32
+
+	subClientMu sync.Mutex
+	// subClients holds the sub-clients that joined over the Conn, by their ID.
+	subClients map[byte]*Conn
+	// subClientFunc is an optional function set by a Listener. If set, it is called for every sub-client that
+	// attempts to join over the Conn, before its login request is handled.
+	subClientFunc func(sub *Conn)
 }
 
 // newConn creates a new Minecraft connection for the net.Conn passed, reading and writing compressed
 // Minecraft packets to that net.Conn.
 // newConn accepts a private key which will be used to identify the connection. If a nil key is passed, the
 // key is generated.
-func newConn(netConn net.Conn, key *ecdsa.PrivateKey, log *log.Logger) *Conn {
+func newConn(netConn net.Conn, key *ecdsa.PrivateKey, log Logger) *Conn {
 	if key == nil {
 		// If no key is passed, we generate one in this function and use it instead.
 		key, _ = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 	}
 	closeCtx, cancel := context.WithCancel(context.Background())
+	stats, batchBuf := newStats(), bytes.NewBuffer(nil)
 	conn := &Conn{
-		conn:        netConn,
-		encoder:     packet.NewEncoder(netConn),
-		decoder:     packet.NewDecoder(netConn),
-		pool:        packet.NewPool(),
-		packets:     make(chan []byte, 256),
-		writeBuf:    bytes.NewBuffer(make([]byte, 0, 1024)),
-		close:       cancel,
-		closeCtx:    closeCtx,
-		spawn:       make(chan bool),
-		privateKey:  key,
-		salt:        make([]byte, 16),
-		log:         log,
-		chunkRadius: 16,
+		conn: netConn,
+		// The batches written by the encoder are pushed to the send queue, which are then written to the wire
+		// by a separate goroutine. The wire and the decoder use a net.Conn that counts the bytes going over
+		// the wire for the stats of the connection.
+		encoder:        packet.NewEncoder(batchBuf),
+		batchBuf:       batchBuf,
+		decoder:        packet.NewDecoder(statsConn{Conn: netConn, stats: stats}),
+		wire:           statsConn{Conn: netConn, stats: stats},
+		sendQueue:      newSendQueue(),
+		writerDone:     make(chan struct{}),
+		sendBufferSize: defaultSendBufferSize,
+		closeTimeout:   defaultCloseTimeout,
+		readDeadline:   newDeadline(),
+		writeDeadline:  newDeadline(),
+		stats:          stats,
+		proto:          DefaultProtocol,
+		pool:           DefaultProtocol.Packets(),
+		packets:        make(chan []byte, 256),
+		writeBuf:       bytes.NewBuffer(make([]byte, 0, 1024)),
+		close:          cancel,
+		closeCtx:       closeCtx,
+		spawn:          make(chan bool),
+		flushSignal:    make(chan struct{}, 1),
+		privateKey:     key,
+		salt:           make([]byte, 16),
+		log:            log.With("remoteAddr", netConn.RemoteAddr()),
+		chunkRadius:    16,
+
+		packChunkConcurrency: 1,
+		packChunkTimeout:     time.Second * 10,
+		packMaxSize:          defaultMaxPackSize,
 	}
 	conn.disconnectMessage.Store("")
 	conn.waitingForSpawn.Store(false)
 	conn.expectedIDs.Store([]uint32{packet.IDLogin})
 	_, _ = rand.Read(conn.salt)
 
-	go func() {
-		ticker := time.NewTicker(time.Second / 20)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if err := conn.Flush(); err != nil {
-					_ = conn.Close()
-				}
-			case <-conn.closeCtx.Done():
+	go conn.flushLoop()
+	go conn.writeLoop()
+	return conn
+}
+
+// writeLoop writes the batches in the send queue of the connection to the underlying connection as they are
+// added. If writing fails, the connection is closed. writeLoop returns once the connection is closed.
+func (conn *Conn) writeLoop() {
+	defer close(conn.writerDone)
+	for {
+		select {
+		case <-conn.sendQueue.notEmpty:
+		case <-conn.closeCtx.Done():
+			return
+		}
+		if err := conn.sendQueue.drain(conn.wire); err != nil {
+			if conn.closeCtx.Err() != nil {
+				// The connection is being closed, and Close is waiting for us to return.
 				return
 			}
+			conn.log.Error("error writing packet batch", conn.logContext("err", err)...)
+			// Close waits for writeLoop to return, so we close the connection on a different goroutine.
+			go func() {
+				_ = conn.Close()
+			}()
+			return
 		}
-	}()
-	return conn
+	}
+}
+
+// flushLoop flushes the packets buffered by the connection once the flush interval passed after the first
+// packet was buffered. No timer runs while no packets are buffered. flushLoop returns once the connection is
+// closed.
+func (conn *Conn) flushLoop() {
+	for {
+		select {
+		case <-conn.flushSignal:
+		case <-conn.closeCtx.Done():
+			return
+		}
+		timer := time.NewTimer(conn.flushPolicy.interval())
+		select {
+		case <-timer.C:
+			if err := conn.Flush(); err != nil {
+				_ = conn.Close()
+			}
+		case <-conn.closeCtx.Done():
+			timer.Stop()
+			return
+		}
+	}
 }
 
 // IdentityData returns the identity data of the connection. It holds the UUID, XUID and username of the
@@ -245,103 +405,230 @@ func (conn *Conn) DoSpawn() error {
 	}
 }
 
-// WritePacket encodes the packet passed and writes it to the Conn. The encoded data is buffered until the
-// next 20th of a second, after which the data is flushed and sent over the connection.
+// WritePacket encodes the packet passed and writes it to the Conn. The encoded data is buffered until it is
+// flushed according to the FlushPolicy of the Conn, by default after a 20th of a second, after which the data
+// is sent over the connection.
+// The packet passed is a packet of the current version. If the Conn uses another Protocol, the packet is
+// converted to the packets of that protocol before it is written.
 func (conn *Conn) WritePacket(pk packet.Packet) error {
-	conn.sendMutex.Lock()
-	defer conn.sendMutex.Unlock()
+	if _, ok := pk.(*packet.Disconnect); ok && conn.parent != nil {
+		atomic.StoreInt32(&conn.disconnectSent, 1)
+	}
+	pks := conn.Protocol().ConvertFromLatest(pk, conn)
 
-	header := &packet.Header{PacketID: pk.ID()}
-	_ = header.Write(conn.writeBuf)
-	// Record the length of the header so we can filter it out for the packet func.
-	headerLen := conn.writeBuf.Len()
+	t := conn.transport()
+	t.sendMutex.Lock()
+	defer t.sendMutex.Unlock()
 
-	pk.Marshal(conn.writeBuf)
-	if conn.packetFunc != nil {
-		// The packet func was set, so we call it.
-		conn.packetFunc(*header, conn.writeBuf.Bytes()[headerLen:], conn.LocalAddr(), conn.RemoteAddr())
-	}
-	conn.bufferedSend = append(conn.bufferedSend, append([]byte(nil), conn.writeBuf.Bytes()...))
+	for _, pk := range pks {
+		header := &packet.Header{PacketID: pk.ID()}
+		if conn.clientSide {
+			header.SenderSubClient = conn.subClientID
+		} else {
+			header.TargetSubClient = conn.subClientID
+		}
+		_ = header.Write(conn.writeBuf)
+		// Record the length of the header so we can filter it out for the packet func.
+		headerLen := conn.writeBuf.Len()
 
-	conn.writeBuf.Reset()
+		pk.Marshal(conn.writeBuf)
+		if conn.packetFunc != nil {
+			// The packet func was set, so we call it.
+			conn.packetFunc(*header, conn.writeBuf.Bytes()[headerLen:], conn.LocalAddr(), conn.RemoteAddr())
+		}
+		data := append([]byte(nil), conn.writeBuf.Bytes()...)
+		conn.writeBuf.Reset()
+		if err := conn.buffer(data); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // ReadPacket reads a packet from the Conn, depending on the packet ID that is found in front of the packet
 // data. If a read deadline is set, an error is returned if the deadline is reached before any packet is
 // received.
-// The packet received must not be held until the next packet is read using ReadPacket(). If the same type of
-// packet is read, the previous one will be invalidated.
+//
+// By default, ReadPacket does not allocate a new packet for every call: The same packet is returned every
+// time a packet with the same ID is read, and strings and byte slices in it may point directly into the data
+// received. The packet received must therefore not be held until the next packet is read using
+// ReadPacket(), as reading the same type of packet invalidates the previous one, and it must not be passed to
+// other goroutines. packet.Clone may be used to obtain a copy of the packet that may be held and passed
+// freely.
+// If OwnedPackets is set on the Dialer or Listener that the Conn was obtained from, ReadPacket returns a new
+// packet for every call, which owns all of its data. Such a packet may be held and passed to other goroutines
+// without restrictions, at the cost of an allocation and a copy of the data for every packet read.
 //
 // If the packet read was not implemented, a *packet.Unknown is returned, containing the raw payload of the
 // packet read.
+//
+// The packet returned is always a packet of the current version. If the Conn uses another Protocol, packets
+// read are converted to the packets of the current version first.
 func (conn *Conn) ReadPacket() (pk packet.Packet, err error) {
+	if len(conn.additionalPackets) != 0 {
+		pk = conn.additionalPackets[0]
+		conn.additionalPackets = conn.additionalPackets[1:]
+		return pk, nil
+	}
 	if data, ok := conn.takePushedBackPacket(); ok {
-		pk, err := conn.parsePacket(data, false)
-		if err != nil {
-			conn.log.Println(err)
+		pks, err := conn.parsePacket(data, false)
+		if err != nil || len(pks) == 0 {
 			return conn.ReadPacket()
 		}
-		return pk, nil
+		conn.additionalPackets = pks[1:]
+		return pks[0], nil
 	}
 
+	exceeded := conn.readDeadline.wait()
+	if isClosed(exceeded) {
+		// The deadline already passed, so we don't read a packet even if one is available.
+		return nil, &timeoutError{op: "error reading packet"}
+	}
+	var data []byte
 	select {
-	case data := <-conn.packets:
-		pk, err := conn.parsePacket(data, true)
-		if err != nil {
-			conn.log.Println(err)
-			return conn.ReadPacket()
-		}
-		return pk, nil
-	case <-conn.readDeadline:
-		return nil, fmt.Errorf("error reading packet: read timeout")
+	case data = <-conn.packets:
+	case <-exceeded:
+		return nil, &timeoutError{op: "error reading packet"}
 	case <-conn.closeCtx.Done():
-		return nil, fmt.Errorf("error reading packet: connection closed")
+		select {
+		case data = <-conn.packets:
+			// The packet was received before the connection was closed, such as a Disconnect packet, so we
+			// still return it.
+		default:
+			return nil, fmt.Errorf("error reading packet: connection closed")
+		}
 	}
+	pks, err := conn.parsePacket(data, true)
+	if err != nil || len(pks) == 0 {
+		return conn.ReadPacket()
+	}
+	conn.additionalPackets = pks[1:]
+	return pks[0], nil
 }
 
 // readPacket reads a new packet from the Conn, depending on the packet ID that is found in front of the
-// packet data. If a read deadline is set, an error is returned if the deadline is reached before any packet
-// is received.
+// packet data, and returns the packets of the current version that it was converted to. Unlike ReadPacket,
+// readPacket does not honour the read deadline of the Conn, as it is only called by the Conn itself right
+// after a packet was received.
 //
 // If the packet read was not implemented, a *packet.Unknown is returned, containing the raw payload of the
 // packet read.
-func (conn *Conn) readPacket() (pk packet.Packet, rawData []byte, readNext bool, err error) {
+func (conn *Conn) readPacket() (pks []packet.Packet, rawData []byte, readNext bool, err error) {
 	select {
 	case data := <-conn.packets:
-		pk, err := conn.parsePacket(data, true)
-		if err != nil {
-			conn.log.Println(err)
+		pks, err := conn.parsePacket(data, true)
+		if err != nil || len(pks) == 0 {
 			return nil, nil, true, nil
 		}
-		return pk, data, false, nil
-	case <-conn.readDeadline:
-		return nil, nil, false, fmt.Errorf("error reading packet: read timeout")
+		return pks, data, false, nil
 	case <-conn.closeCtx.Done():
 		return nil, nil, false, fmt.Errorf("error reading packet: connection closed")
 	}
 }
 
+// Stats returns statistics of the traffic of the connection, such as the amount of packets and bytes
+// received and sent, and the time spent in each stage of the login sequence. The ConnStats returned is a
+// copy and is not updated after the call.
+func (conn *Conn) Stats() ConnStats {
+	return conn.stats.snapshot()
+}
+
 // ResourcePacks returns a slice of all resource packs the connection holds. For a Conn obtained using a
-// Listener, this holds all resource packs set to the Listener. For a Conn obtained using Dial, the resource
+// Listener, this holds all resource packs set to the Listener, or those returned by its ResourcePacksFor
+// function for this connection. For a Conn obtained using Dial, the resource
 // packs include all packs sent by the server connected to.
+// Once the ResourcePackStack packet is sent or received, the packs on the stack are ordered as they are in
+// the stack.
 func (conn *Conn) ResourcePacks() []*resource.Pack {
+	conn.packMutex.Lock()
+	defer conn.packMutex.Unlock()
 	return conn.resourcePacks
 }
 
-// Write writes a slice of serialised packet data to the Conn. The data is buffered until the next 20th of a
-// tick, after which it is flushed to the connection. Write returns the amount of bytes written n.
+// ResourcePackStack returns the order in which the resource packs of the connection are applied, as sent in
+// the ResourcePackStack packet during login. The stack includes the vanilla packs that clients always have,
+// for which no resource.Pack is set. Before the ResourcePackStack packet is sent or received, an empty stack
+// is returned.
+// ResourcePackStack().Textures() may be used to resolve a file through the texture packs on the stack.
+func (conn *Conn) ResourcePackStack() ResourcePackStack {
+	conn.packMutex.Lock()
+	defer conn.packMutex.Unlock()
+	return conn.packStack
+}
+
+// Write writes a slice of serialised packet data to the Conn. The data is buffered until it is flushed
+// according to the FlushPolicy of the Conn, by default after a 20th of a second, after which it is sent over
+// the connection. Write returns the amount of bytes written n.
 func (conn *Conn) Write(b []byte) (n int, err error) {
-	conn.sendMutex.Lock()
-	defer conn.sendMutex.Unlock()
+	t := conn.transport()
+	t.sendMutex.Lock()
+	defer t.sendMutex.Unlock()
 
-	conn.bufferedSend = append(conn.bufferedSend, b)
+	if err := conn.buffer(b); err != nil {
+		return 0, err
+	}
 	return len(b), nil
 }
 
+// buffer adds the packet data passed to the packets buffered. If the FlushPolicy of the connection requires
+// it, the packets are flushed immediately. buffer must only be called while holding the sendMutex of the
+// transport of the Conn. For a sub-client, the packets are buffered by the Conn it belongs to.
+func (conn *Conn) buffer(b []byte) error {
+	select {
+	case <-conn.closeCtx.Done():
+		return fmt.Errorf("error writing packet: connection closed")
+	default:
+	}
+	t := conn.transport()
+	if t.bufferedBytes+t.sendQueue.Len()+len(b) > t.sendBufferSize {
+		// The send buffer is full. We wait until enough of the queue is written to the connection for the
+		// packets buffered so far to fit before flushing them, so that the queue never holds more than the
+		// size of the send buffer, and then wait until the new packet fits too.
+		if err := conn.waitSendSpace(t.bufferedBytes); err != nil {
+			return err
+		}
+		if err := t.flush(); err != nil {
+			return err
+		}
+		if err := conn.waitSendSpace(len(b)); err != nil {
+			return err
+		}
+	}
+	t.bufferedSend = append(t.bufferedSend, b)
+	t.bufferedBytes += len(b)
+	conn.stats.packetOut(b)
+
+	if t.flushPolicy.shouldFlush(len(t.bufferedSend), t.bufferedBytes) {
+		return t.flush()
+	}
+	if len(t.bufferedSend) == 1 {
+		// This is the first packet buffered, so we start the flush timer.
+		select {
+		case t.flushSignal <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// waitSendSpace waits until n more bytes fit in the send queue of the transport of the Conn. An error is
+// returned if the write deadline passes or the Conn is closed before that.
+func (conn *Conn) waitSendSpace(n int) error {
+	t := conn.transport()
+	ok, timeout := t.sendQueue.waitSpace(n, t.sendBufferSize, conn.writeDeadline, conn.closeCtx.Done())
+	if timeout {
+		return &timeoutError{op: "error writing packet", err: ErrSendBufferFull}
+	} else if !ok {
+		return fmt.Errorf("error writing packet: connection closed")
+	}
+	return nil
+}
+
 // Read reads a packet from the connection into the byte slice passed, provided the byte slice is big enough
 // to carry the full packet.
-// It is recommended to use ReadPacket() rather than Read() in cases where reading is done directly.
+// It is recommended to use ReadPacket() rather than Read() in cases where reading is done directly. Unlike
+// ReadPacket, Read returns the packet data as it was received, so it is not converted if the Conn uses a
+// Protocol other than DefaultProtocol. The same goes for data passed to Write.
 func (conn *Conn) Read(b []byte) (n int, err error) {
 	if data, ok := conn.takePushedBackPacket(); ok {
 		if len(b) < len(data) {
@@ -349,14 +636,18 @@ func (conn *Conn) Read(b []byte) (n int, err error) {
 		}
 		return copy(b, data), nil
 	}
+	exceeded := conn.readDeadline.wait()
+	if isClosed(exceeded) {
+		return 0, &timeoutError{op: "error reading packet"}
+	}
 	select {
 	case data := <-conn.packets:
 		if len(b) < len(data) {
 			return 0, fmt.Errorf("error reading data: A message sent on a Minecraft socket was larger than the buffer used to receive the message into")
 		}
 		return copy(b, data), nil
-	case <-conn.readDeadline:
-		return 0, fmt.Errorf("error reading packet: read timeout")
+	case <-exceeded:
+		return 0, &timeoutError{op: "error reading packet"}
 	case <-conn.closeCtx.Done():
 		return 0, fmt.Errorf("error reading packet: connection closed")
 	}
@@ -365,24 +656,79 @@ func (conn *Conn) Read(b []byte) (n int, err error) {
 // Flush flushes the packets currently buffered by the connections to the underlying net.Conn, so that they
 // are directly sent.
 func (conn *Conn) Flush() error {
-	conn.sendMutex.Lock()
-	defer conn.sendMutex.Unlock()
+	t := conn.transport()
+	t.sendMutex.Lock()
+	defer t.sendMutex.Unlock()
+	return t.flush()
+}
 
+// flush flushes the packets currently buffered. flush must only be called while holding the sendMutex.
+func (conn *Conn) flush() error {
 	if len(conn.bufferedSend) > 0 {
-		if err := conn.encoder.Encode(conn.bufferedSend); err != nil {
+		err := conn.encoder.Encode(conn.bufferedSend)
+		if err == nil {
+			conn.sendQueue.push(conn.batchBuf.Bytes(), conn.bufferedBytes)
+		}
+		conn.batchBuf.Reset()
+		if err != nil {
 			return fmt.Errorf("error encoding packet batch: %v", err)
 		}
+		conn.stats.batch()
 		// Reset the send slice so that we don't accidentally send the same packets.
 		conn.bufferedSend = nil
+		conn.bufferedBytes = 0
 	}
 	return nil
 }
 
 // Close closes the Conn and its underlying connection. Before closing, it also calls Flush() so that any
-// packets currently pending are sent out.
+// packets currently pending are sent out. Writing these packets may take up to 5 seconds, after which the
+// underlying connection is closed regardless, so that Close does not block on a peer that stopped reading.
+// Closing a Conn also closes all of its sub-clients.
+// If the Conn is a sub-client, the underlying connection, which it shares with the Conn it joined over, is
+// left open and only the sub-client is closed. Unless one was already written, the other end is sent a
+// Disconnect packet so that it knows the sub-client left.
 func (conn *Conn) Close() error {
+	// The time spent in the login sequence stops counting once the connection is closed, even if the login
+	// sequence was not completed.
+	conn.stats.endLogin()
+	if conn.parent != nil {
+		if !isClosed(conn.closeCtx.Done()) && atomic.LoadInt32(&conn.disconnectSent) == 0 {
+			_ = conn.WritePacket(&packet.Disconnect{HideDisconnectionScreen: true})
+			_ = conn.Flush()
+		}
+		conn.closeSubClient()
+		return nil
+	}
 	conn.close()
-	_ = conn.Flush()
+	// The write loop may be blocked writing to a peer that stopped reading, so the writes that remain are
+	// bounded by a deadline. In case the underlying connection does not support deadlines, it is also closed
+	// once the timeout passes, which fails any write that is still blocked.
+	_ = conn.conn.SetWriteDeadline(time.Now().Add(conn.closeTimeout))
+	timeout := time.NewTimer(conn.closeTimeout)
+	defer timeout.Stop()
+
+	// Wait for the write loop to stop, so that we can write the remaining packets in order here.
+	select {
+	case <-conn.writerDone:
+	case <-timeout.C:
+		err := conn.conn.Close()
+		<-conn.writerDone
+		return err
+	}
+
+	conn.sendMutex.Lock()
+	defer conn.sendMutex.Unlock()
+	_ = conn.flush()
+	drained := make(chan struct{})
+	go func() {
+		_ = conn.sendQueue.drain(conn.wire)
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-timeout.C:
+	}
 	return conn.conn.Close()
 }
 
@@ -399,29 +745,36 @@ func (conn *Conn) RemoteAddr() net.Addr {
 // SetDeadline sets the read and write deadline of the connection. It is equivalent to calling SetReadDeadline
 // and SetWriteDeadline at the same time.
 func (conn *Conn) SetDeadline(t time.Time) error {
-	return conn.SetReadDeadline(t)
+	if err := conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return conn.SetWriteDeadline(t)
 }
 
-// SetReadDeadline sets the read deadline of the Conn to the time passed. The time must be after time.Now().
-// Passing an empty time.Time to the method (time.Time{}) results in the read deadline being cleared.
+// SetReadDeadline sets the read deadline of the Conn to the time passed. Once the deadline passes, calls to
+// Read and ReadPacket fail with an error that implements net.Error with Timeout() returning true, including
+// calls that are blocked at the time. The deadline may be changed at any time, also while a call to Read or
+// ReadPacket is blocked, in which case the new deadline applies to that call.
+// A deadline in the past makes reads fail immediately. Passing an empty time.Time to the method (time.Time{})
+// clears the read deadline.
 func (conn *Conn) SetReadDeadline(t time.Time) error {
-	if t.Before(time.Now()) {
-		return fmt.Errorf("error setting read deadline: time passed is before time.Now()")
-	}
-	empty := time.Time{}
-	if t == empty {
-		// Empty time, so we just set the time to some crazy high value to ensure the read deadline is never
-		// actually reached.
-		conn.readDeadline = time.After(time.Hour * 1000000)
-	} else {
-		conn.readDeadline = time.After(time.Until(t))
-	}
+	conn.readDeadline.set(t)
 	return nil
 }
 
-// SetWriteDeadline is a stub function to implement net.Conn. It has no functionality.
-func (conn *Conn) SetWriteDeadline(time.Time) error {
-	return nil
+// SetWriteDeadline sets the write deadline of the Conn to the time passed. If the send buffer of the Conn is
+// full, writing a packet blocks until enough of the buffer is written to the connection. If the deadline
+// passes before that, the write fails with an error wrapping ErrSendBufferFull, so that a slow connection may
+// be dropped. Like the read deadline, the write deadline may be changed while a write is blocked. The
+// deadline is also set on the underlying connection, so that blocking writes to it fail too, unless the Conn
+// is a sub-client sharing the underlying connection with another Conn.
+// Passing an empty time.Time to the method (time.Time{}) clears the write deadline.
+func (conn *Conn) SetWriteDeadline(t time.Time) error {
+	conn.writeDeadline.set(t)
+	if conn.parent != nil {
+		return nil
+	}
+	return conn.conn.SetWriteDeadline(t)
 }
 
 // Latency returns the last measured latency between both ends of the connection in milliseconds.
@@ -436,6 +789,22 @@ func (conn *Conn) Latency() int {
 	return -1
 }
 
+// Protocol returns the Protocol that is used to communicate over the Conn. For a Conn obtained using a
+// Listener, it is the protocol of the version that the client connected with, as negotiated during login.
+// Before the login packet is received, DefaultProtocol is returned.
+func (conn *Conn) Protocol() Protocol {
+	conn.protoMu.RLock()
+	defer conn.protoMu.RUnlock()
+	return conn.proto
+}
+
+// setProtocol sets the Protocol used to communicate over the Conn and the packets that are decoded for it.
+func (conn *Conn) setProtocol(proto Protocol) {
+	conn.protoMu.Lock()
+	defer conn.protoMu.Unlock()
+	conn.proto, conn.pool = proto, proto.Packets()
+}
+
 // ClientCacheEnabled checks if the connection has the client blob cache enabled. If true, the server may send
 // blobs to the client to reduce network transmission, but if false, the client does not support it, and the
 // server must send chunks as usual.
@@ -450,15 +819,28 @@ func (conn *Conn) ChunkRadius() int {
 	return conn.chunkRadius
 }
 
-// parsePacket parses a packet from the data passed and returns it, if successful. If the packet could not be
-// parsed successfully, nil and an error is returned.
-func (conn *Conn) parsePacket(data []byte, callPacketFunc bool) (packet.Packet, error) {
-	buf := bytes.NewBuffer(data)
+// parsePacket parses a packet from the data passed and returns the packets of the current version it was
+// converted to by the Protocol of the connection, if successful. If the packet could not be parsed
+// successfully, the error is logged and nil and the error are returned.
+func (conn *Conn) parsePacket(data []byte, callPacketFunc bool) ([]packet.Packet, error) {
+	if conn.ownedPackets {
+		// Strings and byte slices decoded may point directly into the data, so we make sure the packet is
+		// decoded from data that nothing else refers to.
+		data = append([]byte(nil), data...)
+	}
+	if conn.reader == nil {
+		conn.reader = protocol.NewReader(nil, conn.decodeLimits)
+	}
+	buf := conn.reader
+	buf.ResetBuffer(bytes.NewBuffer(data))
 	header := &packet.Header{}
 	if err := header.Read(buf); err != nil {
 		// We don't return this as an error as it's not in the hand of the user to control this. Instead,
 		// we return to reading a new packet.
-		return nil, fmt.Errorf("error reading packet header: %v", err)
+		err = fmt.Errorf("error reading packet header: %w", err)
+		conn.stats.decodeFailure()
+		conn.log.Warn("error decoding packet", conn.logContext("err", err)...)
+		return nil, err
 	}
 	if callPacketFunc {
 		if conn.packetFunc != nil {
@@ -466,42 +848,76 @@ func (conn *Conn) parsePacket(data []byte, callPacketFunc bool) (packet.Packet,
 			conn.packetFunc(*header, buf.Bytes(), conn.RemoteAddr(), conn.LocalAddr())
 		}
 	}
+	conn.protoMu.RLock()
+	proto, pool := conn.proto, conn.pool
+	conn.protoMu.RUnlock()
+
 	// Attempt to fetch the packet with the right packet ID from the pool.
-	pk, ok := conn.pool[header.PacketID]
+	pk, ok := pool[header.PacketID]
 	if !ok {
 		// We haven't implemented this packet ID, so we return an unknown packet which could be used by
 		// the reader.
 		pk = &packet.Unknown{PacketID: header.PacketID}
+	} else if conn.ownedPackets {
+		// Rather than re-using the packet in the pool, we create a new one of the same type.
+		pk = reflect.New(reflect.TypeOf(pk).Elem()).Interface().(packet.Packet)
 	}
-	var violationErr string
+	var violationErr error
 	defer func() {
-		if violationErr != "" && conn.sendPacketViolations {
-			// The server sent an invalid packet. We reply with a PacketViolationWarning holding any
-			// potentially useful information.
-			_ = conn.WritePacket(&packet.PacketViolationWarning{
-				Type:             packet.ViolationTypeMalformed,
-				Severity:         packet.ViolationSeverityWarning,
-				PacketID:         int32(header.PacketID),
-				ViolationContext: violationErr,
-			})
+		if violationErr != nil {
+			conn.stats.decodeFailure()
+			conn.log.Warn("error decoding packet", conn.logContext("packetID", header.PacketID, "err", violationErr)...)
+			if conn.sendPacketViolations {
+				// The server sent an invalid packet. We reply with a PacketViolationWarning holding any
+				// potentially useful information.
+				_ = conn.WritePacket(&packet.PacketViolationWarning{
+					Type:             packet.ViolationTypeMalformed,
+					Severity:         packet.ViolationSeverityWarning,
+					PacketID:         int32(header.PacketID),
+					ViolationContext: violationErr.Error(),
+				})
+			}
 		}
 	}()
 
-	if err := pk.Unmarshal(buf); err != nil {
-		violationErr = fmt.Sprintf("error decoding packet %T from %v: %v", pk, conn.RemoteAddr(), err)
+	if err := protocol.Decode(buf, func() error { return pk.Unmarshal(buf) }); err != nil {
+		// The error is wrapped so that the typed errors of the protocol package, such as a
+		// protocol.ElementBudgetError, may still be found in the error passed to the Logger.
+		violationErr = fmt.Errorf("error decoding packet %T: %w", pk, err)
 		// We don't return this as an error as it's not in the hand of the user to control this. Instead,
 		// we return to reading a new packet.
-		return nil, errors.New(violationErr)
+		return nil, violationErr
 	}
 	if buf.Len() != 0 {
-		violationErr = fmt.Sprintf("%v unread bytes left in packet %T%v from %v: 0x%x (full payload: 0x%x)\n", buf.Len(), pk, fmt.Sprintf("%+v", pk)[1:], conn.RemoteAddr(), buf.Bytes(), data)
-		return nil, errors.New(violationErr)
+		violationErr = fmt.Errorf("%v unread bytes left in packet %T%v: 0x%x (full payload: 0x%x)", buf.Len(), pk, fmt.Sprintf("%+v", pk)[1:], buf.Bytes(), data)
+		return nil, violationErr
 	}
 	if violation, ok := pk.(*packet.PacketViolationWarning); ok && conn.sendPacketViolations {
-		errPacket, _ := conn.pool[uint32(violation.PacketID)]
-		return nil, fmt.Errorf("gophertunnel packet violation (type = %v for packet %T): %v (severity = %v)", violation.Type, errPacket, violation.ViolationContext, violation.Severity)
+		errPacket, _ := pool[uint32(violation.PacketID)]
+		err := fmt.Errorf("gophertunnel packet violation (type = %v for packet %T): %v (severity = %v)", violation.Type, errPacket, violation.ViolationContext, violation.Severity)
+		conn.log.Warn("packet violation received", conn.logContext("packetID", violation.PacketID, "err", err)...)
+		return nil, err
+	}
+	return proto.ConvertToLatest(pk, conn), nil
+}
+
+// logContext returns the key/value pairs passed with the XUID of the connection added to them, if it is
+// known. The remote address of the connection is already added to the Logger of the connection.
+func (conn *Conn) logContext(keyvals ...interface{}) []interface{} {
+	if xuid, _ := conn.xuid.Load().(string); xuid != "" {
+		return append([]interface{}{"xuid", xuid}, keyvals...)
+	}
+	return keyvals
+}
+
+// logHandleError logs an error returned by handling an incoming packet. If the connection was not yet logged
+// in, the stage of the login sequence that it was in is added to the context of the error.
+func (conn *Conn) logHandleError(err error) {
+	if conn.loggedIn {
+		conn.log.Error("error handling packet", conn.logContext("err", err)...)
+		return
 	}
-	return pk, nil
+	conn.log.Error("error handling packet", conn.logContext("stage", conn.stage(), "err", err)...)
 }
 
 // takePushedBackPacketLocked locks the pushed back packets lock and takes the next packet from the list of
@@ -522,6 +938,13 @@ func (conn *Conn) takePushedBackPacket() ([]byte, bool) {
 // handleIncoming handles an incoming serialised packet from the underlying connection. If the connection is
 // not yet logged in, the packet is immediately read and processed.
 func (conn *Conn) handleIncoming(data []byte) error {
+	if conn.parent == nil {
+		if id := conn.subClientOf(data); id != 0 {
+			// The packet belongs to a sub-client that joined over this connection.
+			return conn.handleSubClientPacket(id, data)
+		}
+	}
+	conn.stats.packetIn(data)
 	select {
 	case conn.packets <- data:
 	case <-conn.closeCtx.Done():
@@ -529,7 +952,7 @@ func (conn *Conn) handleIncoming(data []byte) error {
 	}
 
 	if !conn.loggedIn || conn.waitingForSpawn.Load().(bool) {
-		pk, rawPk, tryNext, err := conn.readPacket()
+		pks, rawPk, tryNext, err := conn.readPacket()
 		if tryNext {
 			// Some non-critical error occurred that was already logged to the logger. We simply stop handling
 			// this packet.
@@ -539,25 +962,36 @@ func (conn *Conn) handleIncoming(data []byte) error {
 			return err
 		}
 		found := false
-		for _, id := range conn.expectedIDs.Load().([]uint32) {
-			if id == pk.ID() || pk.ID() == packet.IDDisconnect {
-				// If the packet was expected, we set found to true and handle it. If not, we skip it and
-				// ignore it eventually.
-				found = true
-				break
+		for _, pk := range pks {
+			if !conn.expected(pk) {
+				continue
+			}
+			found = true
+			if err := conn.handlePacket(pk); err != nil {
+				return err
 			}
 		}
 		if !found {
 			// This is not the packet we expected next in the login sequence. We push it back so that it may
-			// be handled by the user.
+			// be handled by the user. If the packet was converted to multiple packets, it is only pushed back
+			// if none of them were expected.
 			conn.pushedBackPackets = append(conn.pushedBackPackets, rawPk)
-			return nil
 		}
-		return conn.handlePacket(pk)
 	}
 	return nil
 }
 
+// expected checks if the packet passed is one of the packets expected next in the login sequence. Disconnect
+// packets are always expected.
+func (conn *Conn) expected(pk packet.Packet) bool {
+	for _, id := range conn.expectedIDs.Load().([]uint32) {
+		if id == pk.ID() || pk.ID() == packet.IDDisconnect {
+			return true
+		}
+	}
+	return false
+}
+
 // handlePacket handles an incoming packet. It returns an error if any of the data found in the packet was not
 // valid or if handling failed for any other reason.
 func (conn *Conn) handlePacket(pk packet.Packet) error {
@@ -565,6 +999,8 @@ func (conn *Conn) handlePacket(pk packet.Packet) error {
 	// Internal packets destined for the server.
 	case *packet.Login:
 		return conn.handleLogin(pk)
+	case *packet.SubClientLogin:
+		return conn.handleSubClientLogin(pk)
 	case *packet.ClientToServerHandshake:
 		return conn.handleClientToServerHandshake()
 	case *packet.ClientCacheStatus:
@@ -581,6 +1017,8 @@ func (conn *Conn) handlePacket(pk packet.Packet) error {
 	// Internal packets destined for the client.
 	case *packet.ServerToClientHandshake:
 		return conn.handleServerToClientHandshake(pk)
+	case *packet.NetworkSettings:
+		return conn.handleNetworkSettings(pk)
 	case *packet.PlayStatus:
 		return conn.handlePlayStatus(pk)
 	case *packet.ResourcePacksInfo:
@@ -597,6 +1035,11 @@ func (conn *Conn) handlePacket(pk packet.Packet) error {
 		return conn.handleChunkRadiusUpdated(pk)
 	case *packet.Disconnect:
 		conn.disconnectMessage.Store(pk.Message)
+		if conn.parent != nil {
+			// The other end already knows that the sub-client left, so we don't send a Disconnect back.
+			conn.closeSubClient()
+			return nil
+		}
 		_ = conn.Close()
 	}
 	return nil
@@ -608,57 +1051,119 @@ func (conn *Conn) handleLogin(pk *packet.Login) error {
 	// The next expected packet is a response from the client to the handshake.
 	conn.expect(packet.IDClientToServerHandshake)
 
-	if pk.ClientProtocol != protocol.CurrentProtocol {
-		// By default we assume the client is outdated.
-		status := packet.PlayStatusLoginFailedClient
-		if pk.ClientProtocol > protocol.CurrentProtocol {
-			// The server is outdated in this case, so we have to change the status we send.
-			status = packet.PlayStatusLoginFailedServer
+	proto, ok := conn.acceptedProtocol(pk.ClientProtocol)
+	if !ok {
+		// By default we assume the server is outdated, unless the client is older than one of the versions
+		// accepted.
+		status := packet.PlayStatusLoginFailedServer
+		expected := make([]int32, 0, len(conn.acceptedProto))
+		for _, p := range conn.acceptedProto {
+			expected = append(expected, p.ID())
+			if pk.ClientProtocol < p.ID() {
+				status = packet.PlayStatusLoginFailedClient
+			}
 		}
 		_ = conn.WritePacket(&packet.PlayStatus{Status: status})
 		_ = conn.Close()
-		return fmt.Errorf("%v connected with an incompatible protocol: expected protocol = %v, client protocol = %v", conn.identityData.DisplayName, protocol.CurrentProtocol, pk.ClientProtocol)
+		return fmt.Errorf("%v connected with an incompatible protocol: expected protocol = %v, client protocol = %v", conn.identityData.DisplayName, expected, pk.ClientProtocol)
+	}
+	// From here on, packets are read and written using the protocol of the client.
+	conn.setProtocol(proto)
+
+	publicKey, err := conn.verifyLogin(pk.ConnectionRequest)
+	if err != nil {
+		return err
+	}
+	if err := conn.enableEncryption(publicKey); err != nil {
+		return fmt.Errorf("error enabling encryption: %v", err)
+	}
+	return nil
+}
+
+// acceptedProtocol looks up the Protocol with the protocol number passed in the protocols accepted by the
+// connection. If the connection has no accepted protocols set, only DefaultProtocol is accepted.
+func (conn *Conn) acceptedProtocol(id int32) (Protocol, bool) {
+	if len(conn.acceptedProto) == 0 {
+		conn.acceptedProto = []Protocol{DefaultProtocol}
+	}
+	for _, proto := range conn.acceptedProto {
+		if proto.ID() == id {
+			return proto, true
+		}
 	}
+	return nil, false
+}
 
-	publicKey, authenticated, err := login.Verify(pk.ConnectionRequest)
+// verifyLogin verifies and decodes the login request passed, setting the identity data and client data of
+// the connection. If the connection is not admitted by the admit func of the connection, it is disconnected
+// and an error is returned. The public key of the client found in the request is returned.
+func (conn *Conn) verifyLogin(request []byte) (*ecdsa.PublicKey, error) {
+	publicKey, authenticated, err := login.Verify(request)
 	if err != nil {
-		return fmt.Errorf("error verifying login request: %v", err)
+		return nil, fmt.Errorf("error verifying login request: %v", err)
 	}
 	if !authenticated && conn.authEnabled {
-		return fmt.Errorf("connection %v was not authenticated to XBOX Live", conn.RemoteAddr())
+		return nil, fmt.Errorf("connection %v was not authenticated to XBOX Live", conn.RemoteAddr())
 	}
 	conn.authenticated = authenticated
 
-	conn.identityData, conn.clientData, err = login.Decode(pk.ConnectionRequest)
+	conn.identityData, conn.clientData, err = login.Decode(request)
 	if err != nil {
-		return fmt.Errorf("error decoding login request: %v", err)
+		return nil, fmt.Errorf("error decoding login request: %v", err)
 	}
+	conn.xuid.Store(conn.identityData.XUID)
 	// First validate the identity data and the client data to ensure we're working with valid data. Mojang
 	// might change this data, or some custom client might fiddle with the data, so we can never be too sure.
 	if err := conn.identityData.Validate(); err != nil {
-		return fmt.Errorf("invalid identity data: %v", err)
+		return nil, fmt.Errorf("invalid identity data: %v", err)
 	}
 	if err := conn.clientData.Validate(); err != nil {
-		return fmt.Errorf("invalid client data: %v", err)
-	}
-	if err := conn.enableEncryption(publicKey); err != nil {
-		return fmt.Errorf("error enabling encryption: %v", err)
+		return nil, fmt.Errorf("invalid client data: %v", err)
+	}
+	if conn.admitFunc != nil {
+		if ok, reason := conn.admitFunc(conn.identityData, conn.clientData, conn.RemoteAddr()); !ok {
+			// The connection was not admitted, so we disconnect it with the reason passed before encryption
+			// is enabled.
+			_ = conn.WritePacket(&packet.Disconnect{HideDisconnectionScreen: reason == "", Message: reason})
+			_ = conn.Close()
+			return nil, fmt.Errorf("connection %v was not admitted: %v", conn.RemoteAddr(), reason)
+		}
 	}
-	return nil
+	return publicKey, nil
 }
 
 // handleClientToServerHandshake handles an incoming ClientToServerHandshake packet.
 func (conn *Conn) handleClientToServerHandshake() error {
 	// The next expected packet is a resource pack client response.
+	conn.setStage(DialStagePacks)
 	conn.expect(packet.IDResourcePackClientResponse, packet.IDClientCacheStatus)
-	if err := conn.WritePacket(&packet.NetworkSettings{CompressionThreshold: 512}); err != nil {
+	policy := conn.compression
+	settings := &packet.NetworkSettings{CompressionThreshold: policy.threshold(), CompressionAlgorithm: policy.algorithm().ID()}
+	if err := conn.WritePacket(settings); err != nil {
 		return fmt.Errorf("error sending network settings: %v", err)
 	}
+	// The client does not send any packets until it receives the network settings, so all batches sent by
+	// either end from now on use the new settings.
+	if err := conn.setCompression(policy.algorithm(), policy.level(), settings.CompressionThreshold); err != nil {
+		return fmt.Errorf("error enabling compression: %v", err)
+	}
 	if err := conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginSuccess}); err != nil {
 		return fmt.Errorf("error sending play status login success: %v", err)
 	}
+	if conn.resourcePacksFor != nil {
+		// The packs sent to this connection are selected specifically for it, so we replace the default
+		// resource packs before sending any information about them.
+		packs := conn.resourcePacksFor(conn)
+		conn.packMutex.Lock()
+		conn.resourcePacks = packs
+		conn.packMutex.Unlock()
+	}
+	conn.packMutex.Lock()
+	packs := conn.resourcePacks
+	conn.packMutex.Unlock()
+
 	pk := &packet.ResourcePacksInfo{TexturePackRequired: conn.texturePacksRequired}
-	for _, pack := range conn.resourcePacks {
+	for _, pack := range packs {
 		resourcePack := protocol.ResourcePackInfo{UUID: pack.UUID(), Version: pack.Version(), Size: uint64(pack.Len())}
 		if pack.HasScripts() {
 			// One of the resource packs has scripts, so we set HasScripts in the packet to true.
@@ -733,6 +1238,30 @@ func (conn *Conn) handleServerToClientHandshake(pk *packet.ServerToClientHandsha
 	return conn.WritePacket(&packet.ClientToServerHandshake{})
 }
 
+// handleNetworkSettings handles an incoming NetworkSettings packet. It holds the compression algorithm and
+// threshold that the server uses from now on, which the client must use too.
+func (conn *Conn) handleNetworkSettings(pk *packet.NetworkSettings) error {
+	compression, ok := packet.CompressionByID(pk.CompressionAlgorithm)
+	if !ok {
+		return fmt.Errorf("server uses unknown compression algorithm %v: it must be registered using packet.RegisterCompression", pk.CompressionAlgorithm)
+	}
+	return conn.setCompression(compression, conn.compression.level(), pk.CompressionThreshold)
+}
+
+// setCompression flushes the packets currently buffered and sets the compression algorithm, level and
+// threshold used for all batches sent and received after them. It must only be called while handling a
+// packet, so that the batch read after it is decompressed using the new algorithm.
+func (conn *Conn) setCompression(compression packet.Compression, level int, threshold uint16) error {
+	conn.sendMutex.Lock()
+	defer conn.sendMutex.Unlock()
+	if err := conn.flush(); err != nil {
+		return err
+	}
+	conn.encoder.SetCompression(compression, level, int(threshold))
+	conn.decoder.SetCompression(compression)
+	return nil
+}
+
 // handleClientCacheStatus handles a ClientCacheStatus packet sent by the client. It specifies if the client
 // has support for the client blob cache.
 func (conn *Conn) handleClientCacheStatus(pk *packet.ClientCacheStatus) error {
@@ -747,30 +1276,39 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 	// properly later.
 	conn.packQueue = &resourcePackQueue{
 		packAmount:       len(pk.TexturePacks) + len(pk.BehaviourPacks),
-		downloadingPacks: make(map[string]downloadingPack),
+		downloadingPacks: make(map[string]*downloadingPack),
 		awaitingPacks:    make(map[string]*downloadingPack),
 	}
 	packsToDownload := make([]string, 0, len(pk.TexturePacks)+len(pk.BehaviourPacks))
+	seen := make(map[string]bool, len(pk.TexturePacks)+len(pk.BehaviourPacks))
 
 	for _, pack := range pk.TexturePacks {
-		if _, ok := conn.packQueue.downloadingPacks[pack.UUID]; ok {
-			conn.log.Printf("duplicate texture pack entry %v in resource pack info\n", pack.UUID)
+		if seen[pack.UUID] {
+			conn.log.Warn("duplicate texture pack entry in resource pack info", conn.logContext("stage", conn.stage(), "uuid", pack.UUID)...)
 			conn.packQueue.packAmount--
 			continue
 		}
+		seen[pack.UUID] = true
+		if conn.cachedPack(pack) {
+			continue
+		}
 		// This UUID_Version is a hack Mojang put in place.
 		packsToDownload = append(packsToDownload, pack.UUID+"_"+pack.Version)
-		conn.packQueue.downloadingPacks[pack.UUID] = downloadingPack{size: pack.Size, buf: bytes.NewBuffer(make([]byte, 0, pack.Size)), newFrag: make(chan []byte)}
+		conn.packQueue.downloadingPacks[pack.UUID] = &downloadingPack{size: pack.Size}
 	}
 	for _, pack := range pk.BehaviourPacks {
-		if _, ok := conn.packQueue.downloadingPacks[pack.UUID]; ok {
-			conn.log.Printf("duplicate behaviour pack entry %v in resource pack info\n", pack.UUID)
+		if seen[pack.UUID] {
+			conn.log.Warn("duplicate behaviour pack entry in resource pack info", conn.logContext("stage", conn.stage(), "uuid", pack.UUID)...)
 			conn.packQueue.packAmount--
 			continue
 		}
+		seen[pack.UUID] = true
+		if conn.cachedPack(pack) {
+			continue
+		}
 		// This UUID_Version is a hack Mojang put in place.
 		packsToDownload = append(packsToDownload, pack.UUID+"_"+pack.Version)
-		conn.packQueue.downloadingPacks[pack.UUID] = downloadingPack{size: pack.Size, buf: bytes.NewBuffer(make([]byte, 0, pack.Size)), newFrag: make(chan []byte)}
+		conn.packQueue.downloadingPacks[pack.UUID] = &downloadingPack{size: pack.Size}
 	}
 
 	if len(packsToDownload) != 0 {
@@ -785,52 +1323,129 @@ func (conn *Conn) handleResourcePacksInfo(pk *packet.ResourcePacksInfo) error {
 	return conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseAllPacksDownloaded})
 }
 
+// cachedPack looks up the resource pack of the ResourcePacksInfo packet passed in the pack cache of the
+// connection, using the UUID and version of the pack. If found, the pack is added to the resource packs of the
+// connection and true is returned, so that it is not downloaded.
+func (conn *Conn) cachedPack(pack protocol.ResourcePackInfo) bool {
+	if conn.packCache == nil {
+		return false
+	}
+	p, ok := conn.packCache.Get(pack.UUID, pack.Version)
+	if !ok || uint64(p.Len()) != pack.Size {
+		return false
+	}
+	conn.packMutex.Lock()
+	defer conn.packMutex.Unlock()
+
+	conn.packQueue.packAmount--
+	conn.resourcePacks = append(conn.resourcePacks, p)
+	return true
+}
+
+// addDownloadedPack adds a resource pack that was downloaded or found in the pack cache to the resource packs
+// of the connection. Once all resource packs are present, the server is notified.
+func (conn *Conn) addDownloadedPack(p *resource.Pack) {
+	conn.packMutex.Lock()
+	defer conn.packMutex.Unlock()
+
+	conn.packQueue.packAmount--
+	// Finally we add the resource to the resource packs slice.
+	conn.resourcePacks = append(conn.resourcePacks, p)
+	if conn.packQueue.packAmount == 0 {
+		conn.expect(packet.IDResourcePackStack)
+		_ = conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseAllPacksDownloaded})
+	}
+}
+
 // handleResourcePackStack handles a ResourcePackStack packet sent by the server. The stack defines the order
 // that resource packs are applied in.
 func (conn *Conn) handleResourcePackStack(pk *packet.ResourcePackStack) error {
-	// We currently don't apply resource packs in any way, so instead we just check if all resource packs in
-	// the stacks are also downloaded.
 	for _, pack := range pk.TexturePacks {
 		for i, behaviourPack := range pk.BehaviourPacks {
 			if pack.UUID == behaviourPack.UUID {
 				// We had a behaviour pack with the same UUID as the texture pack, so we drop the texture
 				// pack and log it.
-				conn.log.Printf("dropping behaviour pack with UUID %v due to a texture pack with the same UUID\n", pack.UUID)
+				conn.log.Warn("dropping behaviour pack due to a texture pack with the same UUID", conn.logContext("stage", conn.stage(), "uuid", pack.UUID)...)
 				pk.BehaviourPacks = append(pk.BehaviourPacks[:i], pk.BehaviourPacks[i+1:]...)
 			}
 		}
-		if !conn.hasPack(pack.UUID, pack.Version, false) {
+	}
+	stack := ResourcePackStack{
+		TexturePackRequired: pk.TexturePackRequired,
+		Experimental:        pk.Experimental,
+		BaseGameVersion:     pk.BaseGameVersion,
+	}
+	// We check if all resource packs in the stacks are also downloaded and look up the pack for each of them.
+	for _, pack := range pk.TexturePacks {
+		stacked, ok := conn.stackedPack(pack, false)
+		if !ok {
 			return fmt.Errorf("texture pack {uuid=%v, version=%v} not downloaded", pack.UUID, pack.Version)
 		}
+		stack.TexturePacks = append(stack.TexturePacks, stacked)
 	}
 	for _, pack := range pk.BehaviourPacks {
-		if !conn.hasPack(pack.UUID, pack.Version, true) {
+		stacked, ok := conn.stackedPack(pack, true)
+		if !ok {
 			return fmt.Errorf("behaviour pack {uuid=%v, version=%v} not downloaded", pack.UUID, pack.Version)
 		}
+		stack.BehaviourPacks = append(stack.BehaviourPacks, stacked)
 	}
+	conn.setPackStack(stack)
+
+	conn.setStage(DialStageStartGame)
 	conn.expect(packet.IDStartGame)
 	return conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseCompleted})
 }
 
-// hasPack checks if the connection has a resource pack downloaded with the UUID and version passed, provided
-// the pack either has or does not have behaviours in it.
-func (conn *Conn) hasPack(uuid string, version string, hasBehaviours bool) bool {
+// stackedPack looks up the resource pack of the connection for the pack on the stack passed, provided the pack
+// either has or does not have behaviours in it. If the pack is one of the exempted vanilla packs, a
+// StackedPack without a resource.Pack is returned.
+func (conn *Conn) stackedPack(pack protocol.StackResourcePack, hasBehaviours bool) (StackedPack, bool) {
+	stacked := StackedPack{UUID: pack.UUID, Version: pack.Version, SubPackName: pack.SubPackName}
 	for _, exempted := range exemptedPacks {
-		if exempted.uuid == uuid && exempted.version == version {
+		if exempted.uuid == pack.UUID && exempted.version == pack.Version {
 			// The server may send this resource pack on the stack without sending it in the info, as the client
 			// always has it downloaded.
-			return true
+			return stacked, true
+		}
+	}
+	conn.packMutex.Lock()
+	defer conn.packMutex.Unlock()
+
+	for _, p := range conn.resourcePacks {
+		if p.UUID() == pack.UUID && p.Version() == pack.Version && p.HasBehaviours() == hasBehaviours {
+			stacked.Pack = p
+			return stacked, true
 		}
 	}
+	return stacked, false
+}
+
+// setPackStack sets the resource pack stack of the connection to the stack passed. The resource packs of the
+// connection are re-ordered so that packs on the stack come first, in the order of the stack: Texture packs
+// first, behaviour packs after.
+func (conn *Conn) setPackStack(stack ResourcePackStack) {
 	conn.packMutex.Lock()
 	defer conn.packMutex.Unlock()
 
+	conn.packStack = stack
+	packs := make([]*resource.Pack, 0, len(conn.resourcePacks))
+	seen := make(map[*resource.Pack]bool, len(conn.resourcePacks))
+	for _, stacked := range [][]StackedPack{stack.TexturePacks, stack.BehaviourPacks} {
+		for _, pack := range stacked {
+			if pack.Pack != nil && !seen[pack.Pack] {
+				packs = append(packs, pack.Pack)
+				seen[pack.Pack] = true
+			}
+		}
+	}
 	for _, pack := range conn.resourcePacks {
-		if pack.UUID() == uuid && pack.Version() == version && pack.HasBehaviours() == hasBehaviours {
-			return true
+		if !seen[pack] {
+			// The pack was not on the stack, so we keep it at the end of the slice.
+			packs = append(packs, pack)
 		}
 	}
-	return false
+	conn.resourcePacks = packs
 }
 
 // packChunkSize is the size of a single chunk of data from a resource pack: 512 kB or 0.5 MB
@@ -850,34 +1465,41 @@ func (conn *Conn) handleResourcePackClientResponse(pk *packet.ResourcePackClient
 		if err := conn.packQueue.Request(packs); err != nil {
 			return fmt.Errorf("error looking up resource packs to download: %v", err)
 		}
-		// Proceed with the first resource pack download. We run all downloads in sequence rather than in
-		// parallel, as it's less prone to packet loss.
-		if err := conn.nextResourcePackDownload(); err != nil {
-			return err
+		// We send the data info of all resource packs at once. Chunks are only sent once requested, so clients
+		// decide how many packs they download at the same time. Clients that have a pack cached do not request
+		// its chunks at all.
+		for len(conn.packQueue.packsToDownload) != 0 {
+			if err := conn.nextResourcePackDownload(); err != nil {
+				return err
+			}
 		}
+		// Clients that already have some of the resource packs may finish without requesting all chunks.
+		conn.expect(packet.IDResourcePackChunkRequest, packet.IDResourcePackClientResponse)
 	case packet.PackResponseAllPacksDownloaded:
-		pk := &packet.ResourcePackStack{TexturePackRequired: conn.texturePacksRequired}
+		stack := ResourcePackStack{TexturePackRequired: conn.texturePacksRequired}
+		conn.packMutex.Lock()
 		for _, pack := range conn.resourcePacks {
-			resourcePack := protocol.StackResourcePack{UUID: pack.UUID(), Version: pack.Version()}
+			stacked := StackedPack{UUID: pack.UUID(), Version: pack.Version(), Pack: pack}
 			// If it has behaviours, add it to the behaviour pack list. If not, we add it to the texture packs
 			// list.
 			if pack.HasBehaviours() {
-				pk.BehaviourPacks = append(pk.BehaviourPacks, resourcePack)
+				stack.BehaviourPacks = append(stack.BehaviourPacks, stacked)
 				continue
 			}
-			pk.TexturePacks = append(pk.TexturePacks, resourcePack)
+			stack.TexturePacks = append(stack.TexturePacks, stacked)
 		}
+		conn.packMutex.Unlock()
 		for _, exempted := range exemptedPacks {
-			pk.TexturePacks = append(pk.TexturePacks, protocol.StackResourcePack{
-				UUID:    exempted.uuid,
-				Version: exempted.version,
-			})
+			stack.TexturePacks = append(stack.TexturePacks, StackedPack{UUID: exempted.uuid, Version: exempted.version})
 		}
-		if err := conn.WritePacket(pk); err != nil {
+		conn.setPackStack(stack)
+		conn.setStage(DialStageStartGame)
+		if err := conn.WritePacket(stack.stackPacket()); err != nil {
 			return fmt.Errorf("error writing resource pack stack packet: %v", err)
 		}
 	case packet.PackResponseCompleted:
 		conn.loggedIn = true
+		conn.stats.endLogin()
 	default:
 		return fmt.Errorf("unknown resource pack client response: %v", pk.Response)
 	}
@@ -926,8 +1548,6 @@ func (conn *Conn) nextResourcePackDownload() error {
 	if err := conn.WritePacket(pk); err != nil {
 		return fmt.Errorf("error sending resource pack data info packet: %v", err)
 	}
-	// Set the next expected packet to ResourcePackChunkRequest packets.
-	conn.expect(packet.IDResourcePackChunkRequest)
 	return nil
 }
 
@@ -935,153 +1555,183 @@ func (conn *Conn) nextResourcePackDownload() error {
 // pack by the client.
 func (conn *Conn) handleResourcePackDataInfo(pk *packet.ResourcePackDataInfo) error {
 	id := strings.Split(pk.UUID, "_")[0]
-	chunkCount := pk.ChunkCount
-
-	downloadingPack, ok := conn.packQueue.downloadingPacks[id]
 
+	pack, ok := conn.packQueue.downloadingPacks[id]
 	if !ok {
 		// We either already downloaded the pack or we got sent an invalid UUID, that did not match any pack
 		// sent in the ResourcePacksInfo packet.
 		return fmt.Errorf("unknown pack to download with UUID %v", id)
 	}
-	if downloadingPack.size != pk.Size {
+	if pack.size != pk.Size {
 		// Size mismatch: The ResourcePacksInfo packet had a size for the pack that did not match with the
 		// size sent here.
-		conn.log.Printf("pack %v had a different size in the ResourcePacksInfo packet than the ResourcePackDataInfo packet\n", id)
-		downloadingPack.size = pk.Size
+		conn.log.Warn("pack had a different size in the ResourcePacksInfo packet than the ResourcePackDataInfo packet", conn.logContext("stage", conn.stage(), "uuid", id)...)
+	}
+	if err := pack.init(pk, conn.packChunkConcurrency, conn.packDownloadDir, conn.packMaxSize); err != nil {
+		return fmt.Errorf("error initialising download of resource pack %v: %v", id, err)
 	}
 
 	// Remove the resource pack from the downloading packs and add it to the awaiting packets.
 	delete(conn.packQueue.downloadingPacks, id)
-	conn.packQueue.awaitingPacks[id] = &downloadingPack
+	conn.packQueue.awaitingPacks[id] = pack
 
-	downloadingPack.chunkSize = pk.DataChunkSize
-	go func() {
-		for i := uint32(0); i < chunkCount; i++ {
-			_ = conn.WritePacket(&packet.ResourcePackChunkRequest{
-				UUID:       pk.UUID,
-				ChunkIndex: i,
-			})
-			select {
-			case frag := <-downloadingPack.newFrag:
-				// Write the fragment to the full buffer of the downloading resource pack.
-				_, _ = downloadingPack.buf.Write(frag)
-			case <-conn.closeCtx.Done():
-				return
-			}
+	go conn.downloadResourcePack(id, pk.UUID, pack)
+	return nil
+}
+
+// downloadResourcePack downloads the resource pack passed by requesting all of its chunks. Up to
+// conn.packChunkConcurrency chunk requests are in flight at the same time, and requests that are not answered
+// within conn.packChunkTimeout are sent again. Once all chunks are received, the resource pack is compiled and
+// added to the resource packs of the connection.
+func (conn *Conn) downloadResourcePack(id, requestID string, pack *downloadingPack) {
+	defer close(pack.done)
+	requests := &chunkRequests{timeout: conn.packChunkTimeout, sent: make(map[uint32]time.Time), attempts: make(map[uint32]int)}
+	received := make([]bool, pack.chunkCount)
+	var next, receivedCount uint32
+
+	request := func(index uint32) error {
+		if err := requests.add(index); err != nil {
+			return err
 		}
-		conn.packMutex.Lock()
-		defer conn.packMutex.Unlock()
+		return conn.WritePacket(&packet.ResourcePackChunkRequest{UUID: requestID, ChunkIndex: index})
+	}
+	fail := func(err error) {
+		pack.discard()
+		conn.log.Error("error downloading resource pack", conn.logContext("stage", conn.stage(), "uuid", id, "err", err)...)
+		// The login sequence cannot be completed without the resource pack, so we close the connection.
+		_ = conn.Close()
+	}
 
-		if downloadingPack.buf.Len() != int(downloadingPack.size) {
-			conn.log.Printf("incorrect resource pack size: expected %v, but got %v\n", downloadingPack.size, downloadingPack.buf.Len())
-			return
+	ticker := time.NewTicker(conn.packChunkTimeout / 4)
+	defer ticker.Stop()
+	for receivedCount < pack.chunkCount {
+		for len(requests.sent) < conn.packChunkConcurrency && next < pack.chunkCount {
+			if err := request(next); err != nil {
+				fail(err)
+				return
+			}
+			next++
 		}
-		// First parse the resource pack from the total byte buffer we obtained.
-		pack, err := resource.FromBytes(downloadingPack.buf.Bytes())
-		if err != nil {
-			conn.log.Printf("invalid full resource pack data for UUID %v: %v\n", id, err)
+		select {
+		case chunk := <-pack.newFrag:
+			if received[chunk.index] {
+				// We already received this chunk, which may happen if we requested it again after a time-out.
+				continue
+			}
+			if err := pack.write(chunk); err != nil {
+				fail(err)
+				return
+			}
+			received[chunk.index] = true
+			receivedCount++
+			delete(requests.sent, chunk.index)
+		case <-ticker.C:
+			for _, index := range requests.expired() {
+				// The chunk requested was not received in time, so we request it again.
+				if err := request(index); err != nil {
+					fail(err)
+					return
+				}
+			}
+		case <-conn.closeCtx.Done():
+			pack.discard()
 			return
 		}
-		conn.packQueue.packAmount--
-		// Finally we add the resource to the resource packs slice.
-		conn.resourcePacks = append(conn.resourcePacks, pack)
-		if conn.packQueue.packAmount == 0 {
-			conn.expect(packet.IDResourcePackStack)
-			_ = conn.WritePacket(&packet.ResourcePackClientResponse{Response: packet.PackResponseAllPacksDownloaded})
+	}
+	// The data downloaded must match the checksum sent by the server, so that a pack of which chunks were
+	// corrupted is never used, nor stored in the pack cache.
+	verified, err := pack.verify()
+	if err != nil {
+		fail(err)
+		return
+	}
+	// First parse the resource pack from the total data we obtained.
+	p, err := pack.compile()
+	if err != nil {
+		conn.log.Error("invalid full resource pack data", conn.logContext("stage", conn.stage(), "uuid", id, "err", err)...)
+		// Like a failed download, the login sequence cannot be completed without the resource pack.
+		_ = conn.Close()
+		return
+	}
+	if conn.packCache != nil && verified {
+		if err := conn.packCache.Put(p); err != nil {
+			conn.log.Warn("error caching resource pack", conn.logContext("stage", conn.stage(), "uuid", id, "err", err)...)
 		}
-	}()
-	return nil
+	}
+	conn.addDownloadedPack(p)
 }
 
 // handleResourcePackChunkData handles a resource pack chunk data packet, which holds a fragment of a resource
 // pack that is being downloaded.
 func (conn *Conn) handleResourcePackChunkData(pk *packet.ResourcePackChunkData) error {
-	pk.UUID = strings.Split(pk.UUID, "_")[0]
-	downloadingPack, ok := conn.packQueue.awaitingPacks[pk.UUID]
+	id := strings.Split(pk.UUID, "_")[0]
+	pack, ok := conn.packQueue.awaitingPacks[id]
 	if !ok {
 		// We haven't received a ResourcePackDataInfo packet from the server, so we can't use this data to
 		// download a resource pack.
 		return fmt.Errorf("resource pack chunk data for resource pack that was not being downloaded")
 	}
-	lastData := downloadingPack.buf.Len()+int(downloadingPack.chunkSize) >= int(downloadingPack.size)
-	if !lastData && uint32(len(pk.Data)) != downloadingPack.chunkSize {
-		// The chunk data didn't have the full size and wasn't the last data to be sent for the resource pack,
-		// meaning we got too little data.
-		return fmt.Errorf("resource pack chunk data had a length of %v, but expected %v", len(pk.Data), downloadingPack.chunkSize)
+	if pk.ChunkIndex >= pack.chunkCount {
+		return fmt.Errorf("resource pack chunk data had chunk index %v, but the pack only has %v chunks", pk.ChunkIndex, pack.chunkCount)
+	}
+	if expected := pack.expectedLen(pk.ChunkIndex); len(pk.Data) != expected {
+		// The chunk data didn't have the size we expected it to have for this chunk index.
+		return fmt.Errorf("resource pack chunk data had a length of %v, but expected %v", len(pk.Data), expected)
 	}
-	if pk.ChunkIndex != downloadingPack.expectedIndex {
-		return fmt.Errorf("resource pack chunk data had chunk index %v, but expected %v", pk.ChunkIndex, downloadingPack.expectedIndex)
+	// If the download is behind, for example because writing the chunks to disk is slow, we wait until it
+	// catches up rather than dropping the chunk, which would only have it requested again.
+	select {
+	case pack.newFrag <- packChunk{index: pk.ChunkIndex, data: pk.Data}:
+	case <-pack.done:
+		// The download already finished or failed, so the chunk is no longer needed.
+	case <-conn.closeCtx.Done():
 	}
-	downloadingPack.expectedIndex++
-	downloadingPack.newFrag <- pk.Data
 	return nil
 }
 
 // handleResourcePackChunkRequest handles a resource pack chunk request, which requests a part of the resource
-// pack to be downloaded.
+// pack to be downloaded. Chunks of any of the resource packs being sent may be requested in any order, and
+// may be requested more than once.
 func (conn *Conn) handleResourcePackChunkRequest(pk *packet.ResourcePackChunkRequest) error {
-	current := conn.packQueue.currentPack
-	if current.UUID() != pk.UUID {
-		return fmt.Errorf("resource pack chunk request had unexpected UUID: expected %v, but got %v", current.UUID(), pk.UUID)
+	id := strings.Split(pk.UUID, "_")[0]
+	current, ok := conn.packQueue.sendingPacks[id]
+	if !ok {
+		return fmt.Errorf("resource pack chunk request had unexpected UUID %v", pk.UUID)
 	}
-	if conn.packQueue.currentOffset != uint64(pk.ChunkIndex)*packChunkSize {
-		return fmt.Errorf("resource pack chunk request had unexpected chunk index: expected %v, but got %v", conn.packQueue.currentOffset/packChunkSize, pk.ChunkIndex)
+	if pk.ChunkIndex >= current.chunkCount {
+		return fmt.Errorf("resource pack chunk request had unexpected chunk index: pack has %v chunks, but got %v", current.chunkCount, pk.ChunkIndex)
 	}
 	response := &packet.ResourcePackChunkData{
 		UUID:       pk.UUID,
 		ChunkIndex: pk.ChunkIndex,
-		DataOffset: conn.packQueue.currentOffset,
+		DataOffset: uint64(pk.ChunkIndex) * packChunkSize,
 		Data:       make([]byte, packChunkSize),
 	}
-	conn.packQueue.currentOffset += packChunkSize
 	// We read the data directly into the response's data.
-	if n, err := current.ReadAt(response.Data, int64(response.DataOffset)); err != nil {
+	n, err := current.pack.ReadAt(response.Data, int64(response.DataOffset))
+	if err != nil && err != io.EOF {
 		// If we hit an EOF, we don't need to return an error, as we've simply reached the end of the content
 		// AKA the last chunk.
-		if err != io.EOF {
-			return fmt.Errorf("error reading resource pack chunk: %v", err)
-		}
-		response.Data = response.Data[:n]
-
-		defer func() {
-			if !conn.packQueue.AllDownloaded() {
-				_ = conn.nextResourcePackDownload()
-			} else {
-				conn.expect(packet.IDResourcePackClientResponse)
-			}
-		}()
+		return fmt.Errorf("error reading resource pack chunk: %v", err)
 	}
+	response.Data = response.Data[:n]
 	if err := conn.WritePacket(response); err != nil {
 		return fmt.Errorf("error writing resource pack chunk data packet: %v", err)
 	}
-
+	// Chunks are large, so we flush each one immediately to make sure that clients requesting multiple chunks
+	// at once do not receive batches that are too large to be decoded.
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("error flushing resource pack chunk data packet: %v", err)
+	}
 	return nil
 }
 
 // handleStartGame handles an incoming StartGame packet. It is the signal that the player has been added to a
 // world, and it obtains most of its dedicated properties.
 func (conn *Conn) handleStartGame(pk *packet.StartGame) error {
-	conn.gameData = GameData{
-		Difficulty:                   pk.Difficulty,
-		WorldName:                    pk.WorldName,
-		EntityUniqueID:               pk.EntityUniqueID,
-		EntityRuntimeID:              pk.EntityRuntimeID,
-		PlayerGameMode:               pk.PlayerGameMode,
-		PlayerPosition:               pk.PlayerPosition.Sub(mgl32.Vec3{0, 1.62}), // Subtract offset position.
-		Pitch:                        pk.Pitch,
-		Yaw:                          pk.Yaw,
-		Dimension:                    pk.Dimension,
-		WorldSpawn:                   pk.WorldSpawn,
-		GameRules:                    pk.GameRules,
-		Time:                         pk.Time,
-		Blocks:                       pk.Blocks,
-		Items:                        pk.Items,
-		ServerAuthoritativeMovement:  pk.ServerAuthoritativeMovement,
-		WorldGameMode:                pk.WorldGameMode,
-		ServerAuthoritativeInventory: pk.ServerAuthoritativeInventory,
-	}
+	conn.gameData = GameDataFromStartGame(pk)
 	conn.loggedIn = true
+	conn.stats.endLogin()
 
 	conn.expect(packet.IDChunkRadiusUpdated, packet.IDPlayStatus)
 	return conn.WritePacket(&packet.RequestChunkRadius{ChunkRadius: int32(conn.chunkRadius)})
@@ -1136,6 +1786,14 @@ func (conn *Conn) handleSetLocalPlayerAsInitialised(pk *packet.SetLocalPlayerAsI
 func (conn *Conn) handlePlayStatus(pk *packet.PlayStatus) error {
 	switch pk.Status {
 	case packet.PlayStatusLoginSuccess:
+		if conn.parent != nil {
+			// Sub-clients share the resource packs of the connection they joined over, so they move on to the
+			// StartGame packet immediately.
+			conn.setStage(DialStageStartGame)
+			conn.expect(packet.IDStartGame)
+			return nil
+		}
+		conn.setStage(DialStagePacks)
 		if err := conn.WritePacket(&packet.ClientCacheStatus{Enabled: conn.cacheEnabled}); err != nil {
 			return fmt.Errorf("error sending client cache status: %v", err)
 		}
@@ -1143,10 +1801,12 @@ func (conn *Conn) handlePlayStatus(pk *packet.PlayStatus) error {
 		conn.expect(packet.IDResourcePacksInfo)
 	case packet.PlayStatusLoginFailedClient:
 		_ = conn.Close()
-		return fmt.Errorf("client outdated")
+		proto := conn.Protocol()
+		return &ProtocolError{Protocol: proto.ID(), Version: proto.Ver(), ClientOutdated: true}
 	case packet.PlayStatusLoginFailedServer:
 		_ = conn.Close()
-		return fmt.Errorf("server outdated")
+		proto := conn.Protocol()
+		return &ProtocolError{Protocol: proto.ID(), Version: proto.Ver()}
 	case packet.PlayStatusPlayerSpawn:
 		// We've spawned and can send the last packet in the spawn sequence.
 		conn.spawn <- true
@@ -1206,6 +1866,18 @@ func (conn *Conn) enableEncryption(clientPublicKey *ecdsa.PublicKey) error {
 	return nil
 }
 
+// setStage sets the DialStage that the connection has reached. For connections accepted by a Listener, the
+// stages are set as their server side equivalent is reached.
+func (conn *Conn) setStage(stage DialStage) {
+	atomic.StoreInt32(&conn.dialStage, int32(stage))
+	conn.stats.enterStage(stage, time.Now())
+}
+
+// stage returns the DialStage that the connection has currently reached.
+func (conn *Conn) stage() DialStage {
+	return DialStage(atomic.LoadInt32(&conn.dialStage))
+}
+
 // expect sets the packet IDs that are next expected to arrive.
 func (conn *Conn) expect(packetIDs ...uint32) {
 	conn.expectedIDs.Store(packetIDs)
@@ -0,0 +1,319 @@
+package minecraft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// blockingConn is a net.Conn of which reads and writes block until it is closed, like a connection to a peer
+// that stopped reading. It ignores deadlines, like some net.Conn implementations do.
+type blockingConn struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+// newBlockingConn returns a new blockingConn.
+func newBlockingConn() *blockingConn {
+	return &blockingConn{closed: make(chan struct{})}
+}
+
+// Read ...
+func (c *blockingConn) Read([]byte) (int, error) {
+	<-c.closed
+	return 0, io.ErrClosedPipe
+}
+
+// Write ...
+func (c *blockingConn) Write([]byte) (int, error) {
+	<-c.closed
+	return 0, io.ErrClosedPipe
+}
+
+// Close ...
+func (c *blockingConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// LocalAddr ...
+func (c *blockingConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// RemoteAddr ...
+func (c *blockingConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// SetDeadline ...
+func (c *blockingConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+// SetReadDeadline ...
+func (c *blockingConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline ...
+func (c *blockingConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func TestConnCloseBlockedWrite(t *testing.T) {
+	netConn := newBlockingConn()
+	conn := newConn(netConn, nil, NopLogger())
+	conn.closeTimeout = time.Millisecond * 100
+
+	if err := conn.WritePacket(&packet.Text{Message: "Hello"}); err != nil {
+		t.Fatalf("error writing packet: %v", err)
+	}
+	if err := conn.Flush(); err != nil {
+		t.Fatalf("error flushing packet: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		_ = conn.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(testTimeout):
+		t.Fatalf("Close did not return while writing to a connection that blocks")
+	}
+	select {
+	case <-netConn.closed:
+	default:
+		t.Fatalf("underlying connection was not closed")
+	}
+}
+
+func TestConnSendBufferLimit(t *testing.T) {
+	netConn := newBlockingConn()
+	conn := newConn(netConn, nil, NopLogger())
+	conn.closeTimeout = time.Millisecond * 100
+	conn.sendBufferSize = 4096
+	defer conn.Close()
+	defer netConn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Millisecond * 200))
+	// Random messages are used so that the batches written do not compress.
+	message := make([]byte, 500)
+	for i := 0; ; i++ {
+		for j := range message {
+			message[j] = byte(rand.Intn(256))
+		}
+		err := conn.WritePacket(&packet.Text{Message: string(message)})
+
+		conn.sendMutex.Lock()
+		size := conn.sendQueue.Len() + conn.bufferedBytes
+		conn.sendMutex.Unlock()
+		if size > conn.sendBufferSize {
+			t.Fatalf("%v bytes buffered and queued, exceeding the send buffer size of %v", size, conn.sendBufferSize)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrSendBufferFull) {
+				t.Fatalf("expected error wrapping ErrSendBufferFull, got %v", err)
+			}
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Fatalf("expected net.Error with a timeout, got %v", err)
+			}
+			return
+		}
+		if i > 1000 {
+			t.Fatalf("writing did not fail after the send buffer was full")
+		}
+	}
+}
+
+func TestConnStatsClosedDuringLogin(t *testing.T) {
+	netConn := newBlockingConn()
+	conn := newConn(netConn, nil, NopLogger())
+	conn.closeTimeout = time.Millisecond * 100
+	conn.setStage(DialStageHandshake)
+	_ = conn.Close()
+
+	// The connection was closed before finishing the login sequence, so the time spent in the stage it was
+	// in must no longer increase.
+	before := conn.Stats().StageDurations[DialStageHandshake]
+	time.Sleep(time.Millisecond * 20)
+	conn.setStage(DialStagePacks)
+	if after := conn.Stats().StageDurations[DialStageHandshake]; after != before {
+		t.Errorf("time spent in stage increased from %v to %v after closing", before, after)
+	}
+	if _, ok := conn.Stats().StageDurations[DialStagePacks]; ok {
+		t.Errorf("stage entered after closing was registered")
+	}
+}
+
+// contextLogger is a Logger that records the key/value pairs of the last message logged.
+type contextLogger struct {
+	mu      sync.Mutex
+	keyvals []interface{}
+}
+
+// Debug ...
+func (l *contextLogger) Debug(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Info ...
+func (l *contextLogger) Info(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Warn ...
+func (l *contextLogger) Warn(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Error ...
+func (l *contextLogger) Error(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// With ...
+func (l *contextLogger) With(...interface{}) Logger { return l }
+
+// record records the key/value pairs passed.
+func (l *contextLogger) record(keyvals []interface{}) {
+	l.mu.Lock()
+	l.keyvals = keyvals
+	l.mu.Unlock()
+}
+
+// keys returns the keys of the key/value pairs last logged.
+func (l *contextLogger) keys() []interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var keys []interface{}
+	for i := 0; i < len(l.keyvals); i += 2 {
+		keys = append(keys, l.keyvals[i])
+	}
+	return keys
+}
+
+func TestConnLogHandleError(t *testing.T) {
+	netConn := newBlockingConn()
+	defer netConn.Close()
+	log := &contextLogger{}
+	conn := newConn(netConn, nil, log)
+	conn.setStage(DialStageHandshake)
+
+	conn.logHandleError(io.EOF)
+	if keys := fmt.Sprint(log.keys()); keys != "[stage err]" {
+		t.Errorf("logged keys %v before login, expected [stage err]", keys)
+	}
+	conn.xuid.Store("2535400000000000")
+	conn.loggedIn = true
+	conn.logHandleError(io.EOF)
+	if keys := fmt.Sprint(log.keys()); keys != "[xuid err]" {
+		t.Errorf("logged keys %v after login, expected [xuid err]", keys)
+	}
+}
+
+func TestConnFlushPolicy(t *testing.T) {
+	text := &packet.Text{TextType: packet.TextTypeRaw, Message: "Hello"}
+	buf := bytes.NewBuffer(nil)
+	_ = (&packet.Header{PacketID: text.ID()}).Write(buf)
+	text.Marshal(buf)
+	size := buf.Len()
+
+	tests := []struct {
+		name   string
+		policy FlushPolicy
+		// batches holds the amount of packets in each batch expected after writing 7 packets. Packets that
+		// remain buffered are not flushed if the interval of the policy is not reached.
+		batches []int
+	}{
+		{name: "MaxBatchPackets", policy: FlushPolicy{Interval: time.Hour, MaxBatchPackets: 3}, batches: []int{3, 3}},
+		{name: "MaxBatchBytes", policy: FlushPolicy{Interval: time.Hour, MaxBatchBytes: size*2 + 1}, batches: []int{3, 3}},
+		{name: "WriteThrough", policy: FlushPolicy{Interval: time.Hour, WriteThrough: true}, batches: []int{1, 1, 1, 1, 1, 1, 1}},
+		{name: "Interval", policy: FlushPolicy{Interval: time.Millisecond * 50}, batches: []int{7}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c1, c2 := net.Pipe()
+			writer, reader := newConn(c1, nil, NopLogger()), newConn(c2, nil, NopLogger())
+			writer.flushPolicy = test.policy
+			defer writer.Close()
+			defer reader.Close()
+
+			batches := make(chan int, 16)
+			go func() {
+				for {
+					packets, err := reader.decoder.Decode()
+					if err != nil {
+						return
+					}
+					batches <- len(packets)
+				}
+			}()
+			for i := 0; i < 7; i++ {
+				if err := writer.WritePacket(text); err != nil {
+					t.Fatalf("error writing packet: %v", err)
+				}
+			}
+
+			var got []int
+			for done := false; !done; {
+				select {
+				case n := <-batches:
+					got = append(got, n)
+				case <-time.After(time.Millisecond * 200):
+					done = true
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.batches) {
+				t.Errorf("batches of %v packets were flushed, expected %v", got, test.batches)
+			}
+		})
+	}
+}
+
+func TestConnOwnedPackets(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	conn := newConn(c2, nil, NopLogger())
+	conn.ownedPackets = true
+	defer conn.Close()
+
+	encode := func(pk packet.Packet) []byte {
+		buf := bytes.NewBuffer(nil)
+		_ = (&packet.Header{PacketID: pk.ID()}).Write(buf)
+		pk.Marshal(buf)
+		return buf.Bytes()
+	}
+	read := func(data []byte) *packet.ResourcePackChunkData {
+		conn.packets <- data
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading packet: %v", err)
+		}
+		return pk.(*packet.ResourcePackChunkData)
+	}
+
+	data := encode(&packet.ResourcePackChunkData{UUID: "first", Data: []byte("first chunk")})
+	first := read(data)
+	// The data that a packet is read from is re-used by the decoder once the next packet is read.
+	for i := range data {
+		data[i] = 0
+	}
+	second := read(encode(&packet.ResourcePackChunkData{UUID: "second", Data: []byte("second chunk")}))
+
+	if first == second {
+		t.Fatalf("the same packet was returned by two calls to ReadPacket")
+	}
+	if first.UUID != "first" || string(first.Data) != "first chunk" {
+		t.Errorf("first packet changed after reading the next packet: UUID %q, data %q", first.UUID, first.Data)
+	}
+	if second.UUID != "second" || string(second.Data) != "second chunk" {
+		t.Errorf("second packet had UUID %q and data %q", second.UUID, second.Data)
+	}
+}
@@ -0,0 +1,73 @@
+package minecraft
+
+import (
+	"sync"
+	"time"
+)
+
+// deadline is a deadline that may be set and changed at any time, including while another goroutine is
+// blocked waiting for it. Its methods may be called from multiple goroutines simultaneously.
+type deadline struct {
+	mu    sync.Mutex
+	timer *time.Timer
+	// exceeded is closed once the deadline passes. It is replaced with a new channel if the deadline is
+	// changed after it passed.
+	exceeded chan struct{}
+}
+
+// newDeadline returns a deadline that is not set.
+func newDeadline() *deadline {
+	return &deadline{exceeded: make(chan struct{})}
+}
+
+// set sets the deadline to the time passed. A zero time clears the deadline, and a time in the past makes
+// the deadline pass immediately. Goroutines currently waiting on the channel returned by wait() observe the
+// new deadline.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		// The timer already fired, so we wait for it to close the channel before replacing it.
+		<-d.exceeded
+	}
+	d.timer = nil
+
+	closed := isClosed(d.exceeded)
+	if t.IsZero() {
+		if closed {
+			d.exceeded = make(chan struct{})
+		}
+		return
+	}
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.exceeded = make(chan struct{})
+		}
+		exceeded := d.exceeded
+		d.timer = time.AfterFunc(dur, func() {
+			close(exceeded)
+		})
+		return
+	}
+	if !closed {
+		close(d.exceeded)
+	}
+}
+
+// wait returns a channel that is closed once the deadline passes.
+func (d *deadline) wait() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.exceeded
+}
+
+// isClosed checks if the channel passed is closed.
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
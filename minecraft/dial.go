@@ -2,10 +2,12 @@ package minecraft
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/sandertv/go-raknet"
@@ -13,20 +15,23 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
-	"io/ioutil"
 	"log"
 	rand2 "math/rand"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Dialer allows specifying specific settings for connection to a Minecraft server.
 // The zero value of Dialer is used for the package level Dial function.
 type Dialer struct {
-	// ErrorLog is a log.Logger that errors that occur during packet handling of servers are written to. By
-	// default, ErrorLog is set to one equal to the global logger.
-	ErrorLog *log.Logger
+	// ErrorLog is a Logger that errors that occur during packet handling of servers are written to. Each
+	// message holds the remote address of the connection and, depending on the message, other context such
+	// as the XUID, packet ID or dial stage. By default, ErrorLog is set to a Logger that writes messages of
+	// LogLevelInfo and higher to the standard error. NewStdLogger may be used to log to a *log.Logger.
+	ErrorLog Logger
 
 	// ClientData is the client data used to login to the server with. It includes fields such as the skin,
 	// locale and UUIDs unique to the client. If empty, a default is sent produced using defaultClientData().
@@ -55,6 +60,66 @@ type Dialer struct {
 	// from the server.
 	SendPacketViolations bool
 
+	// ResourcePackChunkConcurrency is the maximum amount of chunk requests that are in flight at the same
+	// time for a single resource pack downloaded from the server. Higher values generally make downloading
+	// large resource packs faster. If zero, one chunk is requested at a time.
+	ResourcePackChunkConcurrency int
+	// ResourcePackChunkTimeout is the time after which a chunk of a resource pack is requested again if it was
+	// not yet received from the server. A chunk is requested at most four times before the connection is
+	// closed. If zero, a time-out of 10 seconds is used.
+	ResourcePackChunkTimeout time.Duration
+	// ResourcePackDownloadDir is a directory that resource packs downloaded from the server are streamed to,
+	// rather than holding them in memory while downloading. Files are removed once the pack is compiled. If
+	// empty, resource packs are downloaded into memory.
+	ResourcePackDownloadDir string
+	// MaxResourcePackSize is the maximum size in bytes of a single resource pack downloaded from the server.
+	// The connection is closed if the server announces a resource pack larger than this size, before any
+	// memory is allocated for it. If zero, a maximum size of 256 MB is used.
+	MaxResourcePackSize uint64
+	// PackCache is a cache of resource packs downloaded from servers, keyed by the UUID and version of each
+	// pack. The packs that the server sends in the ResourcePacksInfo packet are looked up in the cache. Packs
+	// found are not downloaded, and the cached pack shows up in Conn.ResourcePacks(). Downloaded packs are
+	// only stored if their data matches the checksum sent by the server.
+	// NewDiskPackCache may be used to obtain a PackCache that stores packs in a directory. If nil, resource
+	// packs are not cached.
+	PackCache PackCache
+
+	// FlushPolicy specifies when packets written to the connection are flushed to the server. By default,
+	// packets are flushed every 20th of a second.
+	FlushPolicy FlushPolicy
+	// CompressionLevel is the level at which batches of packets sent to the server are compressed, following
+	// the levels of compress/flate, such as flate.BestSpeed. The compression algorithm and the minimum size of
+	// batches that are compressed are set by the server. If zero, flate.DefaultCompression is used. If the
+	// level is not between flate.HuffmanOnly and flate.BestCompression, dialing fails before anything is
+	// dialed, with an error that is not a *DialError.
+	CompressionLevel int
+	// SendBufferSize is the maximum amount of bytes of packets that are buffered by a connection before they
+	// are written to the server. Once the buffer is full, writing packets blocks until enough of it is
+	// written, or until the write deadline set using Conn.SetWriteDeadline passes, in which case an error
+	// wrapping ErrSendBufferFull is returned. If zero, a size of 16 MB is used.
+	SendBufferSize int
+	// OwnedPackets, if set to true, makes Conn.ReadPacket return a new packet for every packet read, which
+	// owns all of its data, rather than re-using packets and referring to the data received. These packets
+	// may be held and passed to other goroutines freely, at the cost of an allocation and a copy for every
+	// packet read. See Conn.ReadPacket for the guarantees of each mode.
+	OwnedPackets bool
+	// DecodeLimits holds the limits that apply to decoding every packet read from the connection with the
+	// server, such as the maximum amount of elements that the slices and maps of a single packet may hold
+	// together. Packets exceeding these limits fail to decode. If left empty, the defaults of
+	// protocol.DecodeLimits are used.
+	// The error of a packet that fails to decode is passed to the ErrorLog under the 'err' key. It wraps the
+	// typed errors of the protocol package, such as protocol.ElementBudgetError, so that they may be found
+	// using errors.As.
+	DecodeLimits protocol.DecodeLimits
+
+	// Protocol is the Protocol of the Minecraft version that the Dialer connects with. Its protocol number and
+	// version are sent to the server during login, and packets read from and written to the connection are
+	// converted from and to the packets of the current version by it, so that the packets of the packet
+	// package may be used regardless of the version of the server. If nil, DefaultProtocol is used.
+	// If the server refuses the version, the dial fails with a *ProtocolError describing the version that
+	// the server requires.
+	Protocol Protocol
+
 	// EnableClientCache, if set to true, enables the client blob cache for the client. This means that the
 	// server will send chunks as blobs, which may be saved by the client so that chunks don't have to be
 	// transmitted every time, resulting in less network transmission.
@@ -63,6 +128,8 @@ type Dialer struct {
 
 // Dial dials a Minecraft connection to the address passed over the network passed. The network is typically
 // "raknet". A Conn is returned which may be used to receive packets from and send packets to.
+// Connections may also be dialed over the "tcp" and "ws" (WebSocket) networks to servers listening on those
+// networks using a Listener, such as proxies, or over the "memory" network to a Listener in the same process.
 //
 // A zero value of a Dialer struct is used to initiate the connection. A custom Dialer may be used to specify
 // additional behaviour.
@@ -70,60 +137,213 @@ func Dial(network string, address string) (conn *Conn, err error) {
 	return Dialer{}.Dial(network, address)
 }
 
+// DialTimeout dials a Minecraft connection to the address passed over the network passed, like Dial. If the
+// connection is not established within the timeout passed, the dial is cancelled and an error is returned.
+func DialTimeout(network string, address string, timeout time.Duration) (conn *Conn, err error) {
+	return Dialer{}.DialTimeout(network, address, timeout)
+}
+
+// DialContext dials a Minecraft connection to the address passed over the network passed, like Dial. The
+// context passed is used for the entire connection sequence: If it is cancelled or its deadline is exceeded
+// before the connection is established, the dial is aborted and an error is returned.
+func DialContext(ctx context.Context, network string, address string) (conn *Conn, err error) {
+	return Dialer{}.DialContext(ctx, network, address)
+}
+
 // Dial dials a Minecraft connection to the address passed over the network passed. The network is typically
 // "raknet". A Conn is returned which may be used to receive packets from and send packets to.
 // Specific fields in the Dialer specify additional behaviour during the connection, such as authenticating
 // to XBOX Live and custom client data.
 func (dialer Dialer) Dial(network string, address string) (conn *Conn, err error) {
+	return dialer.DialContext(context.Background(), network, address)
+}
+
+// DialTimeout dials a Minecraft connection to the address passed over the network passed, like Dial. If the
+// connection is not established within the timeout passed, the dial is cancelled and an error is returned.
+func (dialer Dialer) DialTimeout(network string, address string, timeout time.Duration) (conn *Conn, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return dialer.DialContext(ctx, network, address)
+}
+
+// DialContext dials a Minecraft connection to the address passed over the network passed. The context passed
+// is used to cancel the dialing of the underlying connection, the XBOX Live authentication requests and the
+// login sequence. Once the connection is established, cancelling the context has no effect on it.
+// If the dial fails, the error returned is a *DialError holding the stage of the connection sequence that was
+// reached. An invalid configuration of the Dialer, such as an invalid CompressionLevel, is reported with a
+// plain error instead, as no stage of the connection sequence was reached.
+func (dialer Dialer) DialContext(ctx context.Context, network string, address string) (conn *Conn, err error) {
+	compression := CompressionPolicy{Level: dialer.CompressionLevel}
+	if err := compression.validate(); err != nil {
+		return nil, err
+	}
 	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 
 	var chainData string
+	authStart := time.Now()
 	if dialer.Email != "" {
-		chainData, err = authChain(dialer.Email, dialer.Password, key)
+		chainData, err = authChain(ctx, dialer.Email, dialer.Password, key)
 		if err != nil {
-			return nil, err
+			if ctx.Err() != nil {
+				// The auth requests failed because the context was cancelled, so we return that error instead.
+				err = ctx.Err()
+			}
+			return nil, &DialError{Stage: DialStageAuth, Err: err}
 		}
 	}
 	if dialer.ErrorLog == nil {
-		dialer.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+		dialer.ErrorLog = NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), LogLevelInfo)
 	}
-	var netConn net.Conn
-
-	switch network {
-	case "raknet":
-		// If the network is specifically 'raknet', we use the raknet library to dial a RakNet connection.
-		netConn, err = raknet.Dialer{ErrorLog: log.New(ioutil.Discard, "", 0)}.Dial(address)
-	default:
-		// If not set to 'raknet', we fall back to the default net.Dial method to find a proper connection for
-		// the network passed.
-		netConn, err = net.Dial(network, address)
+	if dialer.Protocol == nil {
+		dialer.Protocol = DefaultProtocol
 	}
+	transportStart := time.Now()
+	netConn, err := dialTransport(ctx, network, address)
 	if err != nil {
-		return nil, err
+		return nil, &DialError{Stage: DialStageTransport, Err: err}
 	}
 	conn = newConn(netConn, key, dialer.ErrorLog)
-	conn.clientData = defaultClientData(address)
-	conn.identityData = defaultIdentityData()
+	// The auth and transport stages are completed before the connection exists, so we add the time spent in
+	// them to the stats of the connection afterwards.
+	if dialer.Email != "" {
+		conn.stats.enterStage(DialStageAuth, authStart)
+	}
+	conn.stats.enterStage(DialStageTransport, transportStart)
+	conn.clientSide = true
+	conn.setProtocol(dialer.Protocol)
 	conn.packetFunc = dialer.PacketFunc
 	conn.cacheEnabled = dialer.EnableClientCache
 	conn.sendPacketViolations = dialer.SendPacketViolations
+	conn.setStage(DialStageHandshake)
+	if dialer.ResourcePackChunkConcurrency > 0 {
+		conn.packChunkConcurrency = dialer.ResourcePackChunkConcurrency
+	}
+	if dialer.ResourcePackChunkTimeout > 0 {
+		conn.packChunkTimeout = dialer.ResourcePackChunkTimeout
+	}
+	conn.packDownloadDir = dialer.ResourcePackDownloadDir
+	if dialer.MaxResourcePackSize > 0 {
+		conn.packMaxSize = dialer.MaxResourcePackSize
+	}
+	conn.packCache = dialer.PackCache
+	conn.flushPolicy = dialer.FlushPolicy
+	conn.compression = compression
+	conn.ownedPackets = dialer.OwnedPackets
+	conn.decodeLimits = dialer.DecodeLimits
+	if dialer.SendBufferSize > 0 {
+		conn.sendBufferSize = dialer.SendBufferSize
+	}
 	// Disable the batch packet limit so that the server can send packets as often as it wants to.
 	conn.decoder.DisableBatchPacketLimit()
 
+	request := dialer.loginRequest(conn, serverAddress(address, netConn), chainData, key)
+	conn.expect(packet.IDServerToClientHandshake, packet.IDNetworkSettings, packet.IDPlayStatus)
+
+	c := make(chan error, 1)
+	go listenConn(conn, c)
+
+	if err := conn.WritePacket(&packet.Login{ConnectionRequest: request, ClientProtocol: conn.Protocol().ID()}); err != nil {
+		_ = conn.Close()
+		return nil, &DialError{Stage: DialStageHandshake, Err: err}
+	}
+	select {
+	case err := <-c:
+		if err != nil {
+			// The connection was closed before we even were fully 'connected', so we return an error.
+			var protoErr *ProtocolError
+			if errors.As(err, &protoErr) && network == "raknet" {
+				// The server refused our version. Its status holds the version it runs, so we try to add it
+				// to the error.
+				protoErr.ServerProtocol, protoErr.ServerVersion = serverVersion(ctx, address)
+			}
+			return nil, &DialError{Stage: conn.stage(), Err: err}
+		}
+		// We've connected successfully. We return the connection and no error.
+		return conn, nil
+	case <-ctx.Done():
+		// The context was cancelled or its deadline exceeded before the connection was logged in. We close
+		// the connection, which will also stop the listenConn goroutine.
+		_ = conn.Close()
+		return nil, &DialError{Stage: conn.stage(), Err: ctx.Err()}
+	}
+}
+
+// DialSubClient logs in an additional local player, a sub-client, over the connection passed, as happens
+// during split screen game play. The connection passed must have been obtained using a Dialer and must not
+// be a sub-client itself. Up to three sub-clients may join over a single connection.
+// The sub-client logs in using the identity data, client data or XBOX Live account set in the Dialer, like a
+// connection dialed using Dial. The Conn returned shares the underlying connection, its encryption and its
+// resource packs with the connection passed, and may be spawned using Conn.DoSpawn.
+func (dialer Dialer) DialSubClient(conn *Conn) (*Conn, error) {
+	return dialer.DialSubClientContext(context.Background(), conn)
+}
+
+// DialSubClientContext logs in an additional local player over the connection passed, like DialSubClient.
+// The context passed is used to cancel the XBOX Live authentication requests and the login sequence of the
+// sub-client. If the login fails, the error returned is a *DialError holding the stage that was reached.
+func (dialer Dialer) DialSubClientContext(ctx context.Context, conn *Conn) (sub *Conn, err error) {
+	if !conn.clientSide || conn.parent != nil {
+		return nil, &DialError{Stage: DialStageHandshake, Err: fmt.Errorf("sub-clients may only join over a connection obtained using a Dialer")}
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	var chainData string
+	if dialer.Email != "" {
+		chainData, err = authChain(ctx, dialer.Email, dialer.Password, key)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return nil, &DialError{Stage: DialStageAuth, Err: err}
+		}
+	}
+	conn.subClientMu.Lock()
+	sub, err = conn.newSubClient(0, key)
+	conn.subClientMu.Unlock()
+	if err != nil {
+		return nil, &DialError{Stage: DialStageHandshake, Err: err}
+	}
+
+	request := dialer.loginRequest(sub, conn.clientData.ServerAddress, chainData, key)
+	if err := sub.WritePacket(&packet.SubClientLogin{ConnectionRequest: request}); err != nil {
+		_ = sub.Close()
+		return nil, &DialError{Stage: DialStageHandshake, Err: err}
+	}
+	select {
+	case err := <-sub.login:
+		if err != nil {
+			return nil, &DialError{Stage: sub.stage(), Err: err}
+		}
+		return sub, nil
+	case <-conn.closeCtx.Done():
+		return nil, &DialError{Stage: sub.stage(), Err: fmt.Errorf("connection closed")}
+	case <-ctx.Done():
+		_ = sub.Close()
+		return nil, &DialError{Stage: sub.stage(), Err: ctx.Err()}
+	}
+}
+
+// loginRequest sets the client data and identity data of the Conn passed to those set in the Dialer, or to
+// defaults if not set, and encodes a login request holding them, signed using the key passed. If chainData is
+// non-empty, it is used as the XBOX Live certificate chain of the request.
+func (dialer Dialer) loginRequest(conn *Conn, address, chainData string, key *ecdsa.PrivateKey) []byte {
+	conn.clientData = defaultClientData(address, conn.Protocol().Ver())
+	conn.identityData = defaultIdentityData()
 	if dialer.ClientData.SkinID != "" {
 		// If a custom client data struct was set, we change the default.
 		conn.clientData = dialer.ClientData
+		if conn.clientData.GameVersion == "" {
+			conn.clientData.GameVersion = conn.Protocol().Ver()
+		}
+		if conn.clientData.SkinResourcePatch == "" {
+			conn.clientData.SkinResourcePatch = defaultSkinResourcePatch
+		}
 	}
 	var emptyIdentityData login.IdentityData
 	if dialer.IdentityData != emptyIdentityData {
 		// If a custom identity data object was set, we change the default.
 		conn.identityData = dialer.IdentityData
 	}
-	conn.expect(packet.IDServerToClientHandshake, packet.IDPlayStatus)
-
-	c := make(chan struct{})
-	go listenConn(conn, dialer.ErrorLog, c)
-
 	if conn.clientData.AnimatedImageData == nil {
 		conn.clientData.AnimatedImageData = make([]login.SkinAnimation, 0)
 	}
@@ -134,105 +354,164 @@ func (dialer Dialer) Dial(network string, address string) (conn *Conn, err error
 		conn.clientData.PieceTintColours = make([]login.PersonaPieceTintColour, 0)
 	}
 
-	var request []byte
-	if dialer.Email == "" {
+	if chainData == "" {
 		// We haven't logged into the user's XBL account. We create a login request with only one token
 		// holding the identity data set in the Dialer.
-		request = login.EncodeOffline(conn.identityData, conn.clientData, key)
-
-	} else {
-		request = login.Encode(chainData, conn.clientData, key)
-		identityData, _, _ := login.Decode(request)
-		// If we got the identity data from Minecraft auth, we need to make sure we set it in the Conn too, as
-		// we are not aware of the identity data ourselves yet.
-		conn.identityData = identityData
-	}
-	if err := conn.WritePacket(&packet.Login{ConnectionRequest: request, ClientProtocol: protocol.CurrentProtocol}); err != nil {
-		return nil, err
+		conn.xuid.Store(conn.identityData.XUID)
+		return login.EncodeOffline(conn.identityData, conn.clientData, key)
 	}
-	select {
-	case <-c:
-		// We've connected successfully. We return the connection and no error.
-		return conn, nil
-	case <-conn.closeCtx.Done():
-		// The connection was closed before we even were fully 'connected', so we return an error.
-		if conn.disconnectMessage.Load().(string) != "" {
-			return nil, fmt.Errorf("disconnected while connecting: %v", conn.disconnectMessage.Load())
-		}
-		return nil, fmt.Errorf("connection timeout")
+	request := login.Encode(chainData, conn.clientData, key)
+	identityData, _, _ := login.Decode(request)
+	// If we got the identity data from Minecraft auth, we need to make sure we set it in the Conn too, as
+	// we are not aware of the identity data ourselves yet.
+	conn.identityData = identityData
+	conn.xuid.Store(identityData.XUID)
+	return request
+}
+
+// dialTransport dials the underlying connection of a Minecraft connection over the network passed. If the
+// context passed is cancelled before the connection is established, the dial is aborted.
+func dialTransport(ctx context.Context, network string, address string) (net.Conn, error) {
+	if n, ok := networks[network]; ok {
+		return n.Dial(ctx, address)
 	}
+	// If the network is not one of the networks we implement ourselves, we fall back to the default
+	// net.Dialer to find a proper connection for the network passed.
+	var d net.Dialer
+	return d.DialContext(ctx, network, address)
 }
 
 // listenConn listens on the connection until it is closed on another goroutine. The channel passed will
-// receive a value once the connection is logged in.
-func listenConn(conn *Conn, logger *log.Logger, c chan struct{}) {
+// receive a nil error once the connection is logged in, or a non-nil error if the connection was closed
+// before that.
+func listenConn(conn *Conn, c chan error) {
+	var loginErr error
 	defer func() {
 		_ = conn.Close()
+		if !conn.loggedIn {
+			if msg := conn.disconnectMessage.Load().(string); msg != "" {
+				loginErr = fmt.Errorf("disconnected while connecting: %v", msg)
+			} else if loginErr == nil {
+				loginErr = fmt.Errorf("connection timeout")
+			}
+			c <- loginErr
+		}
 	}()
 	for {
 		// We finally arrived at the packet decoding loop. We constantly decode packets that arrive
 		// and push them to the Conn so that they may be processed.
 		packets, err := conn.decoder.Decode()
 		if err != nil {
-			if !raknet.ErrConnectionClosed(err) {
-				logger.Printf("error reading from client connection: %v\n", err)
+			if !errConnectionClosed(err) {
+				conn.log.Error("error reading from server connection", conn.logContext("stage", conn.stage(), "err", err)...)
+				loginErr = err
 			}
 			return
 		}
 		for _, data := range packets {
 			loggedInBefore := conn.loggedIn
 			if err := conn.handleIncoming(data); err != nil {
-				logger.Printf("error: %v", err)
+				conn.logHandleError(err)
+				loginErr = err
 				return
 			}
 			if !loggedInBefore && conn.loggedIn {
 				// This is the signal that the connection was considered logged in, so we put a value in the
 				// channel so that it may be detected.
-				c <- struct{}{}
+				c <- nil
 			}
 		}
 	}
 }
 
+// serverVersion obtains the protocol number and Minecraft version that the RakNet server at the address
+// passed runs by pinging it. If the server could not be pinged before the context passed is cancelled, or if
+// its status could not be parsed, 0 and an empty string are returned.
+func serverVersion(ctx context.Context, address string) (int32, string) {
+	c := make(chan []byte, 1)
+	go func() {
+		pong, _ := raknet.Ping(address)
+		c <- pong
+	}()
+	var pong []byte
+	select {
+	case pong = <-c:
+	case <-ctx.Done():
+		return 0, ""
+	}
+	// The status is formatted as 'MCPE;motd;protocol;version;...'.
+	fields := strings.Split(string(pong), ";")
+	if len(fields) < 4 {
+		return 0, ""
+	}
+	id, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return 0, ""
+	}
+	return int32(id), fields[3]
+}
+
 // authChain requests the Minecraft auth JWT chain using the credentials passed. If successful, an encoded
-// chain ready to be put in a login request is returned.
-func authChain(email, password string, key *ecdsa.PrivateKey) (string, error) {
+// chain ready to be put in a login request is returned. The requests made are cancelled if the context passed
+// is cancelled.
+func authChain(ctx context.Context, email, password string, key *ecdsa.PrivateKey) (string, error) {
 	// Obtain the Live token, and using that the XSTS token.
-	liveToken, err := auth.RequestLiveToken(email, password)
+	liveToken, err := auth.RequestLiveTokenContext(ctx, email, password)
 	if err != nil {
 		return "", fmt.Errorf("error obtaining Live token: %v", err)
 	}
-	xsts, err := auth.RequestXSTSToken(liveToken)
+	xsts, err := auth.RequestXSTSTokenContext(ctx, liveToken)
 	if err != nil {
 		return "", fmt.Errorf("error obtaining XSTS token: %v", err)
 	}
 
 	// Obtain the raw chain data using the
-	chain, err := auth.RequestMinecraftChain(xsts, key)
+	chain, err := auth.RequestMinecraftChainContext(ctx, xsts, key)
 	if err != nil {
 		return "", fmt.Errorf("error obtaining Minecraft auth chain: %v", err)
 	}
 	return chain, nil
 }
 
-// defaultClientData returns a valid, mostly filled out ClientData struct using the connection address
-// passed, which is sent by default, if no other client data is set.
-func defaultClientData(address string) login.ClientData {
+// defaultClientData returns a valid, mostly filled out ClientData struct using the connection address and
+// game version passed, which is sent by default, if no other client data is set.
+func defaultClientData(address, version string) login.ClientData {
 	rand2.Seed(time.Now().Unix())
 	return login.ClientData{
-		ClientRandomID:  rand2.Int63(),
-		DeviceOS:        protocol.DeviceWin10,
-		GameVersion:     protocol.CurrentVersion,
-		DeviceID:        uuid.Must(uuid.NewRandom()).String(),
-		LanguageCode:    "en_GB",
-		ThirdPartyName:  "Steve",
-		SelfSignedID:    uuid.Must(uuid.NewRandom()).String(),
-		ServerAddress:   address,
-		SkinID:          uuid.Must(uuid.NewRandom()).String(),
-		SkinData:        base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0, 0, 0, 255}, 32*64)),
-		SkinImageWidth:  64,
-		SkinImageHeight: 32,
+		ClientRandomID:    rand2.Int63(),
+		DeviceOS:          protocol.DeviceWin10,
+		GameVersion:       version,
+		DeviceID:          uuid.Must(uuid.NewRandom()).String(),
+		LanguageCode:      "en_GB",
+		ThirdPartyName:    "Steve",
+		SelfSignedID:      uuid.Must(uuid.NewRandom()).String(),
+		ServerAddress:     address,
+		SkinID:            uuid.Must(uuid.NewRandom()).String(),
+		SkinData:          base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0, 0, 0, 255}, 32*64)),
+		SkinImageWidth:    64,
+		SkinImageHeight:   32,
+		SkinResourcePatch: defaultSkinResourcePatch,
+	}
+}
+
+// defaultSkinResourcePatch is the skin resource patch sent if no other is set in the client data. Listeners
+// validate the client data of every connection, which fails if the patch does not hold JSON, so one is always
+// sent. It points to the default geometry of a humanoid skin.
+var defaultSkinResourcePatch = base64.StdEncoding.EncodeToString([]byte(`{"geometry":{"default":"geometry.humanoid.custom"}}`))
+
+// serverAddress returns the server address to put in the client data of a connection dialed to the address
+// passed. Servers require it to be a UDP address, so for addresses of another form, such as WebSocket URLs,
+// the remote address of the net.Conn passed is used. Connections that are not over IP, such as those of the
+// memory network, have no server address, so the unspecified address is returned for them.
+func serverAddress(address string, netConn net.Conn) string {
+	switch addr := netConn.RemoteAddr().(type) {
+	case *net.UDPAddr, *net.TCPAddr:
+		if _, _, err := net.SplitHostPort(address); err == nil {
+			return address
+		}
+		return addr.String()
 	}
+	return "0.0.0.0:0"
 }
 
 // defaultIdentityData returns a valid default identity data object which may be used to fill out if the
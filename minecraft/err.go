@@ -0,0 +1,137 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrListenerClosed is returned by Listener.Accept and Listener.AcceptContext if the Listener was closed,
+// either using Listener.Close or Listener.Shutdown. It may be used to tell a clean shutdown of a Listener
+// apart from a failure.
+var ErrListenerClosed = errors.New("accept: listener closed")
+
+// ErrSendBufferFull is returned by the methods writing packets to a Conn if the write deadline of the Conn
+// passes while its send buffer is full, which means the other end of the connection does not keep up with
+// the packets sent to it. The error returned is a net.Error with Timeout() returning true, which wraps
+// ErrSendBufferFull so that it may be checked using errors.Is.
+var ErrSendBufferFull = errors.New("send buffer full")
+
+// timeoutError is returned when a deadline set on a Conn passes. It implements net.Error with Timeout()
+// returning true.
+type timeoutError struct {
+	op  string
+	err error
+}
+
+// Error ...
+func (err *timeoutError) Error() string {
+	if err.err != nil {
+		return fmt.Sprintf("%v: %v: i/o timeout", err.op, err.err)
+	}
+	return fmt.Sprintf("%v: i/o timeout", err.op)
+}
+
+// Timeout ...
+func (err *timeoutError) Timeout() bool {
+	return true
+}
+
+// Temporary ...
+func (err *timeoutError) Temporary() bool {
+	return false
+}
+
+// Unwrap ...
+func (err *timeoutError) Unwrap() error {
+	return err.err
+}
+
+// ProtocolError is returned by the dialing methods of a Dialer, wrapped in a *DialError, if the server
+// refused the protocol version that the Dialer connected with, as reported by the server in a PlayStatus
+// packet.
+type ProtocolError struct {
+	// Protocol and Version are the protocol number and Minecraft version that the Dialer connected with.
+	Protocol int32
+	Version  string
+	// ClientOutdated is true if the server reported the client as outdated, meaning the server requires a
+	// newer version. If false, the server reported itself as outdated and requires an older version.
+	ClientOutdated bool
+	// ServerProtocol and ServerVersion are the protocol number and Minecraft version that the server runs, as
+	// reported in the status of the server. They are only set if the status could be obtained, which is
+	// currently only done for servers dialed over RakNet. Servers may leave the version out of their status,
+	// in which case only ServerProtocol is set.
+	ServerProtocol int32
+	ServerVersion  string
+}
+
+// Error ...
+func (err *ProtocolError) Error() string {
+	outdated, required := "client outdated", "newer"
+	if !err.ClientOutdated {
+		outdated, required = "server outdated", "older"
+	}
+	msg := fmt.Sprintf("%v: server requires a %v version than %v (protocol %v)", outdated, required, err.Version, err.Protocol)
+	if err.ServerVersion != "" {
+		msg += fmt.Sprintf(", server runs %v (protocol %v)", err.ServerVersion, err.ServerProtocol)
+	} else if err.ServerProtocol != 0 {
+		msg += fmt.Sprintf(", server runs protocol %v", err.ServerProtocol)
+	}
+	return msg
+}
+
+// DialStage is a stage in the connection sequence of a Dialer. A DialError returned by a Dialer holds the
+// last stage that was reached before the dial failed. The stage is also used as context when logging errors
+// of connections, both of those dialed and of those accepted by a Listener.
+type DialStage int32
+
+const (
+	// DialStageAuth is the stage in which the XBOX Live and Minecraft auth tokens are requested. It is only
+	// reached if the Dialer has an Email set.
+	DialStageAuth DialStage = iota
+	// DialStageTransport is the stage in which the underlying connection, typically RakNet, is dialed.
+	DialStageTransport
+	// DialStageHandshake is the stage in which the Login packet is sent and encryption is initialised.
+	DialStageHandshake
+	// DialStagePacks is the stage in which resource packs are negotiated and downloaded.
+	DialStagePacks
+	// DialStageStartGame is the stage in which the client waits for the StartGame packet of the server.
+	DialStageStartGame
+)
+
+// String returns a readable name of the DialStage, such as 'transport' or 'start game'.
+func (stage DialStage) String() string {
+	switch stage {
+	case DialStageAuth:
+		return "auth"
+	case DialStageTransport:
+		return "transport"
+	case DialStageHandshake:
+		return "handshake"
+	case DialStagePacks:
+		return "packs"
+	case DialStageStartGame:
+		return "start game"
+	}
+	return fmt.Sprintf("DialStage(%d)", int32(stage))
+}
+
+// DialError is returned by the dialing methods of a Dialer if the connection could not be established. It
+// holds the stage of the connection sequence that was reached and the error that caused the dial to fail.
+// If the dial was cancelled through a context, Err is the error returned by context.Context.Err().
+type DialError struct {
+	// Stage is the stage of the connection sequence that was reached when the dial failed.
+	Stage DialStage
+	// Err is the error that caused the dial to fail.
+	Err error
+}
+
+// Error ...
+func (err *DialError) Error() string {
+	return fmt.Sprintf("dial failed during %v: %v", err.Stage, err.Err)
+}
+
+// Unwrap returns the underlying error of the DialError, so that it may be inspected using errors.Is and
+// errors.As.
+func (err *DialError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,42 @@
+package minecraft
+
+import "time"
+
+// FlushPolicy specifies when the packets written to a Conn are flushed to the underlying connection. Packets
+// written are buffered and sent together in a single batch once flushed. The zero value of FlushPolicy
+// flushes packets every 20th of a second.
+type FlushPolicy struct {
+	// Interval is the time after which packets written to a Conn are flushed. The time is counted from the
+	// moment that the first packet is buffered, so no timer runs while no packets are buffered. If zero, an
+	// interval of a 20th of a second is used.
+	Interval time.Duration
+	// MaxBatchBytes is the maximum total size in bytes of the buffered packets, before compression. Once the
+	// packets buffered reach this size, they are flushed immediately, regardless of the Interval. If zero,
+	// there is no maximum size.
+	MaxBatchBytes int
+	// MaxBatchPackets is the maximum amount of buffered packets. Once this amount of packets is buffered, they
+	// are flushed immediately, regardless of the Interval. If zero, there is no maximum amount.
+	MaxBatchPackets int
+	// WriteThrough, if set to true, makes every packet written to a Conn flush immediately, so that each
+	// packet is sent in a batch of its own. It is equivalent to calling Conn.Flush() after every write.
+	WriteThrough bool
+}
+
+// defaultFlushInterval is the interval at which packets are flushed if no Interval is set in a FlushPolicy.
+const defaultFlushInterval = time.Second / 20
+
+// interval returns the interval of the FlushPolicy, or the default interval if none was set.
+func (policy FlushPolicy) interval() time.Duration {
+	if policy.Interval <= 0 {
+		return defaultFlushInterval
+	}
+	return policy.Interval
+}
+
+// shouldFlush checks if packets buffered should be flushed immediately, provided the amount of packets and
+// the total size of them in bytes.
+func (policy FlushPolicy) shouldFlush(packets, bytes int) bool {
+	return policy.WriteThrough ||
+		(policy.MaxBatchPackets > 0 && packets >= policy.MaxBatchPackets) ||
+		(policy.MaxBatchBytes > 0 && bytes >= policy.MaxBatchBytes)
+}
@@ -0,0 +1,67 @@
+package minecraft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// framedConn wraps around a net.Conn of a stream oriented network, such as TCP, to preserve the boundaries of
+// the data written to it. Each call to Write sends the data prefixed with its length as a varuint32, which
+// is read by the other end so that each call to Read returns the data of exactly one Write.
+type framedConn struct {
+	net.Conn
+	closeTracker
+	r *bufio.Reader
+
+	writeMu sync.Mutex
+	buf     []byte
+}
+
+// newFramedConn returns a framedConn that frames the data written to and read from the net.Conn passed.
+func newFramedConn(conn net.Conn) *framedConn {
+	return &framedConn{Conn: conn, r: bufio.NewReader(conn)}
+}
+
+// Read reads the data of one frame into the byte slice passed. If the frame does not fit in the byte slice,
+// an error is returned. If the connection was closed by us, an error wrapping errClosed is returned.
+func (conn *framedConn) Read(b []byte) (n int, err error) {
+	length, err := binary.ReadUvarint(conn.r)
+	if err != nil {
+		if err == io.EOF {
+			return 0, conn.closedErr(err)
+		}
+		return 0, fmt.Errorf("error reading frame length: %w", conn.closedErr(err))
+	}
+	if length > uint64(len(b)) {
+		return 0, fmt.Errorf("frame of %v bytes exceeds the maximum of %v bytes", length, len(b))
+	}
+	if _, err := io.ReadFull(conn.r, b[:length]); err != nil {
+		return 0, fmt.Errorf("error reading frame: %w", conn.closedErr(err))
+	}
+	return int(length), nil
+}
+
+// Write writes the data passed as a single frame. The length prefix and the data are written with a single
+// write to the underlying net.Conn.
+func (conn *framedConn) Write(b []byte) (n int, err error) {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+
+	var prefix [binary.MaxVarintLen32]byte
+	prefixLen := binary.PutUvarint(prefix[:], uint64(len(b)))
+	conn.buf = append(append(conn.buf[:0], prefix[:prefixLen]...), b...)
+	if _, err := conn.Conn.Write(conn.buf); err != nil {
+		return 0, conn.closedErr(err)
+	}
+	return len(b), nil
+}
+
+// Close closes the underlying net.Conn.
+func (conn *framedConn) Close() error {
+	conn.markClosed()
+	return conn.Conn.Close()
+}
@@ -3,6 +3,7 @@ package minecraft
 import (
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
 
 // GameData is a loose wrapper around a part of the data found in the StartGame packet. It holds data sent
@@ -68,3 +69,28 @@ type GameData struct {
 	// some extent been preserved, but will eventually be removed.
 	ServerAuthoritativeInventory bool
 }
+
+// GameDataFromStartGame returns the GameData held by the StartGame packet passed, as a Conn obtained using a
+// Dialer stores it when it receives the packet. The position of the player in the packet is at eye height,
+// so the eye height of 1.62 is subtracted from it.
+func GameDataFromStartGame(pk *packet.StartGame) GameData {
+	return GameData{
+		Difficulty:                   pk.Difficulty,
+		WorldName:                    pk.WorldName,
+		EntityUniqueID:               pk.EntityUniqueID,
+		EntityRuntimeID:              pk.EntityRuntimeID,
+		PlayerGameMode:               pk.PlayerGameMode,
+		PlayerPosition:               pk.PlayerPosition.Sub(mgl32.Vec3{0, 1.62}), // Subtract offset position.
+		Pitch:                        pk.Pitch,
+		Yaw:                          pk.Yaw,
+		Dimension:                    pk.Dimension,
+		WorldSpawn:                   pk.WorldSpawn,
+		GameRules:                    pk.GameRules,
+		Time:                         pk.Time,
+		Blocks:                       pk.Blocks,
+		Items:                        pk.Items,
+		ServerAuthoritativeMovement:  pk.ServerAuthoritativeMovement,
+		WorldGameMode:                pk.WorldGameMode,
+		ServerAuthoritativeInventory: pk.ServerAuthoritativeInventory,
+	}
+}
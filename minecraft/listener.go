@@ -1,15 +1,17 @@
 package minecraft
 
 import (
+	"context"
 	"fmt"
 	"github.com/sandertv/go-raknet"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
-	"io/ioutil"
 	"log"
 	"net"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,9 +21,11 @@ import (
 // login sequence of connecting clients and provides the implements the net.Listener interface to provide a
 // consistent API.
 type Listener struct {
-	// ErrorLog is a log.Logger that errors that occur during packet handling of clients are written to. By
-	// default, ErrorLog is set to one equal to the global logger.
-	ErrorLog *log.Logger
+	// ErrorLog is a Logger that errors that occur during packet handling of clients are written to. Each
+	// message holds the remote address of the connection and, depending on the message, other context such
+	// as the XUID, packet ID or login stage. By default, ErrorLog is set to a Logger that writes messages of
+	// LogLevelInfo and higher to the standard error. NewStdLogger may be used to log to a *log.Logger.
+	ErrorLog Logger
 
 	// AuthenticationDisables specifies if authentication of players that join is disabled. If set to true, no
 	// verification will be done to ensure that the player connecting is authenticated using their XBOX Live
@@ -40,9 +44,49 @@ type Listener struct {
 	// set to false, if set to true, the lowest supported version will be displayed.
 	ShowVersion bool
 
+	// AcceptedProtocols is a slice of Protocols accepted by the Listener in addition to DefaultProtocol, the
+	// protocol of the current version. Clients that connect with the protocol number of one of these versions
+	// are accepted, and packets read from and written to them are converted from and to the packets of the
+	// current version by their Protocol, so that the packets of the packet package may be used for all
+	// clients. Conn.Protocol returns the protocol that a client connected with.
+	AcceptedProtocols []Protocol
+
 	// ResourcePacks is a slice of resource packs that the listener may hold. Each client will be asked to
 	// download these resource packs upon joining.
 	ResourcePacks []*resource.Pack
+	// ResourcePacksFor is an optional function that returns the resource packs that a specific connection
+	// should be asked to download. If set, it is called for every connection during login, after its login
+	// request has been decoded, so that the ClientData and IdentityData of the connection may be used to
+	// select the packs, for example by device OS or language. The packs returned replace the ResourcePacks
+	// field for that connection.
+	// ResourcePacksFor may be called from multiple goroutines simultaneously.
+	ResourcePacksFor func(conn *Conn) []*resource.Pack
+
+	// FlushPolicy specifies when packets written to connections of the Listener are flushed to the client.
+	// By default, packets are flushed every 20th of a second.
+	FlushPolicy FlushPolicy
+	// CompressionPolicy specifies how batches of packets sent over connections of the Listener are
+	// compressed. It is sent to clients during login, so that they compress the batches they send the same
+	// way. By default, batches of 512 bytes and larger are compressed using flate at its default level.
+	CompressionPolicy CompressionPolicy
+	// SendBufferSize is the maximum amount of bytes of packets that are buffered by a connection before they
+	// are written to the client. Once the buffer is full, writing packets blocks until enough of it is
+	// written, or until the write deadline set using Conn.SetWriteDeadline passes, in which case an error
+	// wrapping ErrSendBufferFull is returned. If zero, a size of 16 MB is used.
+	SendBufferSize int
+	// OwnedPackets, if set to true, makes Conn.ReadPacket return a new packet for every packet read, which
+	// owns all of its data, rather than re-using packets and referring to the data received. These packets
+	// may be held and passed to other goroutines freely, at the cost of an allocation and a copy for every
+	// packet read. See Conn.ReadPacket for the guarantees of each mode.
+	OwnedPackets bool
+	// DecodeLimits holds the limits that apply to decoding every packet read from the connection of a client,
+	// such as the maximum amount of elements that the slices and maps of a single packet may hold together.
+	// Packets exceeding these limits fail to decode. If left empty, the defaults of protocol.DecodeLimits are
+	// used.
+	// The error of a packet that fails to decode is passed to the ErrorLog under the 'err' key. It wraps the
+	// typed errors of the protocol package, such as protocol.ElementBudgetError, so that they may be found
+	// using errors.As.
+	DecodeLimits protocol.DecodeLimits
 	// TexturePacksRequired specifies if clients that join must accept the texture pack in order for them to
 	// be able to join the server. If they don't accept, they can only leave the server.
 	TexturePacksRequired bool
@@ -53,6 +97,14 @@ type Listener struct {
 	// from which the packet originated, and the destination address.
 	PacketFunc func(header packet.Header, payload []byte, src, dst net.Addr)
 
+	// AdmitFunc is called for every connection that logs in, after its login request is verified and decoded,
+	// but before encryption is enabled. It is passed the identity data and client data of the connection and
+	// the address it connected from. If AdmitFunc returns false, the connection is disconnected with the
+	// reason returned, and it is never accepted. AdmitFunc may be used to implement whitelists, ban lists or
+	// limits on connections per IP.
+	// AdmitFunc may be called from multiple goroutines simultaneously. If nil, all connections are admitted.
+	AdmitFunc func(identity login.IdentityData, client login.ClientData, addr net.Addr) (ok bool, reason string)
+
 	// SendPacketViolations makes the Listener send PacketViolationWarnings to clients connected when it
 	// receives packets it cannot decode properly. Additionally, it will log PacketViolationWarnings coming
 	// from the client.
@@ -67,28 +119,42 @@ type Listener struct {
 	hijackingPong atomic.Value
 	incoming      chan *Conn
 	close         chan struct{}
+	closeOnce     sync.Once
+	closeErr      error
+	// shutdownMessage holds the message that connections are disconnected with if the Listener is shut down
+	// using Shutdown. It is not set if the Listener is closed using Close.
+	shutdownMessage atomic.Value
+
+	connMu sync.Mutex
+	// conns holds all connections of the Listener, both those that are still logging in and those that were
+	// accepted. The value is true if the connection was accepted. Connections are removed when they are
+	// closed.
+	conns map[*Conn]bool
+	// connWg is a sync.WaitGroup that is done once all connections of the Listener are closed.
+	connWg sync.WaitGroup
+	// closedStats holds the combined stats of all connections of the Listener that were closed.
+	closedStats ConnStats
 
 	mu sync.Mutex
 	p  ServerStatusProvider
 }
 
-// Listen announces on the local network address. The network is typically "raknet".
+// Listen announces on the local network address. The network is typically "raknet". Minecraft connections may
+// also be accepted over "tcp" and "ws" (WebSocket) networks, for example between proxies, in which case the
+// batches sent are framed so that their boundaries are preserved. The "memory" network accepts connections
+// dialed within the same process only, without using sockets, which is useful for tests. Other networks are
+// listened on using net.Listen.
 // If the host in the address parameter is empty or a literal unspecified IP address, Listen listens on all
 // available unicast and anycast IP addresses of the local system.
 func (listener *Listener) Listen(network, address string) error {
+	if err := listener.CompressionPolicy.validate(); err != nil {
+		return err
+	}
 	var netListener net.Listener
 	var err error
-	switch network {
-	case "raknet":
-		// Listen specifically for the RakNet network type, as the standard library (obviously) doesn't
-		// implement that.
-		var l *raknet.Listener
-		l, err = raknet.Listen(address)
-		if err == nil {
-			l.ErrorLog = log.New(ioutil.Discard, "", 0)
-			netListener = l
-		}
-	default:
+	if n, ok := networks[network]; ok {
+		netListener, err = n.Listen(address)
+	} else {
 		// Otherwise fall back to the standard net.Listen.
 		netListener, err = net.Listen(network, address)
 	}
@@ -98,7 +164,7 @@ func (listener *Listener) Listen(network, address string) error {
 	var count int32
 
 	if listener.ErrorLog == nil {
-		listener.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+		listener.ErrorLog = NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), LogLevelInfo)
 	}
 	if listener.ServerName == "" {
 		listener.ServerName = "Minecraft Server"
@@ -106,6 +172,8 @@ func (listener *Listener) Listen(network, address string) error {
 	listener.listener = netListener
 	listener.incoming = make(chan *Conn)
 	listener.close = make(chan struct{})
+	listener.conns = make(map[*Conn]bool)
+	listener.closedStats = newConnStats()
 	listener.hijackingPong.Store(false)
 	listener.playerCount = &count
 
@@ -114,8 +182,8 @@ func (listener *Listener) Listen(network, address string) error {
 	return nil
 }
 
-// Listen announces on the local network address. The network must be "tcp", "tcp4", "tcp6", "unix",
-// "unixpacket" or "raknet". A Listener is returned which may be used to accept connections.
+// Listen announces on the local network address. The network must be "raknet", "tcp", "tcp4", "tcp6",
+// "unix", "unixpacket", "ws" or "memory". A Listener is returned which may be used to accept connections.
 // If the host in the address parameter is empty or a literal unspecified IP address, Listen listens on all
 // available unicast and anycast IP addresses of the local system.
 // Listen has the default values for the fields of Listener filled out. To use different values for these
@@ -129,13 +197,33 @@ func Listen(network, address string) (*Listener, error) {
 // Accept accepts a fully connected (on Minecraft layer) connection which is ready to receive and send
 // packets. It is recommended to cast the net.Conn returned to a *minecraft.Conn so that it is possible to
 // use the conn.ReadPacket() and conn.WritePacket() methods.
-// Accept returns an error if the listener is closed.
+// Sub-clients, additional local players that join over the connection of a client accepted earlier, as
+// happens during split screen game play, are also accepted as a *minecraft.Conn of their own once their
+// login request is verified. Conn.SubClientID may be used to tell them apart.
+// Accept returns ErrListenerClosed if the listener is closed.
 func (listener *Listener) Accept() (net.Conn, error) {
-	conn, ok := <-listener.incoming
-	if !ok {
-		return nil, fmt.Errorf("accept: listener closed")
+	return listener.AcceptContext(context.Background())
+}
+
+// AcceptContext accepts a fully connected (on Minecraft layer) connection, like Accept. If the context passed
+// is cancelled before a connection is accepted, AcceptContext returns the error of the context.
+// AcceptContext returns ErrListenerClosed if the listener is closed.
+func (listener *Listener) AcceptContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case conn := <-listener.incoming:
+		// The connection only shows up in Conns once it is accepted. It may already have been closed and
+		// removed from the listener, in which case it is not added again.
+		listener.connMu.Lock()
+		if _, ok := listener.conns[conn]; ok {
+			listener.conns[conn] = true
+		}
+		listener.connMu.Unlock()
+		return conn, nil
+	case <-listener.close:
+		return nil, ErrListenerClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	return conn, nil
 }
 
 // Disconnect disconnects a Minecraft Conn passed by first sending a disconnect with the message passed, and
@@ -149,6 +237,85 @@ func (listener *Listener) Disconnect(conn *Conn, message string) error {
 	return conn.Close()
 }
 
+// Conns returns a list of all connections that were accepted by the Listener and are still open. The order
+// of the connections in the slice returned is undefined.
+func (listener *Listener) Conns() []*Conn {
+	listener.connMu.Lock()
+	defer listener.connMu.Unlock()
+
+	conns := make([]*Conn, 0, len(listener.conns))
+	for conn, accepted := range listener.conns {
+		if accepted {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// Stats returns the combined stats of all connections of the Listener, including those still logging in and
+// those that were closed. Connections that were rejected are included too, whether the server was full, the
+// Listener was closed or the AdmitFunc refused them. The StageDurations of the ConnStats returned hold the
+// total time spent in each stage over all connections.
+func (listener *Listener) Stats() ConnStats {
+	listener.connMu.Lock()
+	defer listener.connMu.Unlock()
+
+	stats := newConnStats()
+	stats.add(listener.closedStats)
+	for conn := range listener.conns {
+		stats.add(conn.Stats())
+	}
+	return stats
+}
+
+// ConnByXUID looks up an accepted connection of the Listener by the XUID in its identity data. If found, the
+// connection is returned and the bool returned is true. Connections that were not authenticated with XBOX
+// Live have no XUID and cannot be found using ConnByXUID.
+func (listener *Listener) ConnByXUID(xuid string) (*Conn, bool) {
+	if xuid == "" {
+		return nil, false
+	}
+	for _, conn := range listener.Conns() {
+		if conn.IdentityData().XUID == xuid {
+			return conn, true
+		}
+	}
+	return nil, false
+}
+
+// ConnByName looks up an accepted connection of the Listener by the display name in its identity data. The
+// name is compared case insensitively. If found, the connection is returned and the bool returned is true.
+func (listener *Listener) ConnByName(name string) (*Conn, bool) {
+	for _, conn := range listener.Conns() {
+		if strings.EqualFold(conn.IdentityData().DisplayName, name) {
+			return conn, true
+		}
+	}
+	return nil, false
+}
+
+// Broadcast writes the packet passed to all connections accepted by the Listener.
+func (listener *Listener) Broadcast(pk packet.Packet) {
+	for _, conn := range listener.Conns() {
+		_ = conn.WritePacket(pk)
+	}
+}
+
+// DisconnectAll disconnects all connections accepted by the Listener with the message passed, like
+// Disconnect. Connections that are still logging in are not disconnected. The connections are disconnected
+// concurrently, and DisconnectAll returns once all of them are closed.
+func (listener *Listener) DisconnectAll(message string) {
+	var wg sync.WaitGroup
+	for _, conn := range listener.Conns() {
+		wg.Add(1)
+		go func(conn *Conn) {
+			defer wg.Done()
+			_ = listener.Disconnect(conn, message)
+		}(conn)
+	}
+	wg.Wait()
+}
+
 // StatusProvider sets a server status provider to dynamically provide the status of the server.
 // StatusProvider will overwrite the status shown in the server list through the MaximumPlayers field and the
 // current connected players.
@@ -178,9 +345,15 @@ func (listener *Listener) StatusProvider(p ServerStatusProvider) {
 // If the address passed could not be resolved, an error is returned.
 // Calling HijackPong means that any current and future pong data set using listener.PongData is overwritten
 // each update.
+// HijackPong is only supported by Listeners listening on the "raknet" network. For other networks, an error
+// is returned.
 func (listener *Listener) HijackPong(address string) error {
+	rakListener, ok := listener.listener.(*raknet.Listener)
+	if !ok {
+		return fmt.Errorf("hijacking pong data is only supported on the raknet network")
+	}
 	listener.hijackingPong.Store(true)
-	return listener.listener.(*raknet.Listener).HijackPong(address)
+	return rakListener.HijackPong(address)
 }
 
 // Addr returns the address of the underlying listener.
@@ -189,14 +362,78 @@ func (listener *Listener) Addr() net.Addr {
 }
 
 // Close closes the listener and the underlying net.Listener. Pending calls to Accept will fail immediately.
+// Connections that were already accepted are not closed. Connections that were still logging in are closed.
 func (listener *Listener) Close() error {
-	return listener.listener.Close()
+	return listener.stop(true)
+}
+
+// stop closes the listener and the underlying net.Listener. If closePending is true, connections that are
+// still logging in are closed too. Only the first call to stop has any effect.
+func (listener *Listener) stop(closePending bool) error {
+	listener.closeOnce.Do(func() {
+		close(listener.close)
+		listener.closeErr = listener.listener.Close()
+		if !closePending {
+			return
+		}
+		listener.connMu.Lock()
+		defer listener.connMu.Unlock()
+		for conn, accepted := range listener.conns {
+			if !accepted {
+				// Closing a connection may take a while if the client stopped reading, so the connections are
+				// closed concurrently.
+				go func(conn *Conn) {
+					_ = conn.Close()
+				}(conn)
+			}
+		}
+	})
+	return listener.closeErr
+}
+
+// Shutdown gracefully shuts down the listener. It stops accepting new connections and disconnects all
+// connections of the listener, both those that are still logging in and those that were accepted, with the
+// message passed. Shutdown then waits until all connections are closed, or until the context passed is
+// cancelled, in which case the error of the context is returned.
+func (listener *Listener) Shutdown(ctx context.Context, message string) error {
+	listener.shutdownMessage.Store(message)
+	// Connections still logging in are not closed, as they are disconnected with the message below.
+	err := listener.stop(false)
+
+	listener.connMu.Lock()
+	conns := make([]*Conn, 0, len(listener.conns))
+	for conn := range listener.conns {
+		conns = append(conns, conn)
+	}
+	listener.connMu.Unlock()
+
+	for _, conn := range conns {
+		// Closing a connection may take a while if the client stopped reading, so the connections are
+		// disconnected concurrently.
+		go func(conn *Conn) {
+			_ = listener.Disconnect(conn, message)
+		}(conn)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		listener.connWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // updatePongData updates the pong data of the listener using the current only players, maximum players and
 // server name of the listener, provided the listener isn't currently hijacking the pong of another server.
 func (listener *Listener) updatePongData() {
-	if listener.hijackingPong.Load().(bool) {
+	rakListener, ok := listener.listener.(*raknet.Listener)
+	if !ok || listener.hijackingPong.Load().(bool) {
+		// Only RakNet listeners have pong data: Other networks do not support pinging.
 		return
 	}
 
@@ -227,8 +464,6 @@ func (listener *Listener) updatePongData() {
 		ver = protocol.CurrentVersion
 	}
 
-	rakListener := listener.listener.(*raknet.Listener)
-
 	rakListener.PongData([]byte(fmt.Sprintf("MCPE;%v;%v;%v;%v;%v;%v;Minecraft Server;%v;%v;%v;%v;",
 		serverName, protocol.CurrentProtocol, ver, current, maxCount, rakListener.ID(),
 		"Creative", 1, listener.Addr().(*net.UDPAddr).Port, listener.Addr().(*net.UDPAddr).Port,
@@ -240,8 +475,6 @@ func (listener *Listener) updatePongData() {
 func (listener *Listener) listen() {
 	listener.updatePongData()
 	defer func() {
-		close(listener.incoming)
-		close(listener.close)
 		_ = listener.Close()
 	}()
 	for {
@@ -262,51 +495,147 @@ func (listener *Listener) createConn(netConn net.Conn) {
 	conn.packetFunc = listener.PacketFunc
 	conn.texturePacksRequired = listener.TexturePacksRequired
 	conn.resourcePacks = listener.ResourcePacks
+	conn.resourcePacksFor = listener.ResourcePacksFor
 	conn.gameData.WorldName = listener.ServerName
 	conn.authEnabled = !listener.AuthenticationDisabled
+	conn.admitFunc = listener.AdmitFunc
+	conn.flushPolicy = listener.FlushPolicy
+	conn.compression = listener.CompressionPolicy
+	conn.ownedPackets = listener.OwnedPackets
+	conn.decodeLimits = listener.DecodeLimits
+	if listener.SendBufferSize > 0 {
+		conn.sendBufferSize = listener.SendBufferSize
+	}
 	conn.sendPacketViolations = listener.SendPacketViolations
+	conn.subClientFunc = listener.createSubConn
+	conn.acceptedProto = append([]Protocol{DefaultProtocol}, listener.AcceptedProtocols...)
+	// Connections accepted by the listener start out waiting for the Login packet, which is the equivalent of
+	// the handshake stage of a client.
+	conn.setStage(DialStageHandshake)
+
+	if listener.addConn(conn) {
+		go listener.handleConn(conn)
+	}
+}
 
+// createSubConn adds a sub-client that joined over a connection of the listener, so that it may be accepted
+// once its login sequence is complete. It is called by the connection the sub-client joined over.
+func (listener *Listener) createSubConn(sub *Conn) {
+	if listener.addConn(sub) {
+		go listener.handleSubConn(sub)
+	}
+}
+
+// addConn adds a connection to the listener and returns true. If the server is full or the listener was
+// closed, the connection is closed instead and false is returned.
+func (listener *Listener) addConn(conn *Conn) bool {
 	if atomic.LoadInt32(listener.playerCount) == int32(listener.MaximumPlayers) && listener.MaximumPlayers != 0 {
 		// The server was full. We kick the player immediately and close the connection.
 		_ = conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginFailedServerFull})
-		_ = conn.Close()
-		return
+		listener.rejectConn(conn)
+		return false
+	}
+	listener.connMu.Lock()
+	select {
+	case <-listener.close:
+		// The listener was closed while the connection was being created, so we don't accept it at all.
+		listener.connMu.Unlock()
+		listener.rejectConn(conn)
+		return false
+	default:
 	}
+	listener.conns[conn] = false
+	listener.connWg.Add(1)
+	listener.connMu.Unlock()
+
 	atomic.AddInt32(listener.playerCount, 1)
 	listener.updatePongData()
+	return true
+}
 
-	go listener.handleConn(conn)
+// rejectConn closes a connection that was not added to the listener. Its stats are added to those of the
+// closed connections of the listener.
+func (listener *Listener) rejectConn(conn *Conn) {
+	_ = conn.Close()
+	listener.connMu.Lock()
+	listener.closedStats.add(conn.Stats())
+	listener.connMu.Unlock()
+}
+
+// removeConn closes a connection of the listener and removes it from the listener.
+func (listener *Listener) removeConn(conn *Conn) {
+	_ = conn.Close()
+	atomic.AddInt32(listener.playerCount, -1)
+	listener.updatePongData()
+
+	listener.connMu.Lock()
+	delete(listener.conns, conn)
+	listener.closedStats.add(conn.Stats())
+	listener.connMu.Unlock()
+	listener.connWg.Done()
+}
+
+// accept passes a connection of the listener that completed its login sequence to a call to Accept, which
+// marks it as accepted. If the listener is closed before that, false is returned.
+func (listener *Listener) accept(conn *Conn) bool {
+	select {
+	case listener.incoming <- conn:
+		return true
+	case <-listener.close:
+		// The listener was closed before the connection could be accepted. If it was shut down gracefully, we
+		// disconnect the connection with the shutdown message.
+		if message, ok := listener.shutdownMessage.Load().(string); ok {
+			_ = listener.Disconnect(conn, message)
+		}
+		return false
+	}
+}
+
+// handleSubConn handles a sub-client that joined over a connection of the Listener. Packets of the sub-client
+// are read and handled by the connection it joined over, so handleSubConn only waits for its login sequence
+// to complete, after which it is accepted, and for it to be closed.
+func (listener *Listener) handleSubConn(sub *Conn) {
+	defer listener.removeConn(sub)
+	select {
+	case err := <-sub.login:
+		if err != nil {
+			return
+		}
+	case <-sub.closeCtx.Done():
+		return
+	}
+	if listener.accept(sub) {
+		<-sub.closeCtx.Done()
+	}
 }
 
 // handleConn handles an incoming connection of the Listener. It will first attempt to get the connection to
 // log in, after which it will expose packets received to the user.
 func (listener *Listener) handleConn(conn *Conn) {
-	defer func() {
-		_ = conn.Close()
-		atomic.AddInt32(listener.playerCount, -1)
-		listener.updatePongData()
-	}()
+	defer listener.removeConn(conn)
 	for {
 		// We finally arrived at the packet decoding loop. We constantly decode packets that arrive
 		// and push them to the Conn so that they may be processed.
 		packets, err := conn.decoder.Decode()
 		if err != nil {
-			if !raknet.ErrConnectionClosed(err) {
-				listener.ErrorLog.Printf("error reading from client connection: %v\n", err)
+			if !errConnectionClosed(err) {
+				conn.log.Error("error reading from client connection", conn.logContext("err", err)...)
 			}
 			return
 		}
 		for _, data := range packets {
 			loggedInBefore := conn.loggedIn
 			if err := conn.handleIncoming(data); err != nil {
-				listener.ErrorLog.Printf("error: %v", err)
+				conn.logHandleError(err)
 				return
 			}
 			if !loggedInBefore && conn.loggedIn {
 				// The connection was previously not logged in, but was after receiving this packet,
 				// meaning the connection is fully completely now. We add it to the channel so that
 				// a call to Accept() can receive it.
-				listener.incoming <- conn
+				if !listener.accept(conn) {
+					return
+				}
 			}
 		}
 	}
@@ -0,0 +1,151 @@
+package minecraft
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is a leveled, structured logger used by a Conn, Dialer and Listener to log errors and other events
+// that occur while handling connections. Each method takes a message and an optional list of alternating
+// keys and values that provide context to the message, such as the remote address of the connection, for
+// example Warn("error decoding packet", "remoteAddr", addr, "packetID", id). Keys are always strings.
+// The methods of a Logger may be called from multiple goroutines simultaneously.
+type Logger interface {
+	// Debug logs a message that is generally only useful when debugging.
+	Debug(msg string, keyvals ...interface{})
+	// Info logs an informational message.
+	Info(msg string, keyvals ...interface{})
+	// Warn logs a message about an event that is unexpected, but that does not close the connection.
+	Warn(msg string, keyvals ...interface{})
+	// Error logs a message about an error, typically one that leads to the connection being closed.
+	Error(msg string, keyvals ...interface{})
+	// With returns a Logger that adds the keys and values passed to every message logged with it, in front
+	// of the keys and values passed to the message itself.
+	With(keyvals ...interface{}) Logger
+}
+
+// LogLevel is the level of a message logged by a Logger.
+type LogLevel int
+
+// The levels below are ordered from lowest to highest. A Logger returned by NewStdLogger drops all messages
+// with a level lower than its own.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String returns the name of the LogLevel in capital letters, such as 'WARN'.
+func (level LogLevel) String() string {
+	switch level {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(level))
+}
+
+// stdLogger is a Logger that writes messages to a *log.Logger.
+type stdLogger struct {
+	log     *log.Logger
+	level   LogLevel
+	keyvals []interface{}
+}
+
+// NewStdLogger returns a Logger that writes messages to the *log.Logger passed. Messages with a level lower
+// than the level passed are dropped. Each message is written on a single line, formatted as
+// 'LEVEL message key=value key=value'.
+func NewStdLogger(l *log.Logger, level LogLevel) Logger {
+	return stdLogger{log: l, level: level}
+}
+
+// Debug ...
+func (l stdLogger) Debug(msg string, keyvals ...interface{}) {
+	l.write(LogLevelDebug, msg, keyvals)
+}
+
+// Info ...
+func (l stdLogger) Info(msg string, keyvals ...interface{}) {
+	l.write(LogLevelInfo, msg, keyvals)
+}
+
+// Warn ...
+func (l stdLogger) Warn(msg string, keyvals ...interface{}) {
+	l.write(LogLevelWarn, msg, keyvals)
+}
+
+// Error ...
+func (l stdLogger) Error(msg string, keyvals ...interface{}) {
+	l.write(LogLevelError, msg, keyvals)
+}
+
+// With ...
+func (l stdLogger) With(keyvals ...interface{}) Logger {
+	// We make sure to copy the key/value pairs, so that loggers derived from the same logger never share
+	// the same backing array.
+	l.keyvals = append(append([]interface{}(nil), l.keyvals...), keyvals...)
+	return l
+}
+
+// write formats the message passed with all key/value pairs and writes it to the underlying *log.Logger, if
+// the level passed is not lower than the level of the logger.
+func (l stdLogger) write(level LogLevel, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+	b := &strings.Builder{}
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	writeKeyvals(b, l.keyvals)
+	writeKeyvals(b, keyvals)
+	_ = l.log.Output(3, b.String())
+}
+
+// writeKeyvals writes the key/value pairs passed to the strings.Builder, each formatted as ' key=value'.
+// Values holding spaces are quoted.
+func writeKeyvals(b *strings.Builder, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		var val interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			val = keyvals[i+1]
+		}
+		str := fmt.Sprint(val)
+		if strings.ContainsAny(str, " \t\n\"=") {
+			str = fmt.Sprintf("%q", str)
+		}
+		_, _ = fmt.Fprintf(b, " %v=%v", keyvals[i], str)
+	}
+}
+
+// nopLogger is a Logger that drops all messages logged.
+type nopLogger struct{}
+
+// NopLogger returns a Logger that drops all messages logged to it.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// Debug ...
+func (nopLogger) Debug(string, ...interface{}) {}
+
+// Info ...
+func (nopLogger) Info(string, ...interface{}) {}
+
+// Warn ...
+func (nopLogger) Warn(string, ...interface{}) {}
+
+// Error ...
+func (nopLogger) Error(string, ...interface{}) {}
+
+// With ...
+func (nopLogger) With(...interface{}) Logger {
+	return nopLogger{}
+}
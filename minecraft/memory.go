@@ -0,0 +1,226 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// memoryNetwork implements a network of connections within the same process, which never touch a socket. It
+// is registered as the "memory" network, so that a Listener and a Dialer may be connected to each other
+// without using the network stack of the system, for example in tests.
+// The addresses of the memory network are arbitrary, non-empty names. Only one Listener may listen on a name
+// at a time.
+type memoryNetwork struct{}
+
+// memoryBufferSize is the amount of batches that may be written to a memory connection before writing to it
+// blocks until the other end reads.
+const memoryBufferSize = 256
+
+var (
+	memoryMu sync.Mutex
+	// memoryListeners holds the listeners of the memory network by the address they listen on.
+	memoryListeners = map[string]*memoryListener{}
+	// memoryConnCount is the amount of connections dialed over the memory network. It is used to give each
+	// connection dialed a unique local address.
+	memoryConnCount uint64
+)
+
+// Dial ...
+func (memoryNetwork) Dial(ctx context.Context, address string) (net.Conn, error) {
+	memoryMu.Lock()
+	listener, ok := memoryListeners[address]
+	memoryMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("connection refused: no memory listener on %v", address)
+	}
+	local := memoryAddr(fmt.Sprintf("%v#%v", address, atomic.AddUint64(&memoryConnCount, 1)))
+	client, server := newMemoryPipe(local, memoryAddr(address))
+	select {
+	case listener.incoming <- server:
+		return client, nil
+	case <-listener.closed:
+		return nil, fmt.Errorf("connection refused: memory listener on %v closed", address)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Listen ...
+func (memoryNetwork) Listen(address string) (net.Listener, error) {
+	if address == "" {
+		return nil, fmt.Errorf("memory network address must not be empty")
+	}
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	if _, ok := memoryListeners[address]; ok {
+		return nil, fmt.Errorf("memory network address %v already in use", address)
+	}
+	listener := &memoryListener{addr: memoryAddr(address), incoming: make(chan net.Conn), closed: make(chan struct{})}
+	memoryListeners[address] = listener
+	return listener, nil
+}
+
+// memoryAddr is the net.Addr of a connection or listener of the memory network.
+type memoryAddr string
+
+// Network ...
+func (memoryAddr) Network() string {
+	return "memory"
+}
+
+// String ...
+func (addr memoryAddr) String() string {
+	return string(addr)
+}
+
+// memoryListener implements a net.Listener of the memory network.
+type memoryListener struct {
+	addr      memoryAddr
+	incoming  chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for a connection to be dialed to the listener and returns it. If the listener is closed,
+// io.ErrClosedPipe is returned.
+func (listener *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-listener.incoming:
+		return conn, nil
+	case <-listener.closed:
+		return nil, io.ErrClosedPipe
+	}
+}
+
+// Close closes the listener, so that its address may be listened on again. Connections accepted earlier
+// remain open.
+func (listener *memoryListener) Close() error {
+	listener.closeOnce.Do(func() {
+		memoryMu.Lock()
+		delete(memoryListeners, string(listener.addr))
+		memoryMu.Unlock()
+		close(listener.closed)
+	})
+	return nil
+}
+
+// Addr ...
+func (listener *memoryListener) Addr() net.Addr {
+	return listener.addr
+}
+
+// memoryConn implements one end of a connection of the memory network. Every call to Write passes a copy of
+// the data to the other end, which receives it with exactly one call to Read.
+type memoryConn struct {
+	local, remote net.Addr
+
+	in, out chan []byte
+
+	closed, remoteClosed chan struct{}
+	closeOnce            sync.Once
+
+	readDeadline, writeDeadline *deadline
+}
+
+// newMemoryPipe returns the two ends of a new connection of the memory network, with the addresses passed.
+func newMemoryPipe(a, b net.Addr) (*memoryConn, *memoryConn) {
+	ab, ba := make(chan []byte, memoryBufferSize), make(chan []byte, memoryBufferSize)
+	aClosed, bClosed := make(chan struct{}), make(chan struct{})
+	connA := &memoryConn{local: a, remote: b, in: ba, out: ab, closed: aClosed, remoteClosed: bClosed,
+		readDeadline: newDeadline(), writeDeadline: newDeadline()}
+	connB := &memoryConn{local: b, remote: a, in: ab, out: ba, closed: bClosed, remoteClosed: aClosed,
+		readDeadline: newDeadline(), writeDeadline: newDeadline()}
+	return connA, connB
+}
+
+// Read reads the data of one call to Write by the other end into the byte slice passed. If the other end was
+// closed, io.EOF is returned once all data it wrote was read.
+func (conn *memoryConn) Read(b []byte) (n int, err error) {
+	if isClosed(conn.closed) {
+		return 0, io.ErrClosedPipe
+	}
+	select {
+	case data := <-conn.in:
+		return conn.read(b, data)
+	case <-conn.closed:
+		return 0, io.ErrClosedPipe
+	case <-conn.remoteClosed:
+		// Data written before the other end was closed may still be read.
+		select {
+		case data := <-conn.in:
+			return conn.read(b, data)
+		default:
+			return 0, io.EOF
+		}
+	case <-conn.readDeadline.wait():
+		return 0, &timeoutError{op: "read"}
+	}
+}
+
+// read copies the data passed into the byte slice passed, provided it fits.
+func (conn *memoryConn) read(b, data []byte) (n int, err error) {
+	if len(data) > len(b) {
+		return 0, fmt.Errorf("message of %v bytes exceeds the maximum of %v bytes", len(data), len(b))
+	}
+	return copy(b, data), nil
+}
+
+// Write passes a copy of the data passed to the other end of the connection. It blocks if the other end has
+// not yet read memoryBufferSize earlier writes.
+func (conn *memoryConn) Write(b []byte) (n int, err error) {
+	if isClosed(conn.closed) || isClosed(conn.remoteClosed) {
+		return 0, io.ErrClosedPipe
+	}
+	select {
+	case conn.out <- append([]byte(nil), b...):
+		return len(b), nil
+	case <-conn.closed:
+		return 0, io.ErrClosedPipe
+	case <-conn.remoteClosed:
+		return 0, io.ErrClosedPipe
+	case <-conn.writeDeadline.wait():
+		return 0, &timeoutError{op: "write"}
+	}
+}
+
+// Close closes the connection. Reads of the other end return io.EOF once all data written was read.
+func (conn *memoryConn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closed)
+	})
+	return nil
+}
+
+// LocalAddr ...
+func (conn *memoryConn) LocalAddr() net.Addr {
+	return conn.local
+}
+
+// RemoteAddr ...
+func (conn *memoryConn) RemoteAddr() net.Addr {
+	return conn.remote
+}
+
+// SetDeadline ...
+func (conn *memoryConn) SetDeadline(t time.Time) error {
+	conn.readDeadline.set(t)
+	conn.writeDeadline.set(t)
+	return nil
+}
+
+// SetReadDeadline ...
+func (conn *memoryConn) SetReadDeadline(t time.Time) error {
+	conn.readDeadline.set(t)
+	return nil
+}
+
+// SetWriteDeadline ...
+func (conn *memoryConn) SetWriteDeadline(t time.Time) error {
+	conn.writeDeadline.set(t)
+	return nil
+}
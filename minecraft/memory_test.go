@@ -0,0 +1,983 @@
+package minecraft
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+)
+
+// testTimeout is the maximum duration that a connection sequence in a test may take.
+const testTimeout = time.Second * 10
+
+// listenMemory starts the Listener passed on the memory network, on an address unique to the test.
+func listenMemory(t *testing.T, listener *Listener) string {
+	if listener.ErrorLog == nil {
+		listener.ErrorLog = NopLogger()
+	}
+	address := t.Name()
+	if err := listener.Listen("memory", address); err != nil {
+		t.Fatalf("error listening on memory network: %v", err)
+	}
+	return address
+}
+
+// acceptAndStart accepts one connection from the Listener passed and starts the game for it with the
+// GameData passed. The connection, or an error, is sent to the channels returned.
+func acceptAndStart(listener *Listener, data GameData) (<-chan *Conn, <-chan error) {
+	conns, errs := make(chan *Conn, 1), make(chan error, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+		conn := c.(*Conn)
+		if err := conn.StartGame(data); err != nil {
+			errs <- err
+			return
+		}
+		conns <- conn
+	}()
+	return conns, errs
+}
+
+// readText reads packets from the Conn passed until a Text packet is read, and returns its message.
+func readText(t *testing.T, conn *Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(testTimeout))
+	for {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading text packet: %v", err)
+		}
+		if text, ok := pk.(*packet.Text); ok {
+			return text.Message
+		}
+	}
+}
+
+func TestMemoryLoginSequence(t *testing.T) {
+	var mu sync.Mutex
+	read, written := map[uint32]bool{}, map[uint32]bool{}
+
+	listener := &Listener{
+		AuthenticationDisabled: true,
+		PacketFunc: func(header packet.Header, _ []byte, src, _ net.Addr) {
+			mu.Lock()
+			defer mu.Unlock()
+			if src.Network() == "memory" && src.String() == t.Name() {
+				written[header.PacketID] = true
+				return
+			}
+			read[header.PacketID] = true
+		},
+	}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{WorldName: "memory world", EntityRuntimeID: 1})
+	client, err := Dialer{
+		ErrorLog:     NopLogger(),
+		IdentityData: login.IdentityData{Identity: "c4b2e4fd-8ba5-4e1b-8e6a-4ae9bd0e4ac2", DisplayName: "Alice"},
+	}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+
+	var server *Conn
+	select {
+	case server = <-conns:
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	case <-time.After(testTimeout):
+		t.Fatalf("connection was not accepted within %v", testTimeout)
+	}
+	defer server.Close()
+
+	if name := server.IdentityData().DisplayName; name != "Alice" {
+		t.Errorf("server got display name %q, expected %q", name, "Alice")
+	}
+	if err := server.ClientData().Validate(); err != nil {
+		t.Errorf("server got invalid default client data: %v", err)
+	}
+	if name := client.GameData().WorldName; name != "memory world" {
+		t.Errorf("client got world name %q, expected %q", name, "memory world")
+	}
+
+	mu.Lock()
+	if !written[packet.IDServerToClientHandshake] || !read[packet.IDClientToServerHandshake] {
+		t.Errorf("encryption handshake was not performed: written %v, read %v", written, read)
+	}
+	mu.Unlock()
+
+	// Packets sent after the login sequence are encrypted, so they are only read correctly if both ends
+	// use the same key.
+	if err := client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "client to server"}); err != nil {
+		t.Fatalf("error writing packet: %v", err)
+	}
+	if msg := readText(t, server); msg != "client to server" {
+		t.Errorf("server read message %q, expected %q", msg, "client to server")
+	}
+	if err := server.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "server to client"}); err != nil {
+		t.Fatalf("error writing packet: %v", err)
+	}
+	if msg := readText(t, client); msg != "server to client" {
+		t.Errorf("client read message %q, expected %q", msg, "server to client")
+	}
+}
+
+// testResourcePack compiles a resource pack of about 2 MB for use in tests.
+// testResourcePack compiles a resource pack for use in tests.
+func testResourcePack(t *testing.T) *resource.Pack {
+	return testResourcePackWithUUID(t, "304017b5-f1a4-4241-a702-d47c06d146cb")
+}
+
+// testResourcePackWithUUID compiles a resource pack for use in tests, with the UUID passed in its header.
+func testResourcePackWithUUID(t *testing.T, uuid string) *resource.Pack {
+	dir, err := ioutil.TempDir("", "gophertunnel")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest := `{"format_version":1,"header":{"name":"test","description":"","uuid":"` + uuid + `","version":[1,0,0],"min_engine_version":[1,16,0]},"modules":[{"uuid":"643b347d-0449-432a-9f63-4603f098cb4f","description":"","type":"resources","version":[1,0,0]}]}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "manifest.json"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("error writing manifest: %v", err)
+	}
+	// The content is random, so that it barely compresses and the pack is sent in multiple chunks.
+	content := make([]byte, 1024*1024*2)
+	rand.New(rand.NewSource(1)).Read(content)
+	if err := ioutil.WriteFile(filepath.Join(dir, "content.bin"), content, 0644); err != nil {
+		t.Fatalf("error writing pack content: %v", err)
+	}
+	pack, err := resource.Compile(dir)
+	if err != nil {
+		t.Fatalf("error compiling resource pack: %v", err)
+	}
+	return pack
+}
+
+func TestMemoryResourcePacks(t *testing.T) {
+	pack := testResourcePack(t)
+	listener := &Listener{AuthenticationDisabled: true, ResourcePacks: []*resource.Pack{pack}}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	_, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	default:
+	}
+
+	packs := client.ResourcePacks()
+	if len(packs) != 1 {
+		t.Fatalf("client got %v resource packs, expected 1", len(packs))
+	}
+	if packs[0].UUID() != pack.UUID() || packs[0].Checksum() != pack.Checksum() {
+		t.Errorf("client got pack %v (checksum %x), expected %v (checksum %x)", packs[0].UUID(), packs[0].Checksum(), pack.UUID(), pack.Checksum())
+	}
+	downloaded := make([]byte, packs[0].Len())
+	if _, err := packs[0].ReadAt(downloaded, 0); err != nil {
+		t.Fatalf("error reading downloaded pack: %v", err)
+	}
+	original := make([]byte, pack.Len())
+	if _, err := pack.ReadAt(original, 0); err != nil {
+		t.Fatalf("error reading pack: %v", err)
+	}
+	if !bytes.Equal(downloaded, original) {
+		t.Errorf("content of downloaded pack differs from the pack sent")
+	}
+}
+
+func TestMemoryResourcePacksFor(t *testing.T) {
+	packs := map[string]*resource.Pack{
+		"Alice": testResourcePackWithUUID(t, "304017b5-f1a4-4241-a702-d47c06d146cb"),
+		"Bob":   testResourcePackWithUUID(t, "8a1f3c52-7d2e-4b6a-9c0d-5e4f3a2b1c0d"),
+	}
+	listener := &Listener{
+		AuthenticationDisabled: true,
+		ResourcePacksFor: func(conn *Conn) []*resource.Pack {
+			return []*resource.Pack{packs[conn.IdentityData().DisplayName]}
+		},
+	}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	for i, identity := range []login.IdentityData{
+		{Identity: "c4b2e4fd-8ba5-4e1b-8e6a-4ae9bd0e4ac2", DisplayName: "Alice"},
+		{Identity: "5d3a8f2e-4a1c-4c6b-9e1f-2b7d3c4e5f60", DisplayName: "Bob"},
+	} {
+		conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: uint64(i + 1)})
+		client, err := Dialer{ErrorLog: NopLogger(), IdentityData: identity}.DialTimeout("memory", address, testTimeout)
+		if err != nil {
+			t.Fatalf("error dialing listener: %v", err)
+		}
+		defer client.Close()
+		if err := client.DoSpawn(); err != nil {
+			t.Fatalf("error spawning client: %v", err)
+		}
+		server := acceptConn(t, conns, errs)
+
+		expected := packs[identity.DisplayName]
+		for _, conn := range []*Conn{client, server} {
+			got := conn.ResourcePacks()
+			if len(got) != 1 || got[0].UUID() != expected.UUID() || got[0].Checksum() != expected.Checksum() {
+				t.Errorf("%v got %v resource packs, expected only pack %v", identity.DisplayName, len(got), expected.UUID())
+			}
+		}
+	}
+}
+
+// countingPackCache is a PackCache that counts the resource packs found in it.
+type countingPackCache struct {
+	*DiskPackCache
+	mu   sync.Mutex
+	hits int
+}
+
+// Get ...
+func (cache *countingPackCache) Get(uuid, version string) (*resource.Pack, bool) {
+	pack, ok := cache.DiskPackCache.Get(uuid, version)
+	if ok {
+		cache.mu.Lock()
+		cache.hits++
+		cache.mu.Unlock()
+	}
+	return pack, ok
+}
+
+func TestMemoryPackCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gophertunnel")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := &countingPackCache{DiskPackCache: NewDiskPackCache(dir)}
+
+	pack := testResourcePack(t)
+	listener := &Listener{AuthenticationDisabled: true, ResourcePacks: []*resource.Pack{pack}}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	// The chunk requests written by the client are counted, so that we can check that cached packs are not
+	// downloaded at all.
+	var chunkRequests int32
+	dialer := Dialer{ErrorLog: NopLogger(), PackCache: cache, PacketFunc: func(header packet.Header, _ []byte, src, _ net.Addr) {
+		if header.PacketID == packet.IDResourcePackChunkRequest && src.String() != address {
+			atomic.AddInt32(&chunkRequests, 1)
+		}
+	}}
+
+	// The first connection downloads the pack and stores it in the cache, the second finds it there.
+	for i := 0; i < 2; i++ {
+		atomic.StoreInt32(&chunkRequests, 0)
+		_, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+		client, err := dialer.DialTimeout("memory", address, testTimeout)
+		if err != nil {
+			t.Fatalf("error dialing listener: %v", err)
+		}
+		if err := client.DoSpawn(); err != nil {
+			t.Fatalf("error spawning client: %v", err)
+		}
+		select {
+		case err := <-errs:
+			t.Fatalf("error accepting connection: %v", err)
+		default:
+		}
+		packs := client.ResourcePacks()
+		_ = client.Close()
+		if len(packs) != 1 || packs[0].Checksum() != pack.Checksum() {
+			t.Fatalf("client got %v resource packs, expected pack with checksum %x", len(packs), pack.Checksum())
+		}
+		if cache.hits != i {
+			t.Fatalf("pack cache had %v hits after %v connections, expected %v", cache.hits, i+1, i)
+		}
+		if n := atomic.LoadInt32(&chunkRequests); (i == 0) != (n != 0) {
+			t.Fatalf("client sent %v chunk requests on connection %v", n, i+1)
+		}
+	}
+
+	// An archive modified after it was stored must not be served from the cache.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.mcpack"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 archive in the pack cache, found %v", len(files))
+	}
+	f, err := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("error opening cached archive: %v", err)
+	}
+	_, _ = f.Write([]byte{0})
+	_ = f.Close()
+	if _, ok := cache.Get(pack.UUID(), pack.Version()); ok {
+		t.Errorf("pack cache returned a modified archive")
+	}
+}
+
+func TestDownloadingPackChecksum(t *testing.T) {
+	data := []byte("resource pack data")
+	checksum := sha256.Sum256(data)
+	pack := &downloadingPack{}
+	if err := pack.init(&packet.ResourcePackDataInfo{DataChunkSize: 4, Size: uint64(len(data)), Hash: checksum[:]}, 1, "", 1024); err != nil {
+		t.Fatalf("error initialising download: %v", err)
+	}
+	for i := uint32(0); i < pack.chunkCount; i++ {
+		offset := int(i) * 4
+		_ = pack.write(packChunk{index: i, data: data[offset : offset+pack.expectedLen(i)]})
+	}
+	if verified, err := pack.verify(); !verified || err != nil {
+		t.Fatalf("pack with matching checksum was not verified: %v", err)
+	}
+	// A corrupted chunk makes the checksum mismatch, so that the pack is rejected.
+	_ = pack.write(packChunk{index: 1, data: []byte("XXXX")})
+	if _, err := pack.verify(); err == nil {
+		t.Fatalf("pack with mismatching checksum was verified")
+	}
+}
+
+func TestResourcePackChunkDataSlowWriter(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := newConn(c1, nil, NopLogger())
+	defer conn.Close()
+
+	// With a concurrency of 2, the channel that chunks are passed through holds 4 chunks. More chunks than
+	// that arrive before the download, which writes the chunks slowly, receives any of them.
+	const chunkCount = 12
+	pack := &downloadingPack{}
+	if err := pack.init(&packet.ResourcePackDataInfo{DataChunkSize: 4, Size: chunkCount * 4}, 2, "", 1024); err != nil {
+		t.Fatalf("error initialising download: %v", err)
+	}
+	conn.packQueue = &resourcePackQueue{awaitingPacks: map[string]*downloadingPack{"pack": pack}}
+	go func() {
+		for i := uint32(0); i < chunkCount; i++ {
+			if err := conn.handleResourcePackChunkData(&packet.ResourcePackChunkData{UUID: "pack_1.0.0", ChunkIndex: i, Data: make([]byte, 4)}); err != nil {
+				t.Errorf("error handling chunk %v: %v", i, err)
+			}
+		}
+	}()
+
+	received := make(map[uint32]bool)
+	for len(received) < chunkCount {
+		select {
+		case chunk := <-pack.newFrag:
+			received[chunk.index] = true
+			time.Sleep(time.Millisecond * 10)
+		case <-time.After(time.Second):
+			t.Fatalf("only %v of %v chunks received: chunks were dropped", len(received), chunkCount)
+		}
+	}
+	close(pack.done)
+}
+
+func TestMemoryResourcePackTooLarge(t *testing.T) {
+	pack := testResourcePack(t)
+	listener := &Listener{AuthenticationDisabled: true, ResourcePacks: []*resource.Pack{pack}}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	_, _ = acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	dialer := Dialer{ErrorLog: NopLogger(), MaxResourcePackSize: uint64(pack.Len() - 1)}
+	client, err := dialer.DialTimeout("memory", address, testTimeout)
+	if err == nil {
+		_ = client.Close()
+		t.Fatalf("dial succeeded with a resource pack exceeding the maximum size")
+	}
+}
+
+func TestMemoryDialWithoutListener(t *testing.T) {
+	_, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", t.Name(), testTimeout)
+	var dialErr *DialError
+	if !errors.As(err, &dialErr) {
+		t.Fatalf("expected *DialError, got %v", err)
+	}
+	if dialErr.Stage != DialStageTransport {
+		t.Errorf("dial failed during stage %v, expected %v", dialErr.Stage, DialStageTransport)
+	}
+}
+
+func TestMemoryDisconnect(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	select {
+	case server := <-conns:
+		if err := listener.Disconnect(server, "bye"); err != nil {
+			t.Fatalf("error disconnecting client: %v", err)
+		}
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+
+	_ = client.SetReadDeadline(time.Now().Add(testTimeout))
+	for {
+		pk, err := client.ReadPacket()
+		if err != nil {
+			t.Fatalf("connection closed without receiving a disconnect packet: %v", err)
+		}
+		if disconnect, ok := pk.(*packet.Disconnect); ok {
+			if disconnect.Message != "bye" {
+				t.Errorf("client got disconnect message %q, expected %q", disconnect.Message, "bye")
+			}
+			break
+		}
+	}
+	if _, err := client.ReadPacket(); err == nil {
+		t.Errorf("connection was not closed after the disconnect packet")
+	}
+
+	if err := listener.Close(); err != nil {
+		t.Fatalf("error closing listener: %v", err)
+	}
+	if _, err := listener.Accept(); err != ErrListenerClosed {
+		t.Errorf("accept after close returned %v, expected %v", err, ErrListenerClosed)
+	}
+	// The address of a closed listener may be listened on again.
+	listener = &Listener{ErrorLog: NopLogger()}
+	if err := listener.Listen("memory", address); err != nil {
+		t.Fatalf("error listening on address of closed listener: %v", err)
+	}
+	_ = listener.Close()
+}
+
+func TestMemoryDialStage(t *testing.T) {
+	tests := []struct {
+		stage DialStage
+		// cancelOn is the packet written by the client after which the dial is cancelled. blockOn is the
+		// packet that the server stops at once read, so that the client cannot reach the next stage.
+		cancelOn, blockOn uint32
+	}{
+		{stage: DialStageHandshake, cancelOn: packet.IDLogin, blockOn: packet.IDLogin},
+		{stage: DialStagePacks, cancelOn: packet.IDClientCacheStatus, blockOn: packet.IDClientCacheStatus},
+		// The listener never accepts the connection, so the client never gets a StartGame packet.
+		{stage: DialStageStartGame, cancelOn: packet.IDResourcePackClientResponse},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.stage.String(), func(t *testing.T) {
+			release := make(chan struct{})
+			listener := &Listener{
+				AuthenticationDisabled: true,
+				PacketFunc: func(header packet.Header, _ []byte, _, _ net.Addr) {
+					if header.PacketID == test.blockOn {
+						<-release
+					}
+				},
+			}
+			address := listenMemory(t, listener)
+			defer listener.Close()
+			defer close(release)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := Dialer{
+				ErrorLog: NopLogger(),
+				PacketFunc: func(header packet.Header, payload []byte, _, _ net.Addr) {
+					if header.PacketID != test.cancelOn {
+						return
+					}
+					if header.PacketID == packet.IDResourcePackClientResponse && payload[0] != packet.PackResponseCompleted {
+						return
+					}
+					cancel()
+				},
+			}.DialContext(ctx, "memory", address)
+
+			var dialErr *DialError
+			if !errors.As(err, &dialErr) {
+				t.Fatalf("expected a *DialError, got %v", err)
+			}
+			if dialErr.Stage != test.stage {
+				t.Errorf("dial failed during %v, expected %v", dialErr.Stage, test.stage)
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected error wrapping %v, got %v", context.Canceled, err)
+			}
+		})
+	}
+}
+
+func TestMemoryListenerConns(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	clients := make([]*Conn, 0, 2)
+	for i, identity := range []login.IdentityData{
+		{Identity: "c4b2e4fd-8ba5-4e1b-8e6a-4ae9bd0e4ac2", DisplayName: "Alice", XUID: "1000"},
+		{Identity: "5d3a8f2e-4a1c-4c6b-9e1f-2b7d3c4e5f60", DisplayName: "Bob", XUID: "2000"},
+	} {
+		conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: uint64(i + 1)})
+		client, err := Dialer{ErrorLog: NopLogger(), IdentityData: identity}.DialTimeout("memory", address, testTimeout)
+		if err != nil {
+			t.Fatalf("error dialing listener: %v", err)
+		}
+		defer client.Close()
+		if err := client.DoSpawn(); err != nil {
+			t.Fatalf("error spawning client: %v", err)
+		}
+		acceptConn(t, conns, errs)
+		clients = append(clients, client)
+	}
+
+	if n := len(listener.Conns()); n != 2 {
+		t.Fatalf("expected 2 connections accepted by the listener, got %v", n)
+	}
+	if conn, ok := listener.ConnByXUID("1000"); !ok || conn.IdentityData().DisplayName != "Alice" {
+		t.Errorf("connection with XUID 1000 was not found")
+	}
+	if _, ok := listener.ConnByXUID(""); ok {
+		t.Errorf("connection found by empty XUID")
+	}
+	if conn, ok := listener.ConnByName("bob"); !ok || conn.IdentityData().XUID != "2000" {
+		t.Errorf("connection with name bob was not found")
+	}
+
+	listener.Broadcast(&packet.Text{TextType: packet.TextTypeRaw, Message: "hello"})
+	for _, client := range clients {
+		if msg := readText(t, client); msg != "hello" {
+			t.Errorf("client %v read %q, expected %q", client.IdentityData().DisplayName, msg, "hello")
+		}
+	}
+
+	// A connection is removed from the listener once it is closed.
+	_ = clients[1].Close()
+	waitFor(t, "closed connection was not removed from the listener", func() bool {
+		return len(listener.Conns()) == 1 && atomic.LoadInt32(listener.playerCount) == 1
+	})
+	if _, ok := listener.ConnByName("Bob"); ok {
+		t.Errorf("closed connection was found by name")
+	}
+	if _, ok := listener.ConnByXUID("2000"); ok {
+		t.Errorf("closed connection was found by XUID")
+	}
+
+	listener.DisconnectAll("bye")
+	_ = clients[0].SetReadDeadline(time.Now().Add(testTimeout))
+	for {
+		pk, err := clients[0].ReadPacket()
+		if err != nil {
+			t.Fatalf("connection closed without receiving a disconnect packet: %v", err)
+		}
+		if disconnect, ok := pk.(*packet.Disconnect); ok {
+			if disconnect.Message != "bye" {
+				t.Errorf("client got disconnect message %q, expected %q", disconnect.Message, "bye")
+			}
+			break
+		}
+	}
+	waitFor(t, "disconnected connection was not removed from the listener", func() bool {
+		return len(listener.Conns()) == 0 && atomic.LoadInt32(listener.playerCount) == 0
+	})
+	if _, ok := listener.ConnByXUID("1000"); ok {
+		t.Errorf("disconnected connection was found by XUID")
+	}
+}
+
+func TestMemoryAcceptContext(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+	if _, err := listener.AcceptContext(ctx); err != context.Canceled {
+		t.Errorf("accept with cancelled context returned %v, expected %v", err, context.Canceled)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, err := listener.AcceptContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("accept with expired context returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	// The listener still accepts connections after an AcceptContext call was cancelled.
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	acceptConn(t, conns, errs)
+}
+
+func TestMemoryShutdown(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	acceptConn(t, conns, errs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := listener.Shutdown(ctx, "server closed"); err != nil {
+		t.Fatalf("error shutting down listener: %v", err)
+	}
+	// Shutdown only returns once all connections are closed.
+	if n := len(listener.Conns()); n != 0 || atomic.LoadInt32(listener.playerCount) != 0 {
+		t.Errorf("listener still had %v connections after shutting down", n)
+	}
+	if _, err := listener.Accept(); err != ErrListenerClosed {
+		t.Errorf("accept after shutdown returned %v, expected %v", err, ErrListenerClosed)
+	}
+
+	_ = client.SetReadDeadline(time.Now().Add(testTimeout))
+	for {
+		pk, err := client.ReadPacket()
+		if err != nil {
+			t.Fatalf("connection closed without receiving a disconnect packet: %v", err)
+		}
+		if disconnect, ok := pk.(*packet.Disconnect); ok {
+			if disconnect.Message != "server closed" {
+				t.Errorf("client got disconnect message %q, expected %q", disconnect.Message, "server closed")
+			}
+			break
+		}
+	}
+}
+
+func TestMemoryShutdownDeadline(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	server := acceptConn(t, conns, errs)
+	server.closeTimeout = time.Millisecond * 500
+
+	// The client stops reading, so that the server cannot write the disconnect and takes until its close
+	// timeout to close the connection.
+	for i := 0; i < memoryBufferSize*4; i++ {
+		_ = server.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "flood"})
+		_ = server.Flush()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err := listener.Shutdown(ctx, "server closed"); err != context.DeadlineExceeded {
+		t.Fatalf("shutdown returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+	if _, err := listener.Accept(); err != ErrListenerClosed {
+		t.Errorf("accept after shutdown returned %v, expected %v", err, ErrListenerClosed)
+	}
+	// The connection is still closed once its close timeout passes.
+	waitFor(t, "connection was not removed after its close timeout", func() bool {
+		return len(listener.Conns()) == 0 && atomic.LoadInt32(listener.playerCount) == 0
+	})
+}
+
+// errorLogger is a Logger that records the errors passed to it under the 'err' key. Loggers returned by
+// With record to the same errorLogger.
+type errorLogger struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Debug ...
+func (l *errorLogger) Debug(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Info ...
+func (l *errorLogger) Info(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Warn ...
+func (l *errorLogger) Warn(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// Error ...
+func (l *errorLogger) Error(_ string, keyvals ...interface{}) { l.record(keyvals) }
+
+// With ...
+func (l *errorLogger) With(...interface{}) Logger { return l }
+
+// record records the error under the 'err' key of the keyvals passed, if any.
+func (l *errorLogger) record(keyvals []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if err, ok := keyvals[i+1].(error); ok && keyvals[i] == "err" {
+			l.errs = append(l.errs, err)
+		}
+	}
+}
+
+func TestMemoryDecodeLimits(t *testing.T) {
+	log := &errorLogger{}
+	listener := &Listener{
+		AuthenticationDisabled: true,
+		ErrorLog:               log,
+		DecodeLimits:           protocol.DecodeLimits{MaxElements: 10},
+	}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	var server *Conn
+	select {
+	case server = <-conns:
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+	defer server.Close()
+
+	_ = client.WritePacket(&packet.UpdateSoftEnum{Options: make([]string, 11)})
+	_ = client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "done"})
+	if msg := readText(t, server); msg != "done" {
+		t.Fatalf("server read message %q, expected %q", msg, "done")
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	for _, err := range log.errs {
+		var budgetErr protocol.ElementBudgetError
+		if errors.As(err, &budgetErr) {
+			if budgetErr.MaxElements != 10 {
+				t.Errorf("got maximum of %v elements in error, expected %v", budgetErr.MaxElements, 10)
+			}
+			return
+		}
+	}
+	t.Fatalf("no protocol.ElementBudgetError was logged, got %v", log.errs)
+}
+
+func TestCompressionLevel(t *testing.T) {
+	listener := &Listener{ErrorLog: NopLogger(), CompressionPolicy: CompressionPolicy{Level: flate.BestCompression + 1}}
+	if err := listener.Listen("memory", t.Name()); err == nil {
+		_ = listener.Close()
+		t.Fatalf("expected error listening with an invalid compression level")
+	}
+
+	listener = &Listener{AuthenticationDisabled: true, CompressionPolicy: CompressionPolicy{Level: flate.HuffmanOnly}}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+	// The invalid level is a configuration error: No stage of the connection sequence is reached.
+	var dialErr *DialError
+	if _, err := (Dialer{ErrorLog: NopLogger(), CompressionLevel: flate.HuffmanOnly - 1}).DialTimeout("memory", address, testTimeout); err == nil || errors.As(err, &dialErr) {
+		t.Fatalf("expected an error other than a *DialError dialing with an invalid compression level, got %v", err)
+	}
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger(), CompressionLevel: flate.BestSpeed}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	var server *Conn
+	select {
+	case server = <-conns:
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+	defer server.Close()
+
+	// The message is large enough to be compressed at the levels of both ends.
+	message := strings.Repeat("compressed ", 100)
+	_ = client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: message})
+	if msg := readText(t, server); msg != message {
+		t.Errorf("server read message of %v bytes, expected %v bytes", len(msg), len(message))
+	}
+	_ = server.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: message})
+	if msg := readText(t, client); msg != message {
+		t.Errorf("client read message of %v bytes, expected %v bytes", len(msg), len(message))
+	}
+}
+
+func TestMemoryStatsRejected(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true, MaximumPlayers: 1}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger()}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	select {
+	case server := <-conns:
+		defer server.Close()
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+
+	before := listener.Stats().PacketsOutByID[packet.IDPlayStatus]
+	if _, err := (Dialer{ErrorLog: NopLogger()}).DialTimeout("memory", address, testTimeout); err == nil {
+		t.Fatalf("dial succeeded while the server was full")
+	}
+	// The stats of the rejected connection are added once it is closed, which may happen after the dial
+	// fails.
+	deadline := time.Now().Add(testTimeout)
+	for listener.Stats().PacketsOutByID[packet.IDPlayStatus] != before+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("PlayStatus sent to rejected connection was not counted in the stats of the listener")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestMemoryClientDataSkinResourcePatch(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	custom := base64.StdEncoding.EncodeToString([]byte(`{"geometry":{"default":"geometry.humanoid.customSlim"}}`))
+	tests := []struct {
+		patch, expected string
+	}{
+		// Custom client data without a skin resource patch is sent with the default patch, as the Listener
+		// refuses client data of which the patch is not valid JSON.
+		{patch: "", expected: defaultSkinResourcePatch},
+		// A patch set in the custom client data is sent as is.
+		{patch: custom, expected: custom},
+	}
+	for i, test := range tests {
+		data := defaultClientData("0.0.0.0:0", protocol.CurrentVersion)
+		data.SkinResourcePatch = test.patch
+		conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: uint64(i + 1)})
+		client, err := Dialer{ErrorLog: NopLogger(), ClientData: data}.DialTimeout("memory", address, testTimeout)
+		if err != nil {
+			t.Fatalf("error dialing listener: %v", err)
+		}
+		defer client.Close()
+		if err := client.DoSpawn(); err != nil {
+			t.Fatalf("error spawning client: %v", err)
+		}
+		server := acceptConn(t, conns, errs)
+		defer server.Close()
+		if patch := server.ClientData().SkinResourcePatch; patch != test.expected {
+			t.Errorf("server got skin resource patch %q, expected %q", patch, test.expected)
+		}
+	}
+}
+
+func TestMemoryAdmitFunc(t *testing.T) {
+	log := &errorLogger{}
+	listener := &Listener{
+		AuthenticationDisabled: true,
+		ErrorLog:               log,
+		AdmitFunc: func(identity login.IdentityData, _ login.ClientData, _ net.Addr) (bool, string) {
+			return identity.DisplayName != "Mallory", "banned"
+		},
+	}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	_, err := Dialer{
+		ErrorLog:     NopLogger(),
+		IdentityData: login.IdentityData{Identity: "c4b2e4fd-8ba5-4e1b-8e6a-4ae9bd0e4ac2", DisplayName: "Mallory"},
+	}.DialTimeout("memory", address, testTimeout)
+	if err == nil || !strings.Contains(err.Error(), "banned") {
+		t.Fatalf("expected dial to fail with the reason of the admit func, got %v", err)
+	}
+	// The connection that was not admitted is torn down, so that it no longer counts as a player.
+	deadline := time.Now().Add(testTimeout)
+	for atomic.LoadInt32(listener.playerCount) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection that was not admitted was not removed from the listener")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.errs) == 0 || !strings.Contains(log.errs[0].Error(), "not admitted") {
+		t.Errorf("expected the connection not being admitted to be logged, got %v", log.errs)
+	}
+}
+
+func TestMemoryCloseLoggingIn(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	// The connection dialed never sends a Login packet, so it remains logging in until it is closed.
+	raw, err := memoryNetwork{}.Dial(context.Background(), address)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer raw.Close()
+	deadline := time.Now().Add(testTimeout)
+	for atomic.LoadInt32(listener.playerCount) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection was not added to the listener")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if err := listener.Close(); err != nil {
+		t.Fatalf("error closing listener: %v", err)
+	}
+	_ = raw.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := raw.Read(make([]byte, 64)); err != io.EOF {
+		t.Fatalf("expected io.EOF reading from connection logging in after closing the listener, got %v", err)
+	}
+}
@@ -252,7 +252,7 @@ func (d *Decoder) unmarshalTag(val reflect.Value, tagType byte, tagName string)
 		val.Set(value)
 
 	case tagInt32Array:
-		length, err := d.Encoding.Int32(d.r)
+		length, err := d.length("Int32Array")
 		if err != nil {
 			return err
 		}
@@ -278,7 +278,7 @@ func (d *Decoder) unmarshalTag(val reflect.Value, tagType byte, tagName string)
 		val.Set(value)
 
 	case tagInt64Array:
-		length, err := d.Encoding.Int32(d.r)
+		length, err := d.length("Int64Array")
 		if err != nil {
 			return err
 		}
@@ -312,7 +312,7 @@ func (d *Decoder) unmarshalTag(val reflect.Value, tagType byte, tagName string)
 		if !tagExists(listType) {
 			return UnknownTagError{Off: d.r.off, TagType: listType, Op: "Slice"}
 		}
-		length, err := d.Encoding.Int32(d.r)
+		length, err := d.length("List")
 		if err != nil {
 			return err
 		}
@@ -436,6 +436,23 @@ func (d *Decoder) populateFields(val reflect.Value, m map[string]reflect.Value)
 	}
 }
 
+// length reads the length of a TAG_List or of an array tag for the op passed. Every element of these tags
+// takes up at least one byte, so an error is returned if the length is negative or, if the amount of bytes
+// left in the input stream is known, if it exceeds that amount.
+func (d *Decoder) length(op string) (int32, error) {
+	length, err := d.Encoding.Int32(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if length < 0 {
+		return 0, InvalidArraySizeError{Off: d.r.off, Op: op, NBTLength: int(length)}
+	}
+	if r, ok := d.r.Reader.(interface{ Len() int }); ok && int(length) > r.Len() {
+		return 0, BufferOverrunError{Op: op}
+	}
+	return length, nil
+}
+
 // tag reads a tag from the decoder, and its name if the tag type is not a TAG_End.
 func (d *Decoder) tag() (tagType byte, tagName string, err error) {
 	if d.depth >= maximumNestingDepth {
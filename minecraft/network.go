@@ -0,0 +1,152 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"github.com/sandertv/go-raknet"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync/atomic"
+)
+
+// network is a network that Minecraft connections may be established over, such as RakNet or TCP. The
+// connections it produces must preserve the boundaries of the batches written to them: Every call to Read
+// must return exactly the data of one call to Write on the other end.
+type network interface {
+	// Dial dials a connection to the address passed. If the context passed is cancelled before the
+	// connection is established, the dial is aborted.
+	Dial(ctx context.Context, address string) (net.Conn, error)
+	// Listen announces on the local address passed and returns a net.Listener to accept connections with.
+	Listen(address string) (net.Listener, error)
+}
+
+// networks holds the networks that Dial and Listen support by their name. Networks that are not found in
+// the map are dialed and listened on using the net package directly, which only works for networks that
+// preserve message boundaries themselves, such as "unixpacket".
+var networks = map[string]network{
+	"raknet": raknetNetwork{},
+	"tcp":    streamNetwork{network: "tcp"},
+	"tcp4":   streamNetwork{network: "tcp4"},
+	"tcp6":   streamNetwork{network: "tcp6"},
+	"unix":   streamNetwork{network: "unix"},
+	"ws":     wsNetwork{},
+	"memory": memoryNetwork{},
+}
+
+// raknetNetwork implements the RakNet network, which is the network used by Minecraft clients and servers.
+type raknetNetwork struct{}
+
+// Dial ...
+func (raknetNetwork) Dial(ctx context.Context, address string) (net.Conn, error) {
+	// The raknet library does not support contexts, so we dial on a separate goroutine and stop waiting for
+	// it if the context is cancelled.
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	c := make(chan result, 1)
+	go func() {
+		netConn, err := raknet.Dialer{ErrorLog: log.New(ioutil.Discard, "", 0)}.Dial(address)
+		if err != nil {
+			c <- result{err: err}
+			return
+		}
+		c <- result{conn: netConn}
+	}()
+	select {
+	case res := <-c:
+		return res.conn, res.err
+	case <-ctx.Done():
+		go func() {
+			// Make sure the connection is closed if it is still established after the context was cancelled.
+			if res := <-c; res.conn != nil {
+				_ = res.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Listen ...
+func (raknetNetwork) Listen(address string) (net.Listener, error) {
+	l, err := raknet.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	l.ErrorLog = log.New(ioutil.Discard, "", 0)
+	return l, nil
+}
+
+// streamNetwork implements a network on top of a stream oriented network of the net package, such as TCP.
+// Streams have no message boundaries, so every batch sent over a connection of the network is prefixed with
+// its length.
+type streamNetwork struct {
+	network string
+}
+
+// Dial ...
+func (n streamNetwork) Dial(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, n.network, address)
+	if err != nil {
+		return nil, err
+	}
+	return newFramedConn(conn), nil
+}
+
+// Listen ...
+func (n streamNetwork) Listen(address string) (net.Listener, error) {
+	l, err := net.Listen(n.network, address)
+	if err != nil {
+		return nil, err
+	}
+	return framedListener{Listener: l}, nil
+}
+
+// framedListener wraps around a net.Listener of a stream oriented network, so that the connections it
+// accepts frame the data written to them.
+type framedListener struct {
+	net.Listener
+}
+
+// Accept ...
+func (l framedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newFramedConn(conn), nil
+}
+
+// errClosed is returned by the connections of the stream and WebSocket networks when they are read from or
+// written to after we closed them. The net package has no such error that is available in every Go version
+// supported, so the connections keep track of being closed themselves.
+var errClosed = errors.New("use of closed connection")
+
+// errConnectionClosed checks if the error passed was returned because the connection read from was closed,
+// either by us or by the other end of the connection.
+func errConnectionClosed(err error) bool {
+	return raknet.ErrConnectionClosed(err) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, errClosed)
+}
+
+// closeTracker records if a connection wrapping a net.Conn was closed by us, so that the errors returned by
+// the net.Conn after that may be replaced with errClosed.
+type closeTracker struct {
+	closed int32
+}
+
+// markClosed marks the connection as closed by us.
+func (tracker *closeTracker) markClosed() {
+	atomic.StoreInt32(&tracker.closed, 1)
+}
+
+// closedErr returns errClosed if the error passed is not nil and the connection was closed by us, as the
+// error was then caused by closing it. Otherwise, the error passed is returned.
+func (tracker *closeTracker) closedErr(err error) error {
+	if err != nil && atomic.LoadInt32(&tracker.closed) == 1 {
+		return errClosed
+	}
+	return err
+}
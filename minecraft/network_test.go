@@ -0,0 +1,60 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestErrConnectionClosed(t *testing.T) {
+	for _, network := range []string{"tcp", "ws"} {
+		n := networks[network]
+		l, err := n.Listen("127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("error listening on %v: %v", network, err)
+		}
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, _ := l.Accept()
+			accepted <- conn
+		}()
+		conn, err := dialTransport(context.Background(), network, l.Addr().String())
+		if err != nil {
+			t.Fatalf("error dialing %v: %v", network, err)
+		}
+		server := <-accepted
+
+		// A connection closed by us must be considered closed when reading from it, just like a connection
+		// closed by the other end.
+		_ = conn.Close()
+		if _, err := conn.Read(make([]byte, 64)); !errConnectionClosed(err) || !errors.Is(err, errClosed) {
+			t.Errorf("%v: error reading from connection closed locally not considered closed: %v", network, err)
+		}
+		if _, err := conn.Write([]byte("data")); !errors.Is(err, errClosed) {
+			t.Errorf("%v: error writing to connection closed locally not considered closed: %v", network, err)
+		}
+		if _, err := server.Read(make([]byte, 64)); !errConnectionClosed(err) {
+			t.Errorf("%v: error reading from connection closed remotely not considered closed: %v", network, err)
+		}
+		_ = server.Close()
+		_ = l.Close()
+	}
+}
+
+func TestFramedConnErrors(t *testing.T) {
+	c1, c2 := net.Pipe()
+	conn := newFramedConn(c1)
+	defer conn.Close()
+
+	// The other end announces a frame of 10 bytes, but closes the connection after writing 2 of them. The
+	// error returned wraps that of the underlying connection.
+	go func() {
+		_, _ = c2.Write([]byte{10, 1, 2})
+		_ = c2.Close()
+	}()
+	if _, err := conn.Read(make([]byte, 64)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected error wrapping %v reading a partial frame, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,132 @@
+package minecraft
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PackCache is a cache of resource packs downloaded from servers by a Dialer. Resource packs found in the
+// cache are not downloaded again: They are left out of the packs that the client asks the server to send, and
+// they are added to the resource packs of the Conn directly.
+// The methods of a PackCache may be called from multiple goroutines simultaneously.
+type PackCache interface {
+	// Get looks up a resource pack with the UUID and version passed, as sent by the server in the
+	// ResourcePacksInfo packet. If found, the pack is returned and the bool returned is true. Like the
+	// Minecraft client, the cache assumes that a pack with the same UUID and version has the same content.
+	Get(uuid, version string) (pack *resource.Pack, ok bool)
+	// Put stores a resource pack that was downloaded from a server. The pack is only stored if the SHA256
+	// checksum of the data downloaded matched the checksum that the server sent in the ResourcePackDataInfo
+	// packet, so that checksum is equal to pack.Checksum().
+	Put(pack *resource.Pack) error
+}
+
+// DiskPackCache is a PackCache that stores resource packs as archives in a directory. Each archive is keyed by
+// the UUID and version of the pack, and its name holds the checksum of the pack, so that archives modified
+// after they were stored are not used.
+type DiskPackCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDiskPackCache returns a new DiskPackCache that stores resource packs in the directory passed. The
+// directory is created once the first resource pack is stored, if it does not yet exist.
+func NewDiskPackCache(dir string) *DiskPackCache {
+	return &DiskPackCache{dir: dir}
+}
+
+// versionRegex is a regular expression that matches valid resource pack versions.
+var versionRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// Get looks up a resource pack with the UUID and version passed in the directory of the cache. An archive of
+// which the content does not match the checksum in its name is removed.
+func (cache *DiskPackCache) Get(packUUID, version string) (*resource.Pack, bool) {
+	if !validPackKey(packUUID, version) {
+		return nil, false
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	files, _ := filepath.Glob(cache.path(packUUID, version, "*"))
+	for _, file := range files {
+		checksum, err := hex.DecodeString(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), packUUID+"_"+version+"_"), ".mcpack"))
+		if err != nil {
+			continue
+		}
+		pack, err := resource.Compile(file)
+		if err != nil {
+			_ = os.Remove(file)
+			continue
+		}
+		if sum := pack.Checksum(); !bytes.Equal(sum[:], checksum) || pack.UUID() != packUUID || pack.Version() != version {
+			// The archive was modified after it was stored, so we can no longer use it.
+			_ = os.Remove(file)
+			continue
+		}
+		return pack, true
+	}
+	return nil, false
+}
+
+// Put stores the resource pack passed in the directory of the cache. Archives previously stored for the same
+// UUID and version are replaced.
+func (cache *DiskPackCache) Put(pack *resource.Pack) error {
+	if !validPackKey(pack.UUID(), pack.Version()) {
+		return fmt.Errorf("invalid resource pack UUID %v or version %v", pack.UUID(), pack.Version())
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if err := os.MkdirAll(cache.dir, 0755); err != nil {
+		return fmt.Errorf("error creating pack cache directory: %v", err)
+	}
+	// We first write the archive to a temporary file and rename it after, so that no partially written
+	// archives are ever found by Get.
+	f, err := ioutil.TempFile(cache.dir, "pack-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating pack cache file: %v", err)
+	}
+	if _, err := io.Copy(f, io.NewSectionReader(pack, 0, int64(pack.Len()))); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("error writing pack cache file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("error closing pack cache file: %v", err)
+	}
+	old, _ := filepath.Glob(cache.path(pack.UUID(), pack.Version(), "*"))
+	for _, file := range old {
+		_ = os.Remove(file)
+	}
+	checksum := pack.Checksum()
+	if err := os.Rename(f.Name(), cache.path(pack.UUID(), pack.Version(), hex.EncodeToString(checksum[:]))); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("error renaming pack cache file: %v", err)
+	}
+	return nil
+}
+
+// path returns the path of the archive of the resource pack with the UUID, version and hex encoded checksum
+// passed.
+func (cache *DiskPackCache) path(packUUID, version, checksum string) string {
+	return filepath.Join(cache.dir, packUUID+"_"+version+"_"+checksum+".mcpack")
+}
+
+// validPackKey checks if the UUID and version passed are valid, so that they may safely be used in a file
+// name.
+func validPackKey(packUUID, version string) bool {
+	if _, err := uuid.Parse(packUUID); err != nil || strings.ContainsAny(packUUID, `/\.`) {
+		return false
+	}
+	return versionRegex.MatchString(version)
+}
@@ -0,0 +1,63 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Protocol represents a version of the Minecraft protocol that a Conn may communicate with. A Protocol provides
+// the packets of its version and converts them from and to the packets of the current version, as implemented
+// in the packet package. Users of a Conn therefore always read and write the same, canonical packets,
+// regardless of the version that the other end of the connection runs.
+// Protocols other than DefaultProtocol may be set in Listener.AcceptedProtocols to accept clients running
+// other versions.
+type Protocol interface {
+	// ID returns the protocol number of the version, such as 407.
+	ID() int32
+	// Ver returns the Minecraft version that the protocol belongs to, such as '1.16.0'.
+	Ver() string
+	// Packets returns a packet.Pool holding a packet for every packet ID of the protocol. Packets read from a
+	// Conn using the protocol are decoded into the packets of this pool before they are converted. Packets is
+	// called once for every connection, so the pool returned must not be shared.
+	Packets() packet.Pool
+	// ConvertToLatest converts a packet of the protocol, decoded into a packet obtained from its packet.Pool,
+	// to zero or more packets of the current version. It is called for every packet read from a Conn using the
+	// protocol.
+	ConvertToLatest(pk packet.Packet, conn *Conn) []packet.Packet
+	// ConvertFromLatest converts a packet of the current version to zero or more packets of the protocol. It is
+	// called for every packet written to a Conn using the protocol. ConvertFromLatest may be called from
+	// multiple goroutines simultaneously.
+	ConvertFromLatest(pk packet.Packet, conn *Conn) []packet.Packet
+}
+
+// DefaultProtocol is the Protocol of the current version, protocol.CurrentProtocol. Its packets are those of
+// the packet package, which are never converted. It is used by a Conn unless another Protocol is negotiated.
+var DefaultProtocol Protocol = currentProtocol{}
+
+// currentProtocol implements the Protocol of the current version.
+type currentProtocol struct{}
+
+// ID ...
+func (currentProtocol) ID() int32 {
+	return protocol.CurrentProtocol
+}
+
+// Ver ...
+func (currentProtocol) Ver() string {
+	return protocol.CurrentVersion
+}
+
+// Packets ...
+func (currentProtocol) Packets() packet.Pool {
+	return packet.NewPool()
+}
+
+// ConvertToLatest ...
+func (currentProtocol) ConvertToLatest(pk packet.Packet, _ *Conn) []packet.Packet {
+	return []packet.Packet{pk}
+}
+
+// ConvertFromLatest ...
+func (currentProtocol) ConvertFromLatest(pk packet.Packet, _ *Conn) []packet.Packet {
+	return []packet.Packet{pk}
+}
@@ -23,9 +23,9 @@ type Attribute struct {
 }
 
 // Attributes reads an Attribute slice from bytes.Buffer src and stores it in the pointer passed.
-func Attributes(src *bytes.Buffer, attributes *[]Attribute) error {
+func Attributes(src *Reader, attributes *[]Attribute) error {
 	var count uint32
-	if err := Varuint32(src, &count); err != nil {
+	if err := Count(src, &count, "attribute"); err != nil {
 		return wrap(err)
 	}
 	if count > mediumLimit {
@@ -69,9 +69,9 @@ func WriteAttributes(dst *bytes.Buffer, x []Attribute) error {
 
 // InitialAttributes reads an Attribute slice from bytes.Buffer src and stores it in the pointer passed.
 // InitialAttributes is used when reading the attributes of a new entity. (AddEntity packet)
-func InitialAttributes(src *bytes.Buffer, attributes *[]Attribute) error {
+func InitialAttributes(src *Reader, attributes *[]Attribute) error {
 	var count uint32
-	if err := Varuint32(src, &count); err != nil {
+	if err := Count(src, &count, "attribute"); err != nil {
 		return wrap(err)
 	}
 	if count > mediumLimit {
@@ -25,7 +25,7 @@ func WriteBlob(dst *bytes.Buffer, x CacheBlob) error {
 }
 
 // Blob reads a CacheBlob x from Buffer src.
-func Blob(src *bytes.Buffer, x *CacheBlob) error {
+func Blob(src *Reader, x *CacheBlob) error {
 	return chainErr(
 		binary.Read(src, binary.LittleEndian, &x.Hash),
 		ByteSlice(src, &x.Payload),
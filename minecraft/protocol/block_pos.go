@@ -24,7 +24,7 @@ func (pos BlockPos) Z() int32 {
 }
 
 // BlockPosition reads a BlockPos from Buffer src and stores it to the BlockPos pointer passed.
-func BlockPosition(src *bytes.Buffer, x *BlockPos) error {
+func BlockPosition(src *Reader, x *BlockPos) error {
 	if err := chainErr(
 		Varint32(src, &(*x)[0]),
 		Varint32(src, &(*x)[1]),
@@ -46,7 +46,7 @@ func WriteBlockPosition(dst *bytes.Buffer, x BlockPos) error {
 
 // UBlockPosition reads an unsigned BlockPos from Buffer src and stores it to the BlockPos pointer passed. The
 // difference between this and BlockPosition is that the Y coordinate is read as a varuint32.
-func UBlockPosition(src *bytes.Buffer, x *BlockPos) error {
+func UBlockPosition(src *Reader, x *BlockPos) error {
 	var v uint32
 	if err := chainErr(
 		Varint32(src, &(*x)[0]),
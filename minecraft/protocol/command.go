@@ -168,12 +168,12 @@ type CommandOutputMessage struct {
 }
 
 // CommandMessage reads a CommandOutputMessage x from Buffer src.
-func CommandMessage(src *bytes.Buffer, x *CommandOutputMessage) error {
+func CommandMessage(src *Reader, x *CommandOutputMessage) error {
 	var count uint32
 	if err := chainErr(
 		binary.Read(src, binary.LittleEndian, &x.Success),
 		String(src, &x.Message),
-		Varuint32(src, &count),
+		Count(src, &count, "command output parameter"),
 	); err != nil {
 		return err
 	}
@@ -219,7 +219,7 @@ func WriteCommandOriginData(dst *bytes.Buffer, x CommandOrigin) error {
 }
 
 // CommandOriginData reads a CommandOrigin x from Buffer src.
-func CommandOriginData(src *bytes.Buffer, x *CommandOrigin) error {
+func CommandOriginData(src *Reader, x *CommandOrigin) error {
 	if err := chainErr(
 		Varuint32(src, &x.Origin),
 		UUID(src, &x.UUID),
@@ -267,7 +267,7 @@ func WriteCommandData(dst *bytes.Buffer, x Command, enumIndices map[string]int,
 
 // CommandData reads a Command x from Buffer src using the enums and suffixes passed to match indices with
 // the values these slices hold.
-func CommandData(src *bytes.Buffer, x *Command, enums []CommandEnum, suffixes []string) error {
+func CommandData(src *Reader, x *Command, enums []CommandEnum, suffixes []string) error {
 	var (
 		overloadCount, paramCount uint32
 		aliasOffset               int32
@@ -287,12 +287,12 @@ func CommandData(src *bytes.Buffer, x *Command, enums []CommandEnum, suffixes []
 		}
 		x.Aliases = enums[aliasOffset].Options
 	}
-	if err := Varuint32(src, &overloadCount); err != nil {
+	if err := Count(src, &overloadCount, "command overload"); err != nil {
 		return err
 	}
 	x.Overloads = make([]CommandOverload, overloadCount)
 	for i := uint32(0); i < overloadCount; i++ {
-		if err := Varuint32(src, &paramCount); err != nil {
+		if err := Count(src, &paramCount, "command parameter"); err != nil {
 			return err
 		}
 		x.Overloads[i].Parameters = make([]CommandParameter, paramCount)
@@ -326,7 +326,7 @@ func WriteCommandParam(dst *bytes.Buffer, x CommandParameter, enumIndices map[st
 // CommandParam reads a CommandParam x from Buffer src using the enums and suffixes passed to translate
 // offsets to their respective values. CommandParam does not handle soft/dynamic enums. The caller is
 // responsible to do this itself.
-func CommandParam(src *bytes.Buffer, x *CommandParameter, enums []CommandEnum, suffixes []string) error {
+func CommandParam(src *Reader, x *CommandParameter, enums []CommandEnum, suffixes []string) error {
 	if err := chainErr(
 		String(src, &x.Name),
 		binary.Read(src, binary.LittleEndian, &x.Type),
@@ -384,19 +384,19 @@ func WriteEnumConstraint(dst *bytes.Buffer, x CommandEnumConstraint, enumIndices
 }
 
 // EnumConstraint reads a CommandEnumConstraint x from Buffer src using the enums and enum values passed.
-func EnumConstraint(src *bytes.Buffer, x *CommandEnumConstraint, enums []CommandEnum, enumValues []string) error {
+func EnumConstraint(src *Reader, x *CommandEnumConstraint, enums []CommandEnum, enumValues []string) error {
 	var enumValueIndex, enumIndex, constraintCount uint32
 	if err := chainErr(
 		binary.Read(src, binary.LittleEndian, &enumValueIndex),
 		binary.Read(src, binary.LittleEndian, &enumIndex),
-		Varuint32(src, &constraintCount),
+		Count(src, &constraintCount, "command enum constraint"),
 	); err != nil {
 		return wrap(err)
 	}
-	if len(enumValues) <= int(enumValueIndex) {
+	if uint32(len(enumValues)) <= enumValueIndex {
 		return fmt.Errorf("invalid enum value index %v, expected one lower than or equal to %v", enumValueIndex, len(enumValues))
 	}
-	if len(enums) <= int(enumIndex) {
+	if uint32(len(enums)) <= enumIndex {
 		return fmt.Errorf("invalid enum index %v, expected one lower than or equal to %v", enumIndex, len(enums))
 	}
 	x.EnumOption = enumValues[enumValueIndex]
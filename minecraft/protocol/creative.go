@@ -22,7 +22,7 @@ func WriteCreativeEntry(dst *bytes.Buffer, x CreativeItem) error {
 }
 
 // CreativeEntry reads a CreativeItem x from the Buffer src.
-func CreativeEntry(src *bytes.Buffer, x *CreativeItem) error {
+func CreativeEntry(src *Reader, x *CreativeItem) error {
 	return chainErr(
 		Varuint32(src, &x.CreativeItemNetworkID),
 		Item(src, &x.Item),
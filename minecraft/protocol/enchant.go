@@ -38,7 +38,7 @@ func WriteEnchantOption(dst *bytes.Buffer, x EnchantmentOption) error {
 }
 
 // EnchantOption reads an EnchantmentOption x from Buffer src.
-func EnchantOption(src *bytes.Buffer, x *EnchantmentOption) error {
+func EnchantOption(src *Reader, x *EnchantmentOption) error {
 	return chainErr(
 		Varuint32(src, &x.Cost),
 		ItemEnchants(src, &x.Enchantments),
@@ -111,13 +111,13 @@ func WriteItemEnchants(dst *bytes.Buffer, x ItemEnchantments) error {
 }
 
 // ItemEnchants reads an ItemEnchantments x from Buffer src.
-func ItemEnchants(src *bytes.Buffer, x *ItemEnchantments) error {
+func ItemEnchants(src *Reader, x *ItemEnchantments) error {
 	if err := binary.Read(src, binary.LittleEndian, &x.Slot); err != nil {
 		return err
 	}
 	for i := 0; i < 3; i++ {
 		var l uint32
-		if err := Varuint32(src, &l); err != nil {
+		if err := Count(src, &l, "enchantment"); err != nil {
 			return err
 		}
 		x.Enchantments[i] = make([]EnchantmentInstance, l)
@@ -145,7 +145,7 @@ func WriteEnchant(dst *bytes.Buffer, x EnchantmentInstance) error {
 }
 
 // Enchant reads an EnchantmentInstance x from Buffer src.
-func Enchant(src *bytes.Buffer, x *EnchantmentInstance) error {
+func Enchant(src *Reader, x *EnchantmentInstance) error {
 	return chainErr(
 		binary.Read(src, binary.LittleEndian, &x.Type),
 		binary.Read(src, binary.LittleEndian, &x.Level),
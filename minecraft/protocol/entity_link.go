@@ -35,7 +35,7 @@ type EntityLink struct {
 }
 
 // EntityLinkAction reads a single entity link (action) from buffer src.
-func EntityLinkAction(src *bytes.Buffer, x *EntityLink) error {
+func EntityLinkAction(src *Reader, x *EntityLink) error {
 	return chainErr(
 		Varint64(src, &x.RiddenEntityUniqueID),
 		Varint64(src, &x.RiderEntityUniqueID),
@@ -46,9 +46,9 @@ func EntityLinkAction(src *bytes.Buffer, x *EntityLink) error {
 }
 
 // EntityLinks reads a list of entity links from buffer src that are currently active.
-func EntityLinks(src *bytes.Buffer, x *[]EntityLink) error {
+func EntityLinks(src *Reader, x *[]EntityLink) error {
 	var count uint32
-	if err := Varuint32(src, &count); err != nil {
+	if err := Count(src, &count, "entity link"); err != nil {
 		return wrap(err)
 	}
 	if count > lowerLimit {
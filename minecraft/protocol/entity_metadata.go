@@ -22,10 +22,10 @@ const (
 
 // EntityMetadata reads an entity metadata list from buffer src into map x. The types in the map will be one
 // of byte, int16, int32, float32, string, map[string]interface{}, BlockPos, int64 or mgl32.Vec3.
-func EntityMetadata(src *bytes.Buffer, x *map[uint32]interface{}) error {
+func EntityMetadata(src *Reader, x *map[uint32]interface{}) error {
 	var count uint32
 	var err error
-	if err = Varuint32(src, &count); err != nil {
+	if err = Count(src, &count, "entity metadata"); err != nil {
 		return wrap(err)
 	}
 	if count > mediumLimit {
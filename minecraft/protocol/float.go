@@ -8,7 +8,7 @@ import (
 )
 
 // Float32 reads a float32 from Buffer src, setting the result to the pointer to a float32 passed.
-func Float32(src *bytes.Buffer, x *float32) error {
+func Float32(src *Reader, x *float32) error {
 	var bits uint32
 	if err := binary.Read(src, binary.LittleEndian, &bits); err != nil {
 		return wrap(err)
@@ -27,7 +27,7 @@ func WriteFloat32(dst *bytes.Buffer, x float32) error {
 
 // Vec3 reads an mgl32.Vec3 (float32 vector) from Buffer src, setting the result to the pointer to an
 // mgl32.Vec3 passed.
-func Vec3(src *bytes.Buffer, x *mgl32.Vec3) error {
+func Vec3(src *Reader, x *mgl32.Vec3) error {
 	return chainErr(
 		Float32(src, &(*x)[0]),
 		Float32(src, &(*x)[1]),
@@ -46,7 +46,7 @@ func WriteVec3(dst *bytes.Buffer, x mgl32.Vec3) error {
 
 // Vec2 reads an mgl32.Vec2 (float32 vector) from Buffer src, setting the result to the pointer to an
 // mgl32.Vec2 passed.
-func Vec2(src *bytes.Buffer, x *mgl32.Vec2) error {
+func Vec2(src *Reader, x *mgl32.Vec2) error {
 	return chainErr(
 		Float32(src, &(*x)[0]),
 		Float32(src, &(*x)[1]),
@@ -8,10 +8,10 @@ import (
 
 // GameRules reads a map of game rules from Buffer src. It sets one of the types 'bool', 'float32' or 'uint32'
 // to the map x, with the key being the name of the game rule.
-func GameRules(src *bytes.Buffer, x *map[string]interface{}) error {
+func GameRules(src *Reader, x *map[string]interface{}) error {
 	var count uint32
 	// The amount of game rules is in a varuint32 before the game rules.
-	if err := Varuint32(src, &count); err != nil {
+	if err := Count(src, &count, "game rules"); err != nil {
 		return wrap(err)
 	}
 	if count > mediumLimit {
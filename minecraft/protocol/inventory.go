@@ -47,7 +47,7 @@ type InventoryAction struct {
 }
 
 // InvAction reads an inventory action from buffer src.
-func InvAction(src *bytes.Buffer, action *InventoryAction, netIDs bool) error {
+func InvAction(src *Reader, action *InventoryAction, netIDs bool) error {
 	if err := Varuint32(src, &action.SourceType); err != nil {
 		return wrap(err)
 	}
@@ -109,7 +109,7 @@ type InventoryTransactionData interface {
 	Marshal(buf *bytes.Buffer)
 	// Unmarshal decodes a serialised inventory transaction data object in buf into the
 	// InventoryTransactionData instance.
-	Unmarshal(buf *bytes.Buffer) error
+	Unmarshal(buf *Reader) error
 }
 
 // NormalTransactionData represents an inventory transaction data object for normal transactions, such as
@@ -218,7 +218,7 @@ func (data *UseItemTransactionData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (data *UseItemTransactionData) Unmarshal(buf *bytes.Buffer) error {
+func (data *UseItemTransactionData) Unmarshal(buf *Reader) error {
 	return chainErr(
 		Varuint32(buf, &data.ActionType),
 		UBlockPosition(buf, &data.BlockPosition),
@@ -242,7 +242,7 @@ func (data *UseItemOnEntityTransactionData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (data *UseItemOnEntityTransactionData) Unmarshal(buf *bytes.Buffer) error {
+func (data *UseItemOnEntityTransactionData) Unmarshal(buf *Reader) error {
 	return chainErr(
 		Varuint64(buf, &data.TargetEntityRuntimeID),
 		Varuint32(buf, &data.ActionType),
@@ -262,7 +262,7 @@ func (data *ReleaseItemTransactionData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (data *ReleaseItemTransactionData) Unmarshal(buf *bytes.Buffer) error {
+func (data *ReleaseItemTransactionData) Unmarshal(buf *Reader) error {
 	return chainErr(
 		Varuint32(buf, &data.ActionType),
 		Varint32(buf, &data.HotBarSlot),
@@ -277,7 +277,7 @@ func (*NormalTransactionData) Marshal(*bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (*NormalTransactionData) Unmarshal(*bytes.Buffer) error {
+func (*NormalTransactionData) Unmarshal(*Reader) error {
 	return nil
 }
 
@@ -287,7 +287,7 @@ func (*MismatchTransactionData) Marshal(*bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (*MismatchTransactionData) Unmarshal(*bytes.Buffer) error {
+func (*MismatchTransactionData) Unmarshal(*Reader) error {
 	return nil
 }
 
@@ -312,12 +312,12 @@ func WriteSetItemSlot(dst *bytes.Buffer, x LegacySetItemSlot) error {
 }
 
 // SetItemSlot reads a LegacySetItemSlot x from Buffer src.
-func SetItemSlot(src *bytes.Buffer, x *LegacySetItemSlot) error {
+func SetItemSlot(src *Reader, x *LegacySetItemSlot) error {
 	if err := binary.Read(src, binary.LittleEndian, &x.ContainerID); err != nil {
 		return wrap(err)
 	}
 	var length uint32
-	if err := Varuint32(src, &length); err != nil {
+	if err := Count(src, &length, "legacy set item slot"); err != nil {
 		return err
 	}
 	x.Slots = make([]byte, length)
@@ -10,32 +10,31 @@ import (
 // ItemInstance represents a unique instance of an item stack. These instances carry a specific network ID
 // that is persistent for the stack.
 type ItemInstance struct {
-	// StackNetworkID is the network ID of the item stack. If the stack is empty, 0 is always written for this
-	// field. If not, the field should be set to 1 if the server authoritative inventories are disabled in the
-	// StartGame packet, or to a unique stack ID if it is enabled.
+	// StackNetworkID is the network ID of the item stack. If the stack is empty, this field must be 0. If not,
+	// the field should be set to 1 if the server authoritative inventories are disabled in the StartGame
+	// packet, or to a unique stack ID if it is enabled.
 	StackNetworkID int32
 	// Stack is the actual item stack of the item instance.
 	Stack ItemStack
 }
 
 // ItemInst reads an ItemInstance x from Buffer src.
-func ItemInst(src *bytes.Buffer, x *ItemInstance) error {
+func ItemInst(src *Reader, x *ItemInstance) error {
 	if err := chainErr(
 		Varint32(src, &x.StackNetworkID),
 		Item(src, &x.Stack),
 	); err != nil {
 		return err
 	}
-	if (x.Stack.Count == 0 || x.Stack.NetworkID == 0) && x.StackNetworkID != 0 {
-		return fmt.Errorf("stack %#v is empty but network ID %v is non-zero", x.Stack, x.StackNetworkID)
-	}
-	return nil
+	return x.validate()
 }
 
-// WriteItemInst writes an ItemInstance x to Buffer dst.
+// WriteItemInst writes an ItemInstance x to Buffer dst. The stack network ID of x must be 0 if its stack is
+// empty, as the item instance could otherwise not be decoded again: If it is not, an InvalidValueError is
+// returned and nothing is written.
 func WriteItemInst(dst *bytes.Buffer, x ItemInstance) error {
-	if (x.Stack.Count == 0 || x.Stack.NetworkID == 0) && x.StackNetworkID != 0 {
-		panic(fmt.Sprintf("stack %#v is empty but network ID %v is non-zero", x.Stack, x.StackNetworkID))
+	if err := x.validate(); err != nil {
+		return err
 	}
 	return chainErr(
 		WriteVarint32(dst, x.StackNetworkID),
@@ -43,6 +42,14 @@ func WriteItemInst(dst *bytes.Buffer, x ItemInstance) error {
 	)
 }
 
+// validate checks if the stack network ID of the item instance is 0 if its stack is empty.
+func (x ItemInstance) validate() error {
+	if (x.Stack.Count == 0 || x.Stack.NetworkID == 0) && x.StackNetworkID != 0 {
+		return InvalidValueError{Type: "item instance", Reason: fmt.Sprintf("stack %#v is empty but network ID %v is non-zero", x.Stack, x.StackNetworkID)}
+	}
+	return nil
+}
+
 // ItemStack represents an item instance/stack over network. It has a network ID and a metadata value that
 // define its type.
 type ItemStack struct {
@@ -70,7 +77,7 @@ type ItemType struct {
 }
 
 // Item reads an item stack from buffer src and stores it into item stack x.
-func Item(src *bytes.Buffer, x *ItemStack) error {
+func Item(src *Reader, x *ItemStack) error {
 	x.NBTData = make(map[string]interface{})
 	if err := Varint32(src, &x.NetworkID); err != nil {
 		return wrap(err)
@@ -126,6 +133,9 @@ func Item(src *bytes.Buffer, x *ItemStack) error {
 	if count > higherLimit {
 		return LimitHitError{Limit: higherLimit, Type: "item can be placed on"}
 	}
+	if err := CheckCount(src, uint32(count), "item can be placed on"); err != nil {
+		return wrap(err)
+	}
 	x.CanBePlacedOn = make([]string, count)
 	for i := int32(0); i < count; i++ {
 		if err := String(src, &x.CanBePlacedOn[i]); err != nil {
@@ -141,6 +151,9 @@ func Item(src *bytes.Buffer, x *ItemStack) error {
 	if count > higherLimit {
 		return LimitHitError{Limit: higherLimit, Type: "item can break"}
 	}
+	if err := CheckCount(src, uint32(count), "item can break"); err != nil {
+		return wrap(err)
+	}
 	x.CanBreak = make([]string, count)
 	for i := int32(0); i < count; i++ {
 		if err := String(src, &x.CanBreak[i]); err != nil {
@@ -166,7 +179,9 @@ func WriteItem(dst *bytes.Buffer, x ItemStack) error {
 		// The item was air, so there's no more data to follow. Return immediately.
 		return nil
 	}
-	if err := WriteVarint32(dst, int32(x.MetadataValue<<8)|int32(x.Count)); err != nil {
+	// The metadata value is shifted as an int32, so that its upper bits are not lost. Only the lowest 8 bits
+	// of the count are encoded.
+	if err := WriteVarint32(dst, int32(x.MetadataValue)<<8|int32(x.Count&0xff)); err != nil {
 		return wrap(err)
 	}
 	if len(x.NBTData) != 0 {
@@ -216,9 +231,9 @@ func WriteItem(dst *bytes.Buffer, x ItemStack) error {
 }
 
 // RecipeIngredient reads an ItemStack x as a recipe ingredient from Buffer src.
-func RecipeIngredient(src *bytes.Buffer, x *ItemStack) error {
+func RecipeIngredient(src *Reader, x *ItemStack) error {
 	if err := Varint32(src, &x.NetworkID); err != nil {
-		return nil
+		return err
 	}
 	if x.NetworkID == 0 {
 		return nil
@@ -67,12 +67,12 @@ func WriteStackRequest(dst *bytes.Buffer, x ItemStackRequest) error {
 }
 
 // StackRequest reads an ItemStackRequest x from Buffer src.
-func StackRequest(src *bytes.Buffer, x *ItemStackRequest) error {
+func StackRequest(src *Reader, x *ItemStackRequest) error {
 	var count uint32
 	if err := Varint32(src, &x.RequestID); err != nil {
 		return err
 	}
-	if err := Varuint32(src, &count); err != nil {
+	if err := Count(src, &count, "ItemStackRequest"); err != nil {
 		return err
 	}
 	if count > mediumLimit {
@@ -184,7 +184,7 @@ func WriteStackResponse(dst *bytes.Buffer, x ItemStackResponse) error {
 }
 
 // StackResponse reads an ItemStackResponse x from Buffer src.
-func StackResponse(src *bytes.Buffer, x *ItemStackResponse) error {
+func StackResponse(src *Reader, x *ItemStackResponse) error {
 	if err := chainErr(
 		binary.Read(src, binary.LittleEndian, &x.Success),
 		Varint32(src, &x.RequestID),
@@ -195,7 +195,7 @@ func StackResponse(src *bytes.Buffer, x *ItemStackResponse) error {
 		return nil
 	}
 	var l uint32
-	if err := Varuint32(src, &l); err != nil {
+	if err := Count(src, &l, "ItemStackResponse ContainerInfo"); err != nil {
 		return err
 	}
 	x.ContainerInfo = make([]StackResponseContainerInfo, l)
@@ -222,12 +222,12 @@ func WriteStackContainerInfo(dst *bytes.Buffer, x StackResponseContainerInfo) er
 }
 
 // StackContainerInfo reads a StackResponseContainerInfo x from Buffer src.
-func StackContainerInfo(src *bytes.Buffer, x *StackResponseContainerInfo) error {
+func StackContainerInfo(src *Reader, x *StackResponseContainerInfo) error {
 	if err := binary.Read(src, binary.LittleEndian, &x.ContainerID); err != nil {
 		return err
 	}
 	var l uint32
-	if err := Varuint32(src, &l); err != nil {
+	if err := Count(src, &l, "StackResponseContainerInfo SlotInfo"); err != nil {
 		return err
 	}
 	x.SlotInfo = make([]StackResponseSlotInfo, l)
@@ -253,7 +253,7 @@ func WriteStackSlotInfo(dst *bytes.Buffer, x StackResponseSlotInfo) error {
 }
 
 // StackSlotInfo reads a StackResponseSlotInfo x from Buffer src.
-func StackSlotInfo(src *bytes.Buffer, x *StackResponseSlotInfo) error {
+func StackSlotInfo(src *Reader, x *StackResponseSlotInfo) error {
 	if err := chainErr(
 		binary.Read(src, binary.LittleEndian, &x.Slot),
 		binary.Read(src, binary.LittleEndian, &x.HotbarSlot),
@@ -276,7 +276,7 @@ type StackRequestAction interface {
 	Marshal(buf *bytes.Buffer)
 	// Unmarshal decodes a serialised stack request action object in buf into the InventoryTransactionData
 	// instance.
-	Unmarshal(buf *bytes.Buffer) error
+	Unmarshal(buf *Reader) error
 }
 
 const (
@@ -314,7 +314,7 @@ func (a *transferStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *transferStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *transferStackRequestAction) Unmarshal(buf *Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &a.Count),
 		StackReqSlotInfo(buf, &a.Source),
@@ -350,7 +350,7 @@ func (a *SwapStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *SwapStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *SwapStackRequestAction) Unmarshal(buf *Reader) error {
 	return chainErr(
 		StackReqSlotInfo(buf, &a.Source),
 		StackReqSlotInfo(buf, &a.Destination),
@@ -379,7 +379,7 @@ func (a *DropStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *DropStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *DropStackRequestAction) Unmarshal(buf *Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &a.Count),
 		StackReqSlotInfo(buf, &a.Source),
@@ -404,7 +404,7 @@ func (a *DestroyStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *DestroyStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *DestroyStackRequestAction) Unmarshal(buf *Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &a.Count),
 		StackReqSlotInfo(buf, &a.Source),
@@ -435,7 +435,7 @@ func (a *CreateStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *CreateStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *CreateStackRequestAction) Unmarshal(buf *Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &a.ResultsSlot)
 }
 
@@ -446,7 +446,7 @@ type LabTableCombineStackRequestAction struct{}
 func (a *LabTableCombineStackRequestAction) Marshal(*bytes.Buffer) {}
 
 // Unmarshal ...
-func (a *LabTableCombineStackRequestAction) Unmarshal(*bytes.Buffer) error { return nil }
+func (a *LabTableCombineStackRequestAction) Unmarshal(*Reader) error { return nil }
 
 // BeaconPaymentStackRequestAction is sent by the client when it submits an item to enable effects from a
 // beacon. These items will have been moved into the beacon item slot in advance.
@@ -462,7 +462,7 @@ func (a *BeaconPaymentStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *BeaconPaymentStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *BeaconPaymentStackRequestAction) Unmarshal(buf *Reader) error {
 	return chainErr(
 		Varint32(buf, &a.PrimaryEffect),
 		Varint32(buf, &a.SecondaryEffect),
@@ -486,7 +486,7 @@ func (a *CraftRecipeStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *CraftRecipeStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *CraftRecipeStackRequestAction) Unmarshal(buf *Reader) error {
 	return Varuint32(buf, &a.RecipeNetworkID)
 }
 
@@ -510,7 +510,7 @@ func (a *CraftCreativeStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *CraftCreativeStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *CraftCreativeStackRequestAction) Unmarshal(buf *Reader) error {
 	return Varuint32(buf, &a.CreativeItemNetworkID)
 }
 
@@ -522,7 +522,7 @@ type CraftNonImplementedStackRequestAction struct{}
 func (*CraftNonImplementedStackRequestAction) Marshal(*bytes.Buffer) {}
 
 // Unmarshal ...
-func (*CraftNonImplementedStackRequestAction) Unmarshal(*bytes.Buffer) error { return nil }
+func (*CraftNonImplementedStackRequestAction) Unmarshal(*Reader) error { return nil }
 
 // CraftResultsDeprecatedStackRequestAction is an additional, deprecated packet sent by the client after
 // crafting. It holds the final results and the amount of times the recipe was crafted. It shouldn't be used.
@@ -543,9 +543,9 @@ func (a *CraftResultsDeprecatedStackRequestAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (a *CraftResultsDeprecatedStackRequestAction) Unmarshal(buf *bytes.Buffer) error {
+func (a *CraftResultsDeprecatedStackRequestAction) Unmarshal(buf *Reader) error {
 	var l uint32
-	if err := Varuint32(buf, &l); err != nil {
+	if err := Count(buf, &l, "CraftResultsDeprecated ResultItems"); err != nil {
 		return err
 	}
 	if l > higherLimit/2 {
@@ -582,7 +582,7 @@ func WriteStackReqSlotInfo(dst *bytes.Buffer, x StackRequestSlotInfo) error {
 }
 
 // StackReqSlotInfo reads a StackRequestSlotInfo x from Buffer src.
-func StackReqSlotInfo(src *bytes.Buffer, x *StackRequestSlotInfo) error {
+func StackReqSlotInfo(src *Reader, x *StackRequestSlotInfo) error {
 	return chainErr(
 		binary.Read(src, binary.LittleEndian, &x.ContainerID),
 		binary.Read(src, binary.LittleEndian, &x.Slot),
@@ -1,6 +1,9 @@
 package protocol
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+)
 
 const lowerLimit = 64
 const mediumLimit = 256
@@ -28,3 +31,116 @@ type NegativeCountError struct {
 func (err NegativeCountError) Error() string {
 	return wrap(fmt.Errorf("invalid negative count prefix for type '%v'", err.Type)).Error()
 }
+
+// InvalidValueError is returned when a value is encoded or decoded that the other end of a connection could
+// not handle, such as an item instance with an empty stack but a non-zero stack network ID, or a skin of which
+// the image data does not match its dimensions.
+type InvalidValueError struct {
+	Type   string
+	Reason string
+}
+
+// Error ...
+func (err InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid %v: %v", err.Type, err.Reason)
+}
+
+// DefaultMaxElements is the maximum amount of elements that the slices and maps of a single packet may hold
+// together if no other maximum is set in DecodeLimits.
+const DefaultMaxElements = 1 << 18
+
+// DecodeLimits holds the limits that apply to decoding a single packet from a Reader. Together with the
+// checks performed by CheckCount for every count read, they limit the amount of memory that a packet may
+// make the decoding side allocate, regardless of the counts found in its data.
+type DecodeLimits struct {
+	// MaxElements is the maximum amount of elements that all count-prefixed slices and maps decoded for a
+	// single packet may hold together. Elements of nested slices and maps count towards the same maximum. If
+	// zero, DefaultMaxElements is used.
+	MaxElements int
+}
+
+// ElementBudgetError is returned by a reading operation if the elements of a count read would exceed the
+// maximum amount of elements that may be decoded for a single packet, as set in DecodeLimits.
+type ElementBudgetError struct {
+	MaxElements int
+	Type        string
+}
+
+// Error ...
+func (err ElementBudgetError) Error() string {
+	return wrap(fmt.Errorf("maximum element count %v of packet exceeded by type '%v'", err.MaxElements, err.Type)).Error()
+}
+
+// DecodePanicError is returned by Decode if decoding panicked. Decoding should never panic, so a
+// DecodePanicError always indicates a bug in the decoding of the packet.
+type DecodePanicError struct {
+	Value interface{}
+}
+
+// Error ...
+func (err DecodePanicError) Error() string {
+	return fmt.Sprintf("panic while decoding: %v", err.Value)
+}
+
+// Reader reads the data of a packet from the bytes.Buffer it embeds. Besides the data, it holds the amount
+// of elements that may still be decoded from it, as set in the DecodeLimits passed to NewReader. All
+// decoding functions of the protocol package read from a Reader, so that every count read is checked
+// against the same limits. A Reader that is not created using NewReader has no maximum amount of elements.
+type Reader struct {
+	*bytes.Buffer
+	max, left int
+}
+
+// NewReader returns a Reader that reads from buf and applies the DecodeLimits passed to every count read.
+func NewReader(buf *bytes.Buffer, limits DecodeLimits) *Reader {
+	if limits.MaxElements <= 0 {
+		limits.MaxElements = DefaultMaxElements
+	}
+	return &Reader{Buffer: buf, max: limits.MaxElements, left: limits.MaxElements}
+}
+
+// ResetBuffer makes the Reader read from buf and restores the amount of elements left that may be decoded
+// to the maximum it was created with, so that the Reader may be reused for the next packet.
+func (r *Reader) ResetBuffer(buf *bytes.Buffer) {
+	r.Buffer, r.left = buf, r.max
+}
+
+// Decode calls f to decode a packet from the Reader passed. The decoding functions of the protocol package
+// return errors for any data that is not valid and never panic. The recover in Decode is only a last resort,
+// so that a bug in the decoding of a packet does not take down the program: If f panics, the panic is
+// recovered and a DecodePanicError is returned.
+func Decode(r *Reader, f func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = DecodePanicError{Value: v}
+		}
+	}()
+	return f()
+}
+
+// Count reads a varuint32 count prefix of a slice or map holding elements of the type passed from src and
+// stores it in x. The count must pass the checks of CheckCount.
+func Count(src *Reader, x *uint32, typ string) error {
+	if err := Varuint32(src, x); err != nil {
+		return wrap(err)
+	}
+	return CheckCount(src, *x, typ)
+}
+
+// CheckCount checks if the count passed of elements of the type passed may be decoded from src. Every
+// element takes up at least one byte, so a LimitHitError is returned if the count is larger than the amount
+// of bytes left in src. The count is also subtracted from the elements left that may be decoded from src,
+// and an ElementBudgetError is returned if not enough are left. CheckCount should be called for every count
+// read before any elements are allocated.
+func CheckCount(src *Reader, count uint32, typ string) error {
+	if uint64(count) > uint64(src.Len()) {
+		return LimitHitError{Limit: src.Len(), Type: typ}
+	}
+	if src.max > 0 {
+		if int(count) > src.left {
+			return ElementBudgetError{MaxElements: src.max, Type: typ}
+		}
+		src.left -= int(count)
+	}
+	return nil
+}
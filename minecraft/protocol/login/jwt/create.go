@@ -37,7 +37,14 @@ func New(header Header, payload interface{}, privateKey *ecdsa.PrivateKey) ([]by
 	if err != nil {
 		return nil, fmt.Errorf("error signing JWT payload: %v", err)
 	}
-	signatureSection := base64.RawURLEncoding.EncodeToString(append(r.Bytes(), s.Bytes()...))
+	// Both 'r' and 's' must be padded to the byte size of the curve, as the signature is split in two halves
+	// when it is verified.
+	size := (privateKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, size*2)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(signature[size-len(rBytes):size], rBytes)
+	copy(signature[size*2-len(sBytes):], sBytes)
+	signatureSection := base64.RawURLEncoding.EncodeToString(signature)
 
 	// Finally we join together all sections and return it as a single string.
 	return []byte(headerSection + "." + payloadSection + "." + signatureSection), nil
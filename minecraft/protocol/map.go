@@ -46,7 +46,7 @@ type MapDecoration struct {
 }
 
 // MapTrackedObj reads a MapTrackedObject from buf into x.
-func MapTrackedObj(buf *bytes.Buffer, x *MapTrackedObject) error {
+func MapTrackedObj(buf *Reader, x *MapTrackedObject) error {
 	if err := binary.Read(buf, binary.LittleEndian, &x.Type); err != nil {
 		return wrap(err)
 	}
@@ -76,7 +76,7 @@ func WriteMapTrackedObj(buf *bytes.Buffer, x MapTrackedObject) error {
 }
 
 // MapDeco reads a MapDecoration from buf into x.
-func MapDeco(buf *bytes.Buffer, x *MapDecoration) error {
+func MapDeco(buf *Reader, x *MapDecoration) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &x.Type),
 		binary.Read(buf, binary.LittleEndian, &x.Rotation),
@@ -100,7 +100,7 @@ func WriteMapDeco(buf *bytes.Buffer, x MapDecoration) error {
 }
 
 // VarRGBA reads an RGBA value from buf into x packed into a varuint32.
-func VarRGBA(buf *bytes.Buffer, x *color.RGBA) error {
+func VarRGBA(buf *Reader, x *color.RGBA) error {
 	var v uint32
 	err := wrap(Varuint32(buf, &v))
 	*x = color.RGBA{
@@ -97,7 +97,7 @@ func (pk *ActorEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ActorEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ActorEvent) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		binary.Read(buf, binary.LittleEndian, &pk.EventType),
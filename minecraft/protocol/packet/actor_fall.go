@@ -33,7 +33,7 @@ func (pk *ActorFall) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ActorFall) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ActorFall) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Float32(buf, &pk.FallDistance),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // ActorPickRequest is sent by the client when it tries to pick an entity, so that it gets a spawn egg which
@@ -28,7 +30,7 @@ func (pk *ActorPickRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ActorPickRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ActorPickRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.EntityUniqueID),
 		binary.Read(buf, binary.LittleEndian, &pk.HotBarSlot),
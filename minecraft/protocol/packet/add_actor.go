@@ -67,7 +67,7 @@ func (pk *AddActor) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddActor) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddActor) Unmarshal(buf *protocol.Reader) error {
 	pk.EntityMetadata = map[uint32]interface{}{}
 	return chainErr(
 		protocol.Varint64(buf, &pk.EntityUniqueID),
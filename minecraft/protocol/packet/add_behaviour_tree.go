@@ -24,6 +24,6 @@ func (pk *AddBehaviourTree) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddBehaviourTree) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddBehaviourTree) Unmarshal(buf *protocol.Reader) error {
 	return protocol.String(buf, &pk.BehaviourTree)
 }
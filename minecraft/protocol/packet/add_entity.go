@@ -23,6 +23,6 @@ func (pk *AddEntity) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddEntity) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddEntity) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varuint64(buf, &pk.EntityNetworkID)
 }
@@ -52,7 +52,7 @@ func (pk *AddItemActor) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddItemActor) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddItemActor) Unmarshal(buf *protocol.Reader) error {
 	pk.EntityMetadata = map[uint32]interface{}{}
 	return chainErr(
 		protocol.Varint64(buf, &pk.EntityUniqueID),
@@ -41,7 +41,7 @@ func (pk *AddPainting) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddPainting) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddPainting) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint64(buf, &pk.EntityUniqueID),
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
@@ -110,7 +110,7 @@ func (pk *AddPlayer) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AddPlayer) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AddPlayer) Unmarshal(buf *protocol.Reader) error {
 	pk.EntityMetadata = map[uint32]interface{}{}
 	return chainErr(
 		protocol.UUID(buf, &pk.UUID),
@@ -88,7 +88,7 @@ func (pk *AdventureSettings) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AdventureSettings) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AdventureSettings) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.Flags),
 		protocol.Varuint32(buf, &pk.CommandPermissionLevel),
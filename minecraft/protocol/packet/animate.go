@@ -41,7 +41,7 @@ func (pk *Animate) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Animate) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Animate) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varint32(buf, &pk.ActionType),
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
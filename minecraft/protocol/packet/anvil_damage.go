@@ -27,7 +27,7 @@ func (pk *AnvilDamage) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ..
-func (pk *AnvilDamage) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AnvilDamage) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.Damage),
 		protocol.UBlockPosition(buf, &pk.AnvilPosition),
@@ -25,6 +25,6 @@ func (pk *AutomationClientConnect) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AutomationClientConnect) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AutomationClientConnect) Unmarshal(buf *protocol.Reader) error {
 	return protocol.String(buf, &pk.ServerURI)
 }
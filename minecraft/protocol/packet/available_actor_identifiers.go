@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"math"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // AvailableActorIdentifiers is sent by the server at the start of the game to let the client know all
@@ -24,7 +26,7 @@ func (pk *AvailableActorIdentifiers) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AvailableActorIdentifiers) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AvailableActorIdentifiers) Unmarshal(buf *protocol.Reader) error {
 	pk.SerialisedEntityIdentifiers = buf.Next(math.MaxInt32)
 	return nil
 }
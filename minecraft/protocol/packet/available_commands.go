@@ -79,11 +79,11 @@ func (pk *AvailableCommands) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
+func (pk *AvailableCommands) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 
 	// First we read all the enum values.
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands enum value"); err != nil {
 		return err
 	}
 	enumValues := make([]string, count)
@@ -94,7 +94,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 	}
 
 	// Then we read all suffixes.
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands suffix"); err != nil {
 		return err
 	}
 	suffixes := make([]string, count)
@@ -105,7 +105,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 	}
 
 	// After that we create all enums, which are composed of pointers to the enum values above.
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands enum"); err != nil {
 		return err
 	}
 	enums := make([]protocol.CommandEnum, count)
@@ -114,7 +114,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 		if err := protocol.String(buf, &enums[i].Type); err != nil {
 			return err
 		}
-		if err := protocol.Varuint32(buf, &optionCount); err != nil {
+		if err := protocol.Count(buf, &optionCount, "AvailableCommands enum option"); err != nil {
 			return err
 		}
 		enums[i].Options = make([]string, optionCount)
@@ -127,7 +127,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 
 	// We read all the commands, which will have their enums and suffixes set automatically. We don't yet set
 	// the dynamic enums as we haven't read them yet.
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands command"); err != nil {
 		return err
 	}
 	pk.Commands = make([]protocol.Command, count)
@@ -138,7 +138,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 	}
 
 	// We first read all soft enums of the packet.
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands soft enum"); err != nil {
 		return err
 	}
 	softEnums := make([]protocol.CommandEnum, count)
@@ -149,7 +149,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 		}
 
 		var optionCount uint32
-		if err := protocol.Varuint32(buf, &optionCount); err != nil {
+		if err := protocol.Count(buf, &optionCount, "AvailableCommands soft enum option"); err != nil {
 			return err
 		}
 		softEnums[i].Options = make([]string, optionCount)
@@ -176,7 +176,7 @@ func (pk *AvailableCommands) Unmarshal(buf *bytes.Buffer) error {
 		}
 	}
 
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "AvailableCommands constraint"); err != nil {
 		return err
 	}
 	pk.Constraints = make([]protocol.CommandEnumConstraint, count)
@@ -205,7 +205,7 @@ func writeEnumOption(buf *bytes.Buffer, option string, valueIndices map[string]i
 
 // enumOption reads an enum option from buf using the enum values passed. The option is written as a
 // byte/uint16/uint32, depending on the size of the enumValues slice.
-func enumOption(buf *bytes.Buffer, option *string, enumValues []string) error {
+func enumOption(buf *protocol.Reader, option *string, enumValues []string) error {
 	l := len(enumValues)
 	var index int
 	switch {
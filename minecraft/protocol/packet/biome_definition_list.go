@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"math"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // BiomeDefinitionList is sent by the server to let the client know all biomes that are available and
@@ -25,7 +27,7 @@ func (pk *BiomeDefinitionList) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BiomeDefinitionList) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BiomeDefinitionList) Unmarshal(buf *protocol.Reader) error {
 	pk.SerialisedBiomeDefinitions = buf.Next(math.MaxInt32)
 	return nil
 }
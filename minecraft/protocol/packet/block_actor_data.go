@@ -30,7 +30,7 @@ func (pk *BlockActorData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BlockActorData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BlockActorData) Unmarshal(buf *protocol.Reader) error {
 	pk.NBTData = make(map[string]interface{})
 	return chainErr(
 		protocol.UBlockPosition(buf, &pk.Position),
@@ -35,7 +35,7 @@ func (pk *BlockEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BlockEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BlockEvent) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.UBlockPosition(buf, &pk.Position),
 		protocol.Varint32(buf, &pk.EventType),
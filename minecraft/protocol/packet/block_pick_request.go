@@ -32,7 +32,7 @@ func (pk *BlockPickRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BlockPickRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BlockPickRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.BlockPosition(buf, &pk.Position),
 		binary.Read(buf, binary.LittleEndian, &pk.AddBlockNBT),
@@ -78,7 +78,7 @@ func (pk *BookEdit) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BookEdit) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BookEdit) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
 		binary.Read(buf, binary.LittleEndian, &pk.InventorySlot),
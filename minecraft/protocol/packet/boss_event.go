@@ -90,7 +90,7 @@ func (pk *BossEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *BossEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *BossEvent) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varint64(buf, &pk.BossEntityUniqueID),
 		protocol.Varuint32(buf, &pk.EventType),
@@ -28,7 +28,7 @@ func (pk *Camera) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Camera) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Camera) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint64(buf, &pk.CameraEntityUniqueID),
 		protocol.Varint64(buf, &pk.TargetPlayerUniqueID),
@@ -45,7 +45,7 @@ func (pk *ChangeDimension) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ChangeDimension) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ChangeDimension) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.Dimension),
 		protocol.Vec3(buf, &pk.Position),
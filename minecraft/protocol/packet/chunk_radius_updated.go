@@ -25,6 +25,6 @@ func (pk *ChunkRadiusUpdated) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ChunkRadiusUpdated) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ChunkRadiusUpdated) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.ChunkRadius)
 }
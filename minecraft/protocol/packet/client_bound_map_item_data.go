@@ -130,7 +130,7 @@ func (pk *ClientBoundMapItemData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ClientBoundMapItemData) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varint64(buf, &pk.MapID),
 		protocol.Varuint32(buf, &pk.UpdateFlags),
@@ -141,7 +141,7 @@ func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
 	}
 	var count uint32
 	if pk.UpdateFlags&MapUpdateFlagInitialisation != 0 {
-		if err := protocol.Varuint32(buf, &count); err != nil {
+		if err := protocol.Count(buf, &count, "ClientBoundMapItemData map included in"); err != nil {
 			return err
 		}
 		pk.MapsIncludedIn = make([]int64, count)
@@ -157,7 +157,7 @@ func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
 		}
 	}
 	if pk.UpdateFlags&MapUpdateFlagDecoration != 0 {
-		if err := protocol.Varuint32(buf, &count); err != nil {
+		if err := protocol.Count(buf, &count, "ClientBoundMapItemData tracked object"); err != nil {
 			return err
 		}
 		pk.TrackedObjects = make([]protocol.MapTrackedObject, count)
@@ -166,7 +166,7 @@ func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
 				return err
 			}
 		}
-		if err := protocol.Varuint32(buf, &count); err != nil {
+		if err := protocol.Count(buf, &count, "ClientBoundMapItemData decoration"); err != nil {
 			return err
 		}
 		pk.Decorations = make([]protocol.MapDecoration, count)
@@ -182,7 +182,7 @@ func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
 			protocol.Varint32(buf, &pk.Height),
 			protocol.Varint32(buf, &pk.XOffset),
 			protocol.Varint32(buf, &pk.YOffset),
-			protocol.Varuint32(buf, &count),
+			protocol.Count(buf, &count, "ClientBoundMapItemData pixel"),
 		); err != nil {
 			return err
 		}
@@ -190,8 +190,8 @@ func (pk *ClientBoundMapItemData) Unmarshal(buf *bytes.Buffer) error {
 		if pk.Width <= 0 || pk.Height <= 0 {
 			return fmt.Errorf("invalid map texture size: width or height is below 1")
 		}
-		if uint32(pk.Width*pk.Height) != count {
-			return fmt.Errorf("invalid map pixel count: %v * %v = %v, not %v", pk.Width, pk.Height, pk.Width*pk.Height, count)
+		if int64(pk.Width)*int64(pk.Height) != int64(count) {
+			return fmt.Errorf("invalid map pixel count: %v * %v = %v, not %v", pk.Width, pk.Height, int64(pk.Width)*int64(pk.Height), count)
 		}
 		pk.Pixels = make([][]color.RGBA, pk.Height)
 		for y := int32(0); y < pk.Height; y++ {
@@ -35,11 +35,11 @@ func (pk *ClientCacheBlobStatus) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ClientCacheBlobStatus) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ClientCacheBlobStatus) Unmarshal(buf *protocol.Reader) error {
 	var hitCount, missCount uint32
 	if err := chainErr(
-		protocol.Varuint32(buf, &missCount),
-		protocol.Varuint32(buf, &hitCount),
+		protocol.Count(buf, &missCount, "ClientCacheBlobStatus miss hash"),
+		protocol.Count(buf, &hitCount, "ClientCacheBlobStatus hit hash"),
 	); err != nil {
 		return err
 	}
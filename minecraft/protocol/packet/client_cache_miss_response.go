@@ -28,9 +28,9 @@ func (pk *ClientCacheMissResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ClientCacheMissResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ClientCacheMissResponse) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "ClientCacheMissResponse blob"); err != nil {
 		return err
 	}
 	pk.Blobs = make([]protocol.CacheBlob, count)
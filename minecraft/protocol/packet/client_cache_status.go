@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // ClientCacheStatus is sent by the client to the server at the start of the game. It is sent to let the
@@ -25,6 +27,6 @@ func (pk *ClientCacheStatus) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ClientCacheStatus) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ClientCacheStatus) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &pk.Enabled)
 }
@@ -0,0 +1,83 @@
+package packet
+
+import (
+	"reflect"
+)
+
+// Clone returns a deep copy of the packet passed. The packet returned shares no memory with the packet
+// passed: All pointers, slices, maps, interface values and strings held by the packet, including those nested
+// in other types, are copied. Clone may be used to hold on to a packet read from a Conn that re-uses its
+// packets, or to pass such a packet to another goroutine.
+// Unexported fields of the packet, which packets registered using Register may have, are copied shallowly.
+func Clone(pk Packet) Packet {
+	if pk == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(pk)).Interface().(Packet)
+}
+
+// deepCopy returns a deep copy of the reflect.Value passed.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		// We first copy the full struct so that unexported fields, which we cannot set, are also copied.
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are very common in packets, so we copy them at once.
+			reflect.Copy(c, v)
+			return c
+		}
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.String:
+		// Strings read from packets may point directly into the data they were decoded from, so we copy
+		// their content too.
+		return reflect.ValueOf(string(append([]byte(nil), v.String()...))).Convert(v.Type())
+	default:
+		return v
+	}
+}
@@ -0,0 +1,99 @@
+package packet
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TestClone tests that the packets returned by Clone equal the packets cloned, and that changing the slices,
+// maps and NBT data of a packet after cloning it leaves the clone unchanged.
+func TestClone(t *testing.T) {
+	tests := []struct {
+		name string
+		// new returns a new packet, which is the same every time it is called. mutate changes the packet
+		// passed in place, through memory that a shallow copy of it would share.
+		new    func() Packet
+		mutate func(pk Packet)
+	}{
+		{
+			name: "byte slice",
+			new: func() Packet {
+				return &ResourcePackChunkData{UUID: "304017b5-f1a4-4241-a702-d47c06d146cb", Data: []byte("chunk")}
+			},
+			mutate: func(pk Packet) {
+				pk.(*ResourcePackChunkData).Data[0] = 'C'
+			},
+		},
+		{
+			name: "string slice",
+			new: func() Packet {
+				return &Text{TextType: TextTypeTranslation, Message: "%s", Parameters: []string{"a", "b"}}
+			},
+			mutate: func(pk Packet) {
+				pk.(*Text).Parameters[1] = "c"
+			},
+		},
+		{
+			name: "struct slice",
+			new: func() Packet {
+				return &ResourcePacksInfo{TexturePacks: []protocol.ResourcePackInfo{{UUID: "304017b5-f1a4-4241-a702-d47c06d146cb", Version: "1.0.0"}}}
+			},
+			mutate: func(pk Packet) {
+				pk.(*ResourcePacksInfo).TexturePacks[0].Version = "2.0.0"
+			},
+		},
+		{
+			name: "NBT",
+			new: func() Packet {
+				return &BlockActorData{NBTData: map[string]interface{}{
+					"id":    "Chest",
+					"Items": []interface{}{map[string]interface{}{"Count": byte(1), "Name": "minecraft:stone"}},
+					"Data":  []byte{1, 2, 3},
+					"Pos":   map[string]interface{}{"x": int32(1)},
+				}}
+			},
+			mutate: func(pk Packet) {
+				data := pk.(*BlockActorData).NBTData
+				data["Items"].([]interface{})[0].(map[string]interface{})["Count"] = byte(2)
+				data["Data"].([]byte)[0] = 9
+				data["Pos"].(map[string]interface{})["x"] = int32(2)
+				data["id"] = "Barrel"
+			},
+		},
+	}
+	for _, test := range tests {
+		pk := test.new()
+		clone := Clone(pk)
+		if clone == pk {
+			t.Errorf("%v: clone was the same packet as the packet cloned", test.name)
+			continue
+		}
+		if !reflect.DeepEqual(clone, pk) {
+			t.Errorf("%v: clone differed from the packet cloned:\n%v\nbecame\n%v", test.name, Dump(pk), Dump(clone))
+			continue
+		}
+		test.mutate(pk)
+		if !reflect.DeepEqual(clone, test.new()) {
+			t.Errorf("%v: clone changed after changing the packet cloned:\n%v", test.name, Dump(clone))
+		}
+	}
+
+	if Clone(nil) != nil {
+		t.Errorf("clone of nil packet was not nil")
+	}
+}
+
+// TestClonePool tests that the clone of a packet filled with random values equals the packet, for every
+// packet in the pool.
+func TestClonePool(t *testing.T) {
+	for _, id := range poolIDs() {
+		r := rand.New(rand.NewSource(int64(id)))
+		pk := randomPacket(r, id)
+		if d := diff(reflect.ValueOf(pk).Elem(), reflect.ValueOf(Clone(pk)).Elem(), packetName(pk)); d != "" {
+			t.Errorf("clone differed from the packet cloned: %v", d)
+		}
+	}
+}
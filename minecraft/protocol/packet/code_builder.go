@@ -29,7 +29,7 @@ func (pk *CodeBuilder) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CodeBuilder) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CodeBuilder) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.URL),
 		binary.Read(buf, binary.LittleEndian, &pk.ShouldOpenCodeBuilder),
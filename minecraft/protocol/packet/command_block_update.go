@@ -83,7 +83,7 @@ func (pk *CommandBlockUpdate) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CommandBlockUpdate) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CommandBlockUpdate) Unmarshal(buf *protocol.Reader) error {
 	if err := binary.Read(buf, binary.LittleEndian, &pk.Block); err != nil {
 		return err
 	}
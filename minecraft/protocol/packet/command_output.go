@@ -51,13 +51,13 @@ func (pk *CommandOutput) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CommandOutput) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CommandOutput) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 	if err := chainErr(
 		protocol.CommandOriginData(buf, &pk.CommandOrigin),
 		binary.Read(buf, binary.LittleEndian, &pk.OutputType),
 		protocol.Varuint32(buf, &pk.SuccessCount),
-		protocol.Varuint32(buf, &count),
+		protocol.Count(buf, &count, "CommandOutput output message"),
 	); err != nil {
 		return err
 	}
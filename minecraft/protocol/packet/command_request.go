@@ -34,7 +34,7 @@ func (pk *CommandRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CommandRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CommandRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.CommandLine),
 		protocol.CommandOriginData(buf, &pk.CommandOrigin),
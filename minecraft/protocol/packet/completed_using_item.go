@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 const (
@@ -46,7 +48,7 @@ func (pk *CompletedUsingItem) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CompletedUsingItem) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CompletedUsingItem) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.UsedItemID),
 		binary.Read(buf, binary.LittleEndian, &pk.UseMethod),
@@ -0,0 +1,115 @@
+package packet
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Compression represents a compression algorithm that batches of packets may be compressed with by an Encoder
+// and decompressed with by a Decoder. Implementations must be safe for use by multiple goroutines
+// simultaneously.
+// Minecraft only supports FlateCompression. Other algorithms, such as Snappy, may be used between two
+// gophertunnel connections if both ends register them using RegisterCompression.
+type Compression interface {
+	// ID returns the ID of the compression algorithm. The ID identifies the algorithm in the NetworkSettings
+	// packet, so it must be the same on both ends of a connection.
+	ID() uint16
+	// Compress compresses the data passed and writes the compressed data to the buffer passed. The level
+	// follows the levels of compress/flate: A level of flate.NoCompression (0) means that the data should be
+	// stored with as little processing as possible, while still being readable by Decompress. Algorithms that
+	// have no levels may ignore other levels.
+	Compress(dst *bytes.Buffer, data []byte, level int) error
+	// Decompress decompresses the data passed and returns the decompressed data.
+	Decompress(data []byte) ([]byte, error)
+}
+
+// FlateCompression is the Compression used by Minecraft, which compresses data using raw DEFLATE (RFC 1951).
+// It is used by Encoders and Decoders unless another Compression is set. Its ID is 0.
+var FlateCompression Compression = &flateCompression{}
+
+// RegisterCompression registers a Compression so that it may be used by connections that are told to use
+// its ID in a NetworkSettings packet. Registering a Compression with the ID of one already registered
+// replaces it.
+func RegisterCompression(compression Compression) {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+	registeredCompressions[compression.ID()] = compression
+}
+
+// CompressionByID returns the Compression registered with the ID passed. If no Compression with the ID was
+// registered, false is returned.
+func CompressionByID(id uint16) (Compression, bool) {
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+	compression, ok := registeredCompressions[id]
+	return compression, ok
+}
+
+// compressionMu guards registeredCompressions, as compressions may be registered while connections look
+// them up.
+var compressionMu sync.RWMutex
+
+// registeredCompressions holds the compression algorithms that were registered, by their ID.
+var registeredCompressions = map[uint16]Compression{
+	FlateCompression.ID(): FlateCompression,
+}
+
+// flateCompression implements the raw DEFLATE compression algorithm. Writers and readers are re-used across
+// calls, as they are expensive to create.
+type flateCompression struct {
+	// writers holds a sync.Pool of *flate.Writers for every compression level, starting at flate.HuffmanOnly.
+	writers [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	readers sync.Pool
+}
+
+// ID ...
+func (*flateCompression) ID() uint16 {
+	return 0
+}
+
+// Compress ...
+func (c *flateCompression) Compress(dst *bytes.Buffer, data []byte, level int) error {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return fmt.Errorf("invalid flate compression level %v", level)
+	}
+	pool := &c.writers[level-flate.HuffmanOnly]
+	w, ok := pool.Get().(*flate.Writer)
+	if ok {
+		w.Reset(dst)
+	} else {
+		w, _ = flate.NewWriter(dst, level)
+	}
+	defer pool.Put(w)
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error writing compressed data: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error closing compressor: %v", err)
+	}
+	return nil
+}
+
+// Decompress ...
+func (c *flateCompression) Decompress(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(data)
+	r, ok := c.readers.Get().(io.ReadCloser)
+	if ok {
+		if err := r.(flate.Resetter).Reset(buf, nil); err != nil {
+			return nil, fmt.Errorf("error resetting decompressor: %v", err)
+		}
+	} else {
+		r = flate.NewReader(buf)
+	}
+	defer c.readers.Put(r)
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading decompressed data: %v", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,53 @@
+package packet
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// testCompression is a Compression that stores data without compressing it.
+type testCompression struct{}
+
+// ID ...
+func (testCompression) ID() uint16 {
+	return 0xff
+}
+
+// Compress ...
+func (testCompression) Compress(dst *bytes.Buffer, data []byte, _ int) error {
+	dst.Write(data)
+	return nil
+}
+
+// Decompress ...
+func (testCompression) Decompress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// TestRegisterCompression tests that compressions may be registered while they are looked up on other
+// goroutines. It should be run with the race detector enabled.
+func TestRegisterCompression(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			RegisterCompression(testCompression{})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if _, ok := CompressionByID(FlateCompression.ID()); !ok {
+				t.Errorf("flate compression not registered")
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if c, ok := CompressionByID(testCompression{}.ID()); !ok || c != (testCompression{}) {
+		t.Errorf("registered compression not found by its ID: %v, %v", c, ok)
+	}
+}
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // ContainerClose is sent by the server to close a container the player currently has opened, which was opened
@@ -25,6 +27,6 @@ func (pk *ContainerClose) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ContainerClose) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ContainerClose) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &pk.WindowID)
 }
@@ -40,7 +40,7 @@ func (pk *ContainerOpen) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ContainerOpen) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ContainerOpen) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
 		binary.Read(buf, binary.LittleEndian, &pk.ContainerType),
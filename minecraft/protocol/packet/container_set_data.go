@@ -47,7 +47,7 @@ func (pk *ContainerSetData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ContainerSetData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ContainerSetData) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
 		protocol.Varint32(buf, &pk.Key),
@@ -68,9 +68,9 @@ func (pk *CraftingData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CraftingData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CraftingData) Unmarshal(buf *protocol.Reader) error {
 	var length uint32
-	if err := protocol.Varuint32(buf, &length); err != nil {
+	if err := protocol.Count(buf, &length, "CraftingData recipe"); err != nil {
 		return err
 	}
 	pk.Recipes = make([]protocol.Recipe, length)
@@ -105,7 +105,7 @@ func (pk *CraftingData) Unmarshal(buf *bytes.Buffer) error {
 		}
 		pk.Recipes[i] = recipe
 	}
-	if err := protocol.Varuint32(buf, &length); err != nil {
+	if err := protocol.Count(buf, &length, "CraftingData potion recipe"); err != nil {
 		return err
 	}
 	pk.PotionRecipes = make([]protocol.PotionRecipe, length)
@@ -114,7 +114,7 @@ func (pk *CraftingData) Unmarshal(buf *bytes.Buffer) error {
 			return err
 		}
 	}
-	if err := protocol.Varuint32(buf, &length); err != nil {
+	if err := protocol.Count(buf, &length, "CraftingData potion container change recipe"); err != nil {
 		return err
 	}
 	pk.PotionContainerChangeRecipes = make([]protocol.PotionContainerChangeRecipe, length)
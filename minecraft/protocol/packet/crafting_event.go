@@ -47,13 +47,13 @@ func (pk *CraftingEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CraftingEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CraftingEvent) Unmarshal(buf *protocol.Reader) error {
 	var length uint32
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
 		protocol.Varint32(buf, &pk.CraftingType),
 		protocol.UUID(buf, &pk.RecipeUUID),
-		protocol.Varuint32(buf, &length),
+		protocol.Count(buf, &length, "CraftingEvent input"),
 	); err != nil {
 		return err
 	}
@@ -66,7 +66,7 @@ func (pk *CraftingEvent) Unmarshal(buf *bytes.Buffer) error {
 			return err
 		}
 	}
-	if err := protocol.Varuint32(buf, &length); err != nil {
+	if err := protocol.Count(buf, &length, "CraftingEvent output"); err != nil {
 		return err
 	}
 	if length > 64 {
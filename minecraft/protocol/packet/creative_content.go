@@ -27,9 +27,9 @@ func (pk *CreativeContent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *CreativeContent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *CreativeContent) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "CreativeContent item"); err != nil {
 		return err
 	}
 	pk.Items = make([]protocol.CreativeItem, count)
@@ -26,7 +26,7 @@ func (pk *DebugInfo) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *DebugInfo) Unmarshal(buf *bytes.Buffer) error {
+func (pk *DebugInfo) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint64(buf, &pk.PlayerUniqueID),
 		protocol.ByteSlice(buf, &pk.Data),
@@ -2,20 +2,18 @@ package packet
 
 import (
 	"bytes"
-	"compress/flate"
 	"crypto/aes"
 	"fmt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"io"
-	"io/ioutil"
 )
 
 // Decoder handles the decoding of Minecraft packets sent through an io.Reader. These packets in turn contain
 // multiple compressed packets.
 type Decoder struct {
-	buf          []byte
-	decompressor io.ReadCloser
-	reader       io.Reader
+	buf         []byte
+	compression Compression
+	reader      io.Reader
 
 	encrypt *encrypt
 
@@ -23,11 +21,13 @@ type Decoder struct {
 }
 
 // NewDecoder returns a new decoder decoding data from the reader passed. One read call from the reader is
-// assumed to consume an entire packet.
+// assumed to consume an entire packet. Readers of stream oriented connections, such as TCP, must therefore
+// frame the packets themselves.
 func NewDecoder(reader io.Reader) *Decoder {
 	return &Decoder{
 		reader:           reader,
 		buf:              make([]byte, 1024*1024*3),
+		compression:      FlateCompression,
 		checkPacketLimit: true,
 	}
 }
@@ -39,6 +39,12 @@ func (decoder *Decoder) EnableEncryption(keyBytes [32]byte) {
 	decoder.encrypt = newEncrypt(keyBytes, newCFB8Decrypter(block, append([]byte(nil), keyBytes[:aes.BlockSize]...)))
 }
 
+// SetCompression sets the Compression that batches decoded after the call are decompressed with. By default,
+// FlateCompression is used.
+func (decoder *Decoder) SetCompression(compression Compression) {
+	decoder.compression = compression
+}
+
 // DisableBatchPacketLimit disables the check that limits the number of packets allowed in a single packet
 // batch. This should typically be called for Decoders decoding from a server connection.
 func (decoder *Decoder) DisableBatchPacketLimit() {
@@ -58,7 +64,10 @@ const (
 func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 	n, err := decoder.reader.Read(decoder.buf)
 	if err != nil {
-		return nil, fmt.Errorf("error reading batch from reader: %v", err)
+		return nil, fmt.Errorf("error reading batch from reader: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("error reading packet: empty batch")
 	}
 	data := decoder.buf[:n]
 	if data[0] != header {
@@ -66,19 +75,25 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 	}
 	data = data[1:]
 	if decoder.encrypt != nil {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("error verifying packet: encrypted batch of %v bytes is too short to hold a checksum", len(data))
+		}
 		decoder.encrypt.decrypt(data)
 		if err := decoder.encrypt.verify(data); err != nil {
 			// The packet was not encrypted properly.
 			return nil, fmt.Errorf("error verifying packet: %v", err)
 		}
+		// The checksum verified is not part of the compressed data.
+		data = data[:len(data)-8]
 	}
 
-	raw, err := decoder.decompress(data)
+	raw, err := decoder.compression.Decompress(data)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error decompressing batch: %v", err)
 	}
 
-	b := bytes.NewBuffer(raw)
+	// The batch only holds length-prefixed packets, so no limits are needed to read it.
+	b := &protocol.Reader{Buffer: bytes.NewBuffer(raw)}
 	for b.Len() != 0 {
 		if len(packets) > maximumInBatch && decoder.checkPacketLimit {
 			return nil, fmt.Errorf("number of packets in compressed batch exceeds %v", maximumInBatch)
@@ -91,27 +106,3 @@ func (decoder *Decoder) Decode() (packets [][]byte, err error) {
 	}
 	return
 }
-
-// decompress decompresses the data passed and returns it as a byte slice.
-func (decoder *Decoder) decompress(data []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(data)
-	if err := decoder.init(buf); err != nil {
-		return nil, fmt.Errorf("error decompressing data: %v", err)
-	}
-	_ = decoder.decompressor.Close()
-	raw, err := ioutil.ReadAll(decoder.decompressor)
-	if err != nil {
-		return nil, fmt.Errorf("error reading decompressed data: %v", err)
-	}
-	return raw, nil
-}
-
-// init initialises the decompression reader if it wasn't already.
-func (decoder *Decoder) init(buf *bytes.Buffer) (err error) {
-	if decoder.decompressor == nil {
-		decoder.decompressor = flate.NewReader(buf)
-		return
-	}
-	// The reader was already initialised, so we reset it to the buffer passed.
-	return decoder.decompressor.(flate.Resetter).Reset(buf, nil)
-}
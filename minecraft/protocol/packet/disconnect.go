@@ -31,7 +31,7 @@ func (pk *Disconnect) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Disconnect) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Disconnect) Unmarshal(buf *protocol.Reader) error {
 	if err := binary.Read(buf, binary.LittleEndian, &pk.HideDisconnectionScreen); err != nil {
 		return err
 	}
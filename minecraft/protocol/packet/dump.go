@@ -0,0 +1,156 @@
+package packet
+
+import (
+	"encoding"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dump returns a human readable representation of the packet passed, including all of its nested values.
+// Every field of a struct is printed on its own line, and nested structs, slices and maps are indented using
+// a single tab. Values held by interfaces, such as the values of NBT maps and entity metadata, are printed
+// along with the name of their type, like int32(5). Maps are sorted by their keys, so that the result is the
+// same every time Dump is called with the same packet.
+// Dump is meant for logging and debugging: Use MarshalJSON for a representation that may be converted back
+// into the packet.
+func Dump(pk Packet) string {
+	if pk == nil {
+		return "nil"
+	}
+	s := &packetDumpState{}
+	return packetName(pk) + s.value(reflect.ValueOf(pk))
+}
+
+// packetDumpState is used to keep track of the indentation during a single call to Dump.
+type packetDumpState struct {
+	// currentIndent is the amount of tabs that should be present in front of fields and elements in the
+	// dump. It is increased every time a struct, slice or map is opened, and reduced when it is closed.
+	currentIndent int
+}
+
+// indent returns the indentation required for the current nesting level.
+func (s *packetDumpState) indent() string {
+	return strings.Repeat("	", s.currentIndent)
+}
+
+// value returns the representation of the reflect.Value passed in the dump.
+func (s *packetDumpState) value(v reflect.Value) string {
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && t.Implements(textMarshalerType) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, t.Bits())
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "nil"
+		}
+		return s.value(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return v.Elem().Type().String() + "(" + s.value(v.Elem()) + ")"
+	case reflect.Slice:
+		if v.IsNil() {
+			return "nil"
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "0x" + hex.EncodeToString(v.Bytes())
+		}
+		return s.list(v)
+	case reflect.Array:
+		return s.list(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return "nil"
+		}
+		keys, err := sortedKeys(v)
+		if err != nil || len(keys) == 0 {
+			return "{}"
+		}
+		b := strings.Builder{}
+		b.WriteString("{\n")
+		s.currentIndent++
+		for _, k := range keys {
+			key := k.s
+			if k.v.Kind() == reflect.String {
+				key = strconv.Quote(key)
+			}
+			b.WriteString(s.indent() + key + ": " + s.value(v.MapIndex(k.v)) + "\n")
+		}
+		s.currentIndent--
+		b.WriteString(s.indent() + "}")
+		return b.String()
+	case reflect.Struct:
+		b := strings.Builder{}
+		s.currentIndent++
+		s.fields(&b, v)
+		s.currentIndent--
+		if b.Len() == 0 {
+			return "{}"
+		}
+		return "{\n" + b.String() + s.indent() + "}"
+	}
+	return "<" + t.String() + ">"
+}
+
+// fields writes the exported fields of the struct passed to the strings.Builder, one per line. The fields of
+// embedded structs are written as if they were fields of the struct itself.
+func (s *packetDumpState) fields(b *strings.Builder, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			s.fields(b, v.Field(i))
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported field.
+			continue
+		}
+		b.WriteString(s.indent() + field.Name + ": " + s.value(v.Field(i)) + "\n")
+	}
+}
+
+// list returns the representation of the slice or array passed. Lists of numbers with few elements, such as
+// vectors and block positions, are written on a single line. Other lists are written with one element per
+// line.
+func (s *packetDumpState) list(v reflect.Value) string {
+	if v.Len() == 0 {
+		return "[]"
+	}
+	elems := make([]string, v.Len())
+	switch v.Type().Elem().Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if v.Len() <= 16 {
+			for i := range elems {
+				elems[i] = s.value(v.Index(i))
+			}
+			return "[" + strings.Join(elems, ", ") + "]"
+		}
+	}
+	b := strings.Builder{}
+	b.WriteString("[\n")
+	s.currentIndent++
+	for i := range elems {
+		b.WriteString(s.indent() + s.value(v.Index(i)) + "\n")
+	}
+	s.currentIndent--
+	b.WriteString(s.indent() + "]")
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		pk       Packet
+		expected string
+	}{
+		{pk: nil, expected: "nil"},
+		{
+			pk: &Unknown{PacketID: 0xfff, Payload: []byte{1, 2}},
+			expected: `Unknown{
+	PacketID: 4095
+	Payload: 0x0102
+}`,
+		},
+		{
+			pk: &Text{TextType: TextTypeChat, SourceName: "Steve", Message: "Hi", Parameters: []string{"a"}},
+			expected: `Text{
+	TextType: 1
+	NeedsTranslation: false
+	SourceName: "Steve"
+	Message: "Hi"
+	Parameters: [
+		"a"
+	]
+	XUID: ""
+	PlatformChatID: ""
+}`,
+		},
+		{
+			// The keys of maps are sorted and the values held by interfaces are printed with their type.
+			pk: &BlockActorData{Position: protocol.BlockPos{1, -2, 3}, NBTData: map[string]interface{}{
+				"z": int32(5),
+				"a": []interface{}{"x", byte(1)},
+			}},
+			expected: `BlockActorData{
+	Position: [1, -2, 3]
+	NBTData: {
+		"a": []interface {}([
+			string("x")
+			uint8(1)
+		])
+		"z": int32(5)
+	}
+}`,
+		},
+	}
+	for _, test := range tests {
+		if dump := Dump(test.pk); dump != test.expected {
+			t.Errorf("unexpected dump:\n%v\nexpected:\n%v", dump, test.expected)
+		}
+	}
+}
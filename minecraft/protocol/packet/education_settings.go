@@ -41,7 +41,7 @@ func (pk *EducationSettings) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *EducationSettings) Unmarshal(buf *bytes.Buffer) error {
+func (pk *EducationSettings) Unmarshal(buf *protocol.Reader) error {
 	var hasOverrideURI bool
 	if err := chainErr(
 		protocol.String(buf, &pk.CodeBuilderDefaultURI),
@@ -36,7 +36,7 @@ func (pk *Emote) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Emote) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Emote) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.String(buf, &pk.EmoteID),
@@ -38,12 +38,12 @@ func (pk *EmoteList) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *EmoteList) Unmarshal(buf *bytes.Buffer) error {
+func (pk *EmoteList) Unmarshal(buf *protocol.Reader) error {
 	if err := protocol.Varuint64(buf, &pk.PlayerRuntimeID); err != nil {
 		return err
 	}
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "EmoteList emote piece"); err != nil {
 		return err
 	}
 	if count > 6 {
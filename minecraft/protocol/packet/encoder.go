@@ -12,29 +12,43 @@ import (
 // Encoder handles the encoding of Minecraft packets that are sent to an io.Writer. The packets are compressed
 // and optionally encoded before they are sent to the io.Writer.
 type Encoder struct {
-	compressor    *flate.Writer
-	noCompression *flate.Writer
-	writer        io.Writer
-	buf           *bytes.Buffer
-	compressed    *bytes.Buffer
+	writer     io.Writer
+	buf        *bytes.Buffer
+	compressed *bytes.Buffer
+
+	compression Compression
+	level       int
+	threshold   int
 
 	encrypt *encrypt
 }
 
+// DefaultCompressionThreshold is the compression threshold used by an Encoder if none is set using
+// Encoder.SetCompression.
+const DefaultCompressionThreshold = 512
+
 // NewEncoder returns a new Encoder for the io.Writer passed. Each final packet produced by the Encoder is
 // sent with a single call to io.Writer.Write().
+// The Encoder compresses batches using FlateCompression at the default level, provided they are at least
+// DefaultCompressionThreshold bytes long.
 func NewEncoder(writer io.Writer) *Encoder {
-	w, _ := flate.NewWriter(writer, flate.NoCompression)
-	w2, _ := flate.NewWriter(writer, flate.DefaultCompression)
 	return &Encoder{
-		compressor:    w2,
-		writer:        writer,
-		buf:           bytes.NewBuffer(make([]byte, 0, 1024*1024*2)),
-		compressed:    bytes.NewBuffer(make([]byte, 0, 1024*1024*3)),
-		noCompression: w,
+		writer:      writer,
+		buf:         bytes.NewBuffer(make([]byte, 0, 1024*1024*2)),
+		compressed:  bytes.NewBuffer(make([]byte, 0, 1024*1024*3)),
+		compression: FlateCompression,
+		level:       flate.DefaultCompression,
+		threshold:   DefaultCompressionThreshold,
 	}
 }
 
+// SetCompression sets the Compression that batches encoded after the call are compressed with, at the level
+// passed. Batches of which the size before compression is smaller than the threshold passed are passed to
+// the Compression with level flate.NoCompression instead. If the threshold is 0, no batches are compressed.
+func (encoder *Encoder) SetCompression(compression Compression, level, threshold int) {
+	encoder.compression, encoder.level, encoder.threshold = compression, level, threshold
+}
+
 // EnableEncryption enables encryption for the Encoder using the secret key bytes passed. Each packet sent
 // after encryption is enabled will be encrypted.
 func (encoder *Encoder) EnableEncryption(keyBytes [32]byte) {
@@ -64,18 +78,16 @@ func (encoder *Encoder) Encode(packets [][]byte) error {
 		}
 	}
 
-	var w *flate.Writer
-	if encoder.compressed.Len() <= 512 {
-		w = encoder.noCompression
-	} else {
-		w = encoder.compressor
+	level := encoder.level
+	if encoder.threshold == 0 || encoder.compressed.Len() < encoder.threshold {
+		level = flate.NoCompression
 	}
-	// We compress the data and write the full data to the io.Writer. The data returned includes the header
+	// We compress the data and write the full data to the io.Writer. The data written includes the header
 	// we wrote at the start.
-	b, err := encoder.compress(w, encoder.compressed.Bytes())
-	if err != nil {
-		return err
+	if err := encoder.compression.Compress(encoder.buf, encoder.compressed.Bytes(), level); err != nil {
+		return fmt.Errorf("error compressing batch: %v", err)
 	}
+	b := encoder.buf.Bytes()
 
 	if encoder.encrypt != nil {
 		// If the encryption session is not nil, encryption is enabled, meaning we should encrypt the
@@ -87,16 +99,3 @@ func (encoder *Encoder) Encode(packets [][]byte) error {
 	}
 	return nil
 }
-
-// compress compresses the data passed using the writer passed and returns it in a byte slice. It returns
-// the full content of encoder.buf, so any data currently set in that buffer will also be returned.
-func (encoder *Encoder) compress(w *flate.Writer, data []byte) ([]byte, error) {
-	w.Reset(encoder.buf)
-	if _, err := w.Write(data); err != nil {
-		return nil, fmt.Errorf("error writing compressed data: %v", err)
-	}
-	if err := w.Close(); err != nil {
-		return nil, fmt.Errorf("error closing compressor: %v", err)
-	}
-	return encoder.buf.Bytes(), nil
-}
@@ -49,7 +49,7 @@ func (pk *Event) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Event) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Event) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Varint32(buf, &pk.EventType),
@@ -0,0 +1,566 @@
+package packet
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"image/color"
+	"math/rand"
+	"reflect"
+	"strconv"
+)
+
+// maxFillDepth is the maximum depth of nested values that fill fills. Deeper slices and maps are left empty.
+const maxFillDepth = 6
+
+// randomPacket returns a new packet with the ID passed, filled with random values that the packet can be
+// encoded with.
+func randomPacket(r *rand.Rand, id uint32) Packet {
+	pk := newPacket(id)
+	fill(r, reflect.ValueOf(pk).Elem(), 0)
+	fix(r, reflect.ValueOf(pk).Elem())
+	if f, ok := packetFixes[id]; ok {
+		f(r, pk)
+	}
+	return pk
+}
+
+// interfaceTypes holds the types that may be held by the interfaces found in packets. All of these interfaces
+// have the same methods, so the types must be listed for every interface.
+var interfaceTypes = map[reflect.Type][]interface{}{
+	reflect.TypeOf((*protocol.Recipe)(nil)).Elem(): {
+		&protocol.ShapelessRecipe{}, &protocol.ShapedRecipe{}, &protocol.FurnaceRecipe{},
+		&protocol.FurnaceDataRecipe{}, &protocol.MultiRecipe{}, &protocol.ShulkerBoxRecipe{},
+		&protocol.ShapelessChemistryRecipe{}, &protocol.ShapedChemistryRecipe{},
+	},
+	reflect.TypeOf((*protocol.InventoryTransactionData)(nil)).Elem(): {
+		&protocol.NormalTransactionData{}, &protocol.MismatchTransactionData{}, &protocol.UseItemTransactionData{},
+		&protocol.UseItemOnEntityTransactionData{}, &protocol.ReleaseItemTransactionData{},
+	},
+	reflect.TypeOf((*protocol.StackRequestAction)(nil)).Elem(): {
+		&protocol.TakeStackRequestAction{}, &protocol.PlaceStackRequestAction{}, &protocol.SwapStackRequestAction{},
+		&protocol.DropStackRequestAction{}, &protocol.DestroyStackRequestAction{},
+		&protocol.ConsumeStackRequestAction{}, &protocol.CreateStackRequestAction{},
+		&protocol.LabTableCombineStackRequestAction{}, &protocol.BeaconPaymentStackRequestAction{},
+		&protocol.CraftRecipeStackRequestAction{}, &protocol.AutoCraftRecipeStackRequestAction{},
+		&protocol.CraftCreativeStackRequestAction{}, &protocol.CraftNonImplementedStackRequestAction{},
+		&protocol.CraftResultsDeprecatedStackRequestAction{},
+	},
+}
+
+// fill fills the settable reflect.Value passed with random values. Values held by interfaces are given one of
+// the types of interfaceTypes, or int32 for empty interfaces, which is valid both in NBT and in entity
+// metadata.
+func fill(r *rand.Rand, v reflect.Value, depth int) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(randomInt(r, v.Type().Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(randomInt(r, v.Type().Bits())))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat((r.Float64() - 0.5) * 1e6)
+	case reflect.String:
+		b := make([]byte, r.Intn(16))
+		for i := range b {
+			b[i] = byte('a' + r.Intn(26))
+		}
+		v.SetString(string(b))
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(r, v.Elem(), depth+1)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(int32(r.Int31())))
+			return
+		}
+		types := interfaceTypes[v.Type()]
+		if len(types) == 0 {
+			return
+		}
+		value := reflect.New(reflect.TypeOf(types[r.Intn(len(types))])).Elem()
+		fill(r, value, depth+1)
+		v.Set(value)
+	case reflect.Slice:
+		n := 0
+		if depth < maxFillDepth {
+			n = r.Intn(4)
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			fill(r, v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(r, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		if depth >= maxFillDepth {
+			return
+		}
+		for i, n := 0, r.Intn(4); i < n; i++ {
+			key, value := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			fill(r, key, depth+1)
+			fill(r, value, depth+1)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() || (v.Type().Field(i).Anonymous && f.Kind() == reflect.Struct) {
+				fill(r, f, depth)
+			}
+		}
+	}
+}
+
+// randomInt returns a random integer that fits in the amount of bits passed. Small values are returned more
+// often than large ones, as many fields are counts or enums.
+func randomInt(r *rand.Rand, bits int) int64 {
+	switch r.Intn(3) {
+	case 0:
+		return int64(r.Intn(4))
+	case 1:
+		return r.Int63() >> uint(64-bits)
+	}
+	return int64(r.Uint64()) >> uint(64-bits)
+}
+
+// typeFixes holds functions by the type they fix. They change the random values of a type, filled by fill,
+// so that they satisfy the constraints that encoding the type has.
+var typeFixes = map[reflect.Type]func(r *rand.Rand, v reflect.Value){
+	reflect.TypeOf(protocol.ItemStack{}): func(r *rand.Rand, v reflect.Value) {
+		// Only the lowest 8 bits of the count of an item stack are encoded, and nothing but the network ID is
+		// encoded for air.
+		x := v.Addr().Interface().(*protocol.ItemStack)
+		x.Count &= 0xff
+		if x.NetworkID == 0 {
+			*x = protocol.ItemStack{}
+		}
+	},
+	reflect.TypeOf(protocol.ItemInstance{}): func(r *rand.Rand, v reflect.Value) {
+		// Empty stacks must have a stack network ID of 0.
+		x := v.Addr().Interface().(*protocol.ItemInstance)
+		if x.Stack.Count == 0 || x.Stack.NetworkID == 0 {
+			x.StackNetworkID = 0
+		}
+	},
+	reflect.TypeOf(protocol.StackResponseSlotInfo{}): func(r *rand.Rand, v reflect.Value) {
+		x := v.Addr().Interface().(*protocol.StackResponseSlotInfo)
+		x.HotbarSlot = x.Slot
+	},
+	reflect.TypeOf(protocol.MapTrackedObject{}): func(r *rand.Rand, v reflect.Value) {
+		// Either the entity unique ID or the block position is encoded, depending on the type.
+		x := v.Addr().Interface().(*protocol.MapTrackedObject)
+		x.Type = int32(r.Intn(2))
+		if x.Type == protocol.MapObjectTypeEntity {
+			x.BlockPosition = protocol.BlockPos{}
+		} else {
+			x.EntityUniqueID = 0
+		}
+	},
+	reflect.TypeOf(protocol.InventoryAction{}): func(r *rand.Rand, v reflect.Value) {
+		// The window ID and source flags are only encoded for some source types.
+		x := v.Addr().Interface().(*protocol.InventoryAction)
+		switch x.SourceType {
+		case protocol.InventoryActionSourceContainer, protocol.InventoryActionSourceTODO:
+			x.SourceFlags = 0
+		case protocol.InventoryActionSourceWorld:
+			x.WindowID = 0
+		default:
+			x.WindowID, x.SourceFlags = 0, 0
+		}
+	},
+	reflect.TypeOf(protocol.FurnaceRecipe{}): func(r *rand.Rand, v reflect.Value) {
+		// Unlike FurnaceDataRecipe, FurnaceRecipe does not encode the metadata value of the input.
+		v.Addr().Interface().(*protocol.FurnaceRecipe).InputType.MetadataValue = 0
+	},
+	reflect.TypeOf(protocol.CommandOrigin{}): func(r *rand.Rand, v reflect.Value) {
+		x := v.Addr().Interface().(*protocol.CommandOrigin)
+		if x.Origin != protocol.CommandOriginDevConsole && x.Origin != protocol.CommandOriginTest {
+			x.PlayerUniqueID = 0
+		}
+	},
+	reflect.TypeOf(protocol.ItemStackResponse{}): func(r *rand.Rand, v reflect.Value) {
+		if x := v.Addr().Interface().(*protocol.ItemStackResponse); !x.Success {
+			x.ContainerInfo = nil
+		}
+	},
+	reflect.TypeOf(protocol.ScoreboardEntry{}): func(r *rand.Rand, v reflect.Value) {
+		// Either the entity unique ID or the display name is encoded, depending on the identity type.
+		x := v.Addr().Interface().(*protocol.ScoreboardEntry)
+		x.IdentityType = byte(protocol.ScoreboardIdentityPlayer + r.Intn(3))
+		if x.IdentityType == protocol.ScoreboardIdentityFakePlayer {
+			x.EntityUniqueID = 0
+		} else {
+			x.DisplayName = ""
+		}
+	},
+	reflect.TypeOf(protocol.ShapedRecipe{}):             fixShapedRecipe,
+	reflect.TypeOf(protocol.ShapedChemistryRecipe{}):    fixShapedRecipe,
+	reflect.TypeOf(protocol.ShapelessRecipe{}):          fixShapelessRecipe,
+	reflect.TypeOf(protocol.ShulkerBoxRecipe{}):         fixShapelessRecipe,
+	reflect.TypeOf(protocol.ShapelessChemistryRecipe{}): fixShapelessRecipe,
+	reflect.TypeOf(protocol.Skin{}): func(r *rand.Rand, v reflect.Value) {
+		x := v.Addr().Interface().(*protocol.Skin)
+		x.SkinImageWidth, x.SkinImageHeight, x.SkinData = randomImage(r)
+		x.CapeImageWidth, x.CapeImageHeight, x.CapeData = randomImage(r)
+		for i := range x.Animations {
+			x.Animations[i].ImageWidth, x.Animations[i].ImageHeight, x.Animations[i].ImageData = randomImage(r)
+		}
+	},
+	reflect.TypeOf(protocol.CommandParameter{}): func(r *rand.Rand, v reflect.Value) {
+		// The type of a parameter holds the offset of its enum or suffix, which is only written if the
+		// parameter has no enum. Parameters without either have a basic type.
+		x := v.Addr().Interface().(*protocol.CommandParameter)
+		if x.Enum.Dynamic || len(x.Enum.Options) != 0 {
+			x.Suffix = ""
+			return
+		}
+		x.Enum = protocol.CommandEnum{}
+		if x.Suffix == "" {
+			x.Type = protocol.CommandArgValid | uint32(protocol.CommandArgTypeInt+r.Intn(protocol.CommandArgTypeCommand))
+		}
+	},
+}
+
+// fixShapedRecipe fixes a ShapedRecipe or ShapedChemistryRecipe so that it has Width*Height input items.
+func fixShapedRecipe(r *rand.Rand, v reflect.Value) {
+	x := v.Addr().Convert(reflect.TypeOf(&protocol.ShapedRecipe{})).Interface().(*protocol.ShapedRecipe)
+	x.Width, x.Height = int32(1+r.Intn(3)), int32(1+r.Intn(3))
+	x.Input = make([]protocol.ItemStack, x.Width*x.Height)
+	for i := range x.Input {
+		fill(r, reflect.ValueOf(&x.Input[i]).Elem(), maxFillDepth-1)
+	}
+	fixIngredients(x.Input)
+}
+
+// fixShapelessRecipe fixes a ShapelessRecipe, ShulkerBoxRecipe or ShapelessChemistryRecipe so that its input
+// items have valid counts.
+func fixShapelessRecipe(r *rand.Rand, v reflect.Value) {
+	fixIngredients(v.Addr().Convert(reflect.TypeOf(&protocol.ShapelessRecipe{})).Interface().(*protocol.ShapelessRecipe).Input)
+}
+
+// fixIngredients clears the fields of the recipe ingredients passed that are not encoded for ingredients,
+// which are only the network ID, metadata value and count, and makes their counts positive, as negative counts
+// are invalid. Nothing but the network ID is encoded for air.
+func fixIngredients(ingredients []protocol.ItemStack) {
+	for i, x := range ingredients {
+		if x.Count < 0 {
+			x.Count = -(x.Count + 1)
+		}
+		ingredients[i] = protocol.ItemStack{ItemType: x.ItemType, Count: x.Count}
+		if x.NetworkID == 0 {
+			ingredients[i] = protocol.ItemStack{}
+		}
+	}
+}
+
+// randomImage returns the width, height and data of a small random image.
+func randomImage(r *rand.Rand) (uint32, uint32, []byte) {
+	width, height := uint32(r.Intn(4)), uint32(r.Intn(4))
+	data := make([]byte, width*height*4)
+	r.Read(data)
+	return width, height, data
+}
+
+// fix applies the functions of typeFixes to the reflect.Value passed and all values nested in it.
+func fix(r *rand.Rand, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			fix(r, v.Elem())
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fix(r, v.Index(i))
+		}
+		return
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fix(r, v.Field(i))
+		}
+	}
+	if f, ok := typeFixes[v.Type()]; ok && v.CanAddr() {
+		f(r, v)
+	}
+}
+
+// packetFixes holds functions by the ID of the packet they fix. Like typeFixes, they change the random values
+// of the packet so that it may be encoded.
+var packetFixes = map[uint32]func(r *rand.Rand, pk Packet){
+	IDDisconnect: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*Disconnect); x.HideDisconnectionScreen {
+			x.Message = ""
+		}
+	},
+	IDText: func(r *rand.Rand, pk Packet) {
+		// The source name and parameters are only encoded for some text types, and nothing is encoded for
+		// unknown text types.
+		x := pk.(*Text)
+		x.TextType = byte(r.Intn(TextTypeAnnouncement + 1))
+		switch x.TextType {
+		case TextTypeChat, TextTypeWhisper, TextTypeAnnouncement:
+			x.Parameters = nil
+		case TextTypeTranslation, TextTypePopup, TextTypeJukeboxPopup:
+			x.SourceName = ""
+		default:
+			x.SourceName, x.Parameters = "", nil
+		}
+	},
+	IDAddActor: func(r *rand.Rand, pk Packet) {
+		// The default value of attributes is not encoded when adding an actor.
+		for i := range pk.(*AddActor).Attributes {
+			pk.(*AddActor).Attributes[i].Default = 0
+		}
+	},
+	IDMoveActorAbsolute: func(r *rand.Rand, pk Packet) {
+		pk.(*MoveActorAbsolute).Rotation = randomRotation(r)
+	},
+	IDMovePlayer: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*MovePlayer); x.Mode != MoveModeTeleport {
+			x.TeleportCause, x.TeleportSourceEntityType = 0, 0
+		}
+	},
+	IDInventoryTransaction: func(r *rand.Rand, pk Packet) {
+		x := pk.(*InventoryTransaction)
+		if x.LegacyRequestID == 0 {
+			x.LegacySetItemSlots = nil
+		}
+		if !x.HasNetworkIDs {
+			for i := range x.Actions {
+				x.Actions[i].StackNetworkID = 0
+			}
+		}
+	},
+	IDInteract: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*Interact); x.ActionType != InteractActionMouseOverEntity && x.ActionType != InteractActionLeaveVehicle {
+			x.Position = mgl32.Vec3{}
+		}
+	},
+	IDAnimate: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*Animate); x.ActionType&0x80 == 0 {
+			x.BoatRowingTime = 0
+		}
+	},
+	IDLevelChunk: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*LevelChunk); !x.CacheEnabled {
+			x.BlobHashes = nil
+		}
+	},
+	IDCommandBlockUpdate: func(r *rand.Rand, pk Packet) {
+		x := pk.(*CommandBlockUpdate)
+		if x.Block {
+			x.MinecartEntityRuntimeID = 0
+		} else {
+			x.Position, x.Mode, x.NeedsRedstone, x.Conditional = protocol.BlockPos{}, 0, false, false
+		}
+	},
+	IDCommandOutput: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*CommandOutput); x.OutputType != 4 {
+			x.UnknownString = ""
+		}
+	},
+	IDPlaySound: func(r *rand.Rand, pk Packet) {
+		// The position is encoded as a block position holding eight times the position.
+		x := pk.(*PlaySound)
+		for i := range x.Position {
+			x.Position[i] = float32(int32(x.Position[i]*8)) / 8
+		}
+	},
+	IDMoveActorDelta: func(r *rand.Rand, pk Packet) {
+		x := pk.(*MoveActorDelta)
+		x.Rotation = randomRotation(r)
+		for i := 0; i < 3; i++ {
+			if x.Flags&(MoveActorDeltaFlagHasX<<uint(i)) == 0 {
+				x.DeltaPosition[i] = 0
+			}
+			if x.Flags&(MoveActorDeltaFlagHasRotX<<uint(i)) == 0 {
+				x.Rotation[i] = 0
+			}
+		}
+	},
+	IDSetScoreboardIdentity: func(r *rand.Rand, pk Packet) {
+		x := pk.(*SetScoreboardIdentity)
+		if x.ActionType == ScoreboardIdentityActionRegister {
+			return
+		}
+		for i := range x.Entries {
+			x.Entries[i].EntityUniqueID = 0
+		}
+	},
+	IDPlayerAuthInput: func(r *rand.Rand, pk Packet) {
+		if x := pk.(*PlayerAuthInput); x.PlayMode != PlayModeReality {
+			x.GazeDirection = mgl32.Vec3{}
+		}
+	},
+	IDStartGame: func(r *rand.Rand, pk Packet) {
+		pk.(*StartGame).GameRules = randomGameRules(r)
+		pk.(*StartGame).Blocks = nil
+	},
+	IDGameRulesChanged: func(r *rand.Rand, pk Packet) {
+		pk.(*GameRulesChanged).GameRules = randomGameRules(r)
+	},
+	IDPlayerList: func(r *rand.Rand, pk Packet) {
+		// Only the UUID of entries is encoded when removing them.
+		x := pk.(*PlayerList)
+		x.ActionType = byte(r.Intn(2))
+		if x.ActionType == PlayerListActionRemove {
+			for i, entry := range x.Entries {
+				x.Entries[i] = protocol.PlayerListEntry{UUID: entry.UUID}
+			}
+		}
+	},
+	IDBossEvent: func(r *rand.Rand, pk Packet) {
+		x := pk.(*BossEvent)
+		x.EventType = uint32(r.Intn(BossEventTexture + 1))
+		y := BossEvent{BossEntityUniqueID: x.BossEntityUniqueID, EventType: x.EventType}
+		switch x.EventType {
+		case BossEventShow:
+			y.BossBarTitle, y.HealthPercentage, y.UnknownInt16, y.Colour, y.Overlay = x.BossBarTitle, x.HealthPercentage, x.UnknownInt16, x.Colour, x.Overlay
+		case BossEventRegisterPlayer, BossEventUnregisterPlayer:
+			y.PlayerUniqueID = x.PlayerUniqueID
+		case BossEventHealthPercentage:
+			y.HealthPercentage = x.HealthPercentage
+		case BossEventTitle:
+			y.BossBarTitle = x.BossBarTitle
+		case BossEventAppearanceProperties:
+			y.UnknownInt16, y.Colour, y.Overlay = x.UnknownInt16, x.Colour, x.Overlay
+		case BossEventTexture:
+			y.Colour, y.Overlay = x.Colour, x.Overlay
+		}
+		*x = y
+	},
+	IDBookEdit: func(r *rand.Rand, pk Packet) {
+		x := pk.(*BookEdit)
+		x.ActionType = byte(r.Intn(BookActionSign + 1))
+		y := BookEdit{ActionType: x.ActionType, InventorySlot: x.InventorySlot}
+		switch x.ActionType {
+		case BookActionReplacePage, BookActionAddPage:
+			y.PageNumber, y.Text, y.PhotoName = x.PageNumber, x.Text, x.PhotoName
+		case BookActionDeletePage:
+			y.PageNumber = x.PageNumber
+		case BookActionSwapPages:
+			y.PageNumber, y.SecondaryPageNumber = x.PageNumber, x.SecondaryPageNumber
+		case BookActionSign:
+			y.Title, y.Author, y.XUID = x.Title, x.Author, x.XUID
+		}
+		*x = y
+	},
+	IDSetScore: func(r *rand.Rand, pk Packet) {
+		// The identity of entries is only encoded when modifying them.
+		x := pk.(*SetScore)
+		x.ActionType = byte(r.Intn(2))
+		if x.ActionType == ScoreboardActionRemove {
+			for i, entry := range x.Entries {
+				x.Entries[i] = protocol.ScoreboardEntry{EntryID: entry.EntryID, ObjectiveName: entry.ObjectiveName, Score: entry.Score}
+			}
+		}
+	},
+	IDEmoteList: func(r *rand.Rand, pk Packet) {
+		x := pk.(*EmoteList)
+		if len(x.EmotePieces) > 6 {
+			x.EmotePieces = x.EmotePieces[:6]
+		}
+	},
+	IDAvailableCommands: func(r *rand.Rand, pk Packet) {
+		// Commands and enums are identified by their names, so these must be unique. Constraints must point
+		// to an option of one of the enums of the commands.
+		x := pk.(*AvailableCommands)
+		var options [][2]string
+		n := 0
+		for i := range x.Commands {
+			x.Commands[i].Name += strconv.Itoa(i)
+			for _, overload := range x.Commands[i].Overloads {
+				for j, param := range overload.Parameters {
+					if param.Enum.Dynamic || len(param.Enum.Options) != 0 {
+						overload.Parameters[j].Enum.Type += strconv.Itoa(n)
+						n++
+					}
+				}
+			}
+		}
+		for _, command := range x.Commands {
+			for _, alias := range command.Aliases {
+				options = append(options, [2]string{command.Name + "Aliases", alias})
+			}
+			for _, overload := range command.Overloads {
+				for _, param := range overload.Parameters {
+					for _, option := range param.Enum.Options {
+						if !param.Enum.Dynamic {
+							options = append(options, [2]string{param.Enum.Type, option})
+						}
+					}
+				}
+			}
+		}
+		// The type of parameters with an enum or suffix holds the offset of it, which depends on the other
+		// commands in the packet.
+		_, enumIndices := x.enums()
+		_, suffixIndices := x.suffixes()
+		_, dynamicIndices := x.dynamicEnums()
+		for _, command := range x.Commands {
+			for _, overload := range command.Overloads {
+				for j, param := range overload.Parameters {
+					switch {
+					case param.Enum.Dynamic:
+						overload.Parameters[j].Type = protocol.CommandArgSoftEnum | protocol.CommandArgValid | uint32(dynamicIndices[param.Enum.Type])
+					case len(param.Enum.Options) != 0:
+						overload.Parameters[j].Type = protocol.CommandArgEnum | protocol.CommandArgValid | uint32(enumIndices[param.Enum.Type])
+					case param.Suffix != "":
+						overload.Parameters[j].Type = protocol.CommandArgSuffixed | uint32(suffixIndices[param.Suffix])
+					}
+				}
+			}
+		}
+		if len(options) == 0 {
+			x.Constraints = nil
+		}
+		for i := range x.Constraints {
+			option := options[r.Intn(len(options))]
+			x.Constraints[i].EnumName, x.Constraints[i].EnumOption = option[0], option[1]
+		}
+	},
+	IDClientBoundMapItemData: func(r *rand.Rand, pk Packet) {
+		// Each group of fields is only encoded if the update flags have the flag for it.
+		x := pk.(*ClientBoundMapItemData)
+		x.UpdateFlags &= MapUpdateFlagInitialisation | MapUpdateFlagDecoration | MapUpdateFlagTexture
+		if x.UpdateFlags&MapUpdateFlagInitialisation == 0 {
+			x.MapsIncludedIn = nil
+		}
+		if x.UpdateFlags == 0 {
+			x.Scale = 0
+		}
+		if x.UpdateFlags&MapUpdateFlagDecoration == 0 {
+			x.TrackedObjects, x.Decorations = nil, nil
+		}
+		if x.UpdateFlags&MapUpdateFlagTexture == 0 {
+			x.Width, x.Height, x.XOffset, x.YOffset, x.Pixels = 0, 0, 0, 0, nil
+			return
+		}
+		x.Width, x.Height = int32(1+r.Intn(3)), int32(1+r.Intn(3))
+		x.Pixels = make([][]color.RGBA, x.Height)
+		for y := range x.Pixels {
+			x.Pixels[y] = make([]color.RGBA, x.Width)
+			for i := range x.Pixels[y] {
+				x.Pixels[y][i] = color.RGBA{R: uint8(r.Intn(256)), G: uint8(r.Intn(256)), B: uint8(r.Intn(256)), A: uint8(r.Intn(256))}
+			}
+		}
+	},
+}
+
+// randomRotation returns a random rotation that may be encoded as three byte angles without losing precision.
+func randomRotation(r *rand.Rand) mgl32.Vec3 {
+	return mgl32.Vec3{float32(r.Intn(256)) * (360.0 / 256.0), float32(r.Intn(256)) * (360.0 / 256.0), float32(r.Intn(256)) * (360.0 / 256.0)}
+}
+
+// randomGameRules returns a map of random game rules, which may only hold bools, uint32s and float32s.
+func randomGameRules(r *rand.Rand) map[string]interface{} {
+	values := []interface{}{r.Intn(2) == 1, uint32(r.Intn(100)), float32(r.Intn(100)) / 4}
+	rules := make(map[string]interface{})
+	for i, n := 0, r.Intn(4); i < n; i++ {
+		rules[string(rune('a'+r.Intn(26)))] = values[r.Intn(len(values))]
+	}
+	return rules
+}
@@ -0,0 +1,202 @@
+package packet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// fixture is a packet payload together with the packet that it holds.
+type fixture struct {
+	name    string
+	payload []byte
+	packet  Packet
+	// unordered specifies if the packet holds maps with more than one entry, which are encoded in a random
+	// order, so that encoding the packet does not reproduce the payload exactly.
+	unordered bool
+}
+
+// fixtures holds payloads of packets, assembled by hand field by field following the protocol format. They
+// are not recorded from a vanilla client or server. The packets that they decode into are written out
+// independently of the decoder.
+var fixtures = []fixture{
+	{
+		name:    "PlayStatus",
+		payload: join([]byte{0x00, 0x00, 0x00, 0x03}), // Status: big endian int32 3.
+		packet:  &PlayStatus{Status: PlayStatusPlayerSpawn},
+	},
+	{
+		name:    "SetTime",
+		payload: join([]byte{0xe0, 0x5d}), // Time: varint32 6000, zigzag encoded as 12000.
+		packet:  &SetTime{Time: 6000},
+	},
+	{
+		name: "Disconnect",
+		payload: join(
+			[]byte{0x00},                                     // HideDisconnectionScreen: false.
+			[]byte{0x20}, "You were kicked from the server.", // Message: length 32.
+		),
+		packet: &Disconnect{Message: "You were kicked from the server."},
+	},
+	{
+		name: "TextChat",
+		payload: join(
+			[]byte{0x01, 0x00},    // TextType: TextTypeChat, NeedsTranslation: false.
+			[]byte{0x05}, "Steve", // SourceName.
+			[]byte{0x0c}, "Hello world!", // Message.
+			[]byte{0x10}, "2535412345678901", // XUID.
+			[]byte{0x00}, // PlatformChatID: empty.
+		),
+		packet: &Text{TextType: TextTypeChat, SourceName: "Steve", Message: "Hello world!", XUID: "2535412345678901"},
+	},
+	{
+		name: "TextTranslation",
+		payload: join(
+			[]byte{0x02, 0x01},                         // TextType: TextTypeTranslation, NeedsTranslation: true.
+			[]byte{0x1a}, "%multiplayer.player.joined", // Message.
+			[]byte{0x01},          // Parameters: 1 parameter.
+			[]byte{0x05}, "Steve", // Parameters[0].
+			[]byte{0x00, 0x00}, // XUID and PlatformChatID: empty.
+		),
+		packet: &Text{TextType: TextTypeTranslation, NeedsTranslation: true, Message: "%multiplayer.player.joined", Parameters: []string{"Steve"}},
+	},
+	{
+		name: "MovePlayer",
+		payload: join(
+			[]byte{0x01}, // EntityRuntimeID: 1.
+			[]byte{0x00, 0x00, 0x48, 0x41, 0x71, 0x3d, 0x83, 0x42, 0x00, 0x00, 0x21, 0xc2}, // Position: 12.5, 65.62, -40.25.
+			[]byte{0x00, 0x00, 0x48, 0x41}, // Pitch: 12.5.
+			[]byte{0x00, 0x00, 0x87, 0x43}, // Yaw: 270.
+			[]byte{0x00, 0x00, 0x87, 0x43}, // HeadYaw: 270.
+			[]byte{0x00, 0x01, 0x00},       // Mode: MoveModeNormal, OnGround: true, RiddenEntityRuntimeID: 0.
+		),
+		packet: &MovePlayer{EntityRuntimeID: 1, Position: mgl32.Vec3{12.5, 65.62, -40.25}, Pitch: 12.5, Yaw: 270, HeadYaw: 270, OnGround: true},
+	},
+	{
+		name: "MovePlayerTeleport",
+		payload: join(
+			[]byte{0x01}, // EntityRuntimeID: 1.
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc8, 0x42, 0x00, 0x00, 0x00, 0x00}, // Position: 0, 100, 0.
+			make([]byte, 12),               // Pitch, Yaw and HeadYaw: 0.
+			[]byte{0x02, 0x00, 0x00},       // Mode: MoveModeTeleport, OnGround: false, RiddenEntityRuntimeID: 0.
+			[]byte{0x02, 0x00, 0x00, 0x00}, // TeleportCause: little endian int32 2.
+			[]byte{0x3f, 0x00, 0x00, 0x00}, // TeleportSourceEntityType: little endian int32 63.
+		),
+		packet: &MovePlayer{EntityRuntimeID: 1, Position: mgl32.Vec3{0, 100, 0}, Mode: MoveModeTeleport, TeleportCause: 2, TeleportSourceEntityType: 63},
+	},
+	{
+		name: "InventorySlot",
+		payload: join(
+			[]byte{0x00, 0x03},                         // WindowID: 0, Slot: 3.
+			[]byte{0x22},                               // NewItem.StackNetworkID: varint32 17.
+			[]byte{0x98, 0x04},                         // NewItem.Stack.NetworkID: varint32 268.
+			[]byte{0x02},                               // Metadata value and count: varint32 (0 << 8) | 1.
+			[]byte{0xff, 0xff},                         // User data marker: -1, so that NBT follows.
+			[]byte{0x01},                               // NBT version: 1.
+			[]byte{0x0a, 0x00},                         // Compound tag with an empty name.
+			[]byte{0x03, 0x06}, "Damage", []byte{0x18}, // Int tag named Damage: varint32 12.
+			[]byte{0x00},       // End of the compound tag.
+			[]byte{0x00, 0x00}, // CanBePlacedOn and CanBreak: no blocks.
+		),
+		packet: &InventorySlot{Slot: 3, NewItem: protocol.ItemInstance{
+			StackNetworkID: 17,
+			Stack: protocol.ItemStack{
+				ItemType: protocol.ItemType{NetworkID: 268},
+				Count:    1,
+				NBTData:  map[string]interface{}{"Damage": int32(12)},
+			},
+		}},
+	},
+	{
+		name: "AddActor",
+		payload: join(
+			[]byte{0x17},                     // EntityUniqueID: varint64 -12.
+			[]byte{0x0c},                     // EntityRuntimeID: 12.
+			[]byte{0x10}, "minecraft:zombie", // EntityType.
+			[]byte{0x00, 0x00, 0x90, 0x40, 0x00, 0x00, 0x80, 0x42, 0x00, 0x00, 0x90, 0x40}, // Position: 4.5, 64, 4.5.
+			make([]byte, 12),                 // Velocity: 0, 0, 0.
+			[]byte{0x00, 0x00, 0x00, 0x00},   // Pitch: 0.
+			[]byte{0x00, 0x00, 0xb4, 0x42},   // Yaw: 90.
+			[]byte{0x00, 0x00, 0xb4, 0x42},   // HeadYaw: 90.
+			[]byte{0x01},                     // Attributes: 1 attribute.
+			[]byte{0x10}, "minecraft:health", // Attributes[0].Name.
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xa0, 0x41, 0x00, 0x00, 0xa0, 0x41}, // Min: 0, Value: 20, Max: 20.
+			[]byte{0x04},                               // EntityMetadata: 4 entries.
+			[]byte{0x00, 0x07, 0x00},                   // Key 0, EntityDataInt64: 0.
+			[]byte{0x04, 0x04, 0x00},                   // Key 4, EntityDataString: empty.
+			[]byte{0x26, 0x03, 0x9a, 0x99, 0x19, 0x3f}, // Key 38, EntityDataFloat32: 0.6.
+			[]byte{0x27, 0x03, 0x33, 0x33, 0xf3, 0x3f}, // Key 39, EntityDataFloat32: 1.9.
+			[]byte{0x00},                               // EntityLinks: no links.
+		),
+		packet: &AddActor{
+			EntityUniqueID:  -12,
+			EntityRuntimeID: 12,
+			EntityType:      "minecraft:zombie",
+			Position:        mgl32.Vec3{4.5, 64, 4.5},
+			Yaw:             90,
+			HeadYaw:         90,
+			Attributes:      []protocol.Attribute{{Name: "minecraft:health", Value: 20, Max: 20}},
+			EntityMetadata:  map[uint32]interface{}{0: int64(0), 4: "", 38: float32(0.6), 39: float32(1.9)},
+		},
+		unordered: true,
+	},
+}
+
+// join concatenates the byte slices and strings passed into a single byte slice.
+func join(parts ...interface{}) []byte {
+	buf := bytes.NewBuffer(nil)
+	for _, part := range parts {
+		switch part := part.(type) {
+		case []byte:
+			buf.Write(part)
+		case string:
+			buf.WriteString(part)
+		}
+	}
+	return buf.Bytes()
+}
+
+// TestFixtures tests that the payloads of fixtures decode into their packets without bytes left, and that
+// encoding the packets produces the payloads again.
+func TestFixtures(t *testing.T) {
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			pk := newPacket(f.packet.ID())
+			buf := protocol.NewReader(bytes.NewBuffer(f.payload), protocol.DecodeLimits{})
+			if err := pk.Unmarshal(buf); err != nil {
+				t.Fatalf("error decoding payload into %T: %v", pk, err)
+			}
+			if buf.Len() != 0 {
+				t.Fatalf("%v unread bytes left after decoding %T", buf.Len(), pk)
+			}
+			if d := diff(reflect.ValueOf(f.packet).Elem(), reflect.ValueOf(pk).Elem(), f.name); d != "" {
+				t.Fatalf("payload decoded into unexpected packet: %v\n%v", d, Dump(pk))
+			}
+
+			buf.Reset()
+			f.packet.Marshal(buf.Buffer)
+			if f.unordered {
+				// The entries of maps are encoded in a random order, so we can only check that the payload
+				// encoded has the same length and decodes into the same packet.
+				decoded := newPacket(f.packet.ID())
+				if len(buf.Bytes()) != len(f.payload) {
+					t.Fatalf("encoding %T produced %v bytes, expected %v", pk, buf.Len(), len(f.payload))
+				}
+				if err := decoded.Unmarshal(protocol.NewReader(buf.Buffer, protocol.DecodeLimits{})); err != nil {
+					t.Fatalf("error decoding encoded %T: %v", pk, err)
+				}
+				if d := diff(reflect.ValueOf(f.packet).Elem(), reflect.ValueOf(decoded).Elem(), f.name); d != "" {
+					t.Fatalf("encoded packet decoded into unexpected packet: %v", d)
+				}
+				return
+			}
+			if !bytes.Equal(buf.Bytes(), f.payload) {
+				t.Fatalf("encoding %T produced\n%x\nbut expected\n%x", pk, buf.Bytes(), f.payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package packet
+
+import (
+	"bytes"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// fuzzSeedsPerPacket is the amount of encoded random packets added to the seed corpus of FuzzUnmarshal for
+// every packet in the pool.
+const fuzzSeedsPerPacket = 4
+
+// maxFuzzAlloc returns the maximum amount of bytes that decoding a packet from data of the length passed may
+// allocate. Decoding may allocate more than the length of the data, for example for maps and slices of
+// structs, but the amount allocated must be proportional to it.
+func maxFuzzAlloc(n int) uint64 {
+	return 1<<16 + uint64(n)*256
+}
+
+// FuzzUnmarshal decodes random data into the packets of NewPool, looking for panics and for allocations that
+// are out of proportion to the size of the data. Packets decoded successfully are encoded again, looking for
+// panics while encoding them. The packet that the data is decoded into is selected by the index passed into
+// the sorted IDs of the pool, modulo the amount of packets, so that every index selects a packet.
+// The fuzzer may be run using:
+//
+//	go test -run=^$ -fuzz=FuzzUnmarshal ./minecraft/protocol/packet
+//
+// Fuzz tests were added in Go 1.18, so this file is only built by Go 1.18 and later, while the rest of the
+// module builds with Go 1.13.
+func FuzzUnmarshal(f *testing.F) {
+	ids := poolIDs()
+	for i, id := range ids {
+		r := rand.New(rand.NewSource(int64(id)))
+		for j := 0; j < fuzzSeedsPerPacket; j++ {
+			buf := bytes.NewBuffer(nil)
+			randomPacket(r, id).Marshal(buf)
+			f.Add(uint32(i), buf.Bytes())
+		}
+	}
+	f.Fuzz(func(t *testing.T, index uint32, data []byte) {
+		pk := newPacket(ids[index%uint32(len(ids))])
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := pk.Unmarshal(protocol.NewReader(bytes.NewBuffer(data), protocol.DecodeLimits{}))
+		runtime.ReadMemStats(&after)
+
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > maxFuzzAlloc(len(data)) {
+			t.Fatalf("decoding %v bytes into %T allocated %v bytes", len(data), pk, allocated)
+		}
+		if err == nil {
+			// A packet that was decoded successfully holds only valid values, so it must encode again without
+			// panicking.
+			pk.Marshal(bytes.NewBuffer(nil))
+		}
+	})
+}
@@ -32,7 +32,7 @@ func (pk *GUIDataPickItem) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *GUIDataPickItem) Unmarshal(buf *bytes.Buffer) error {
+func (pk *GUIDataPickItem) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.ItemName),
 		protocol.String(buf, &pk.ItemEffects),
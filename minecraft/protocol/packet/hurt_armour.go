@@ -29,7 +29,7 @@ func (pk *HurtArmour) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *HurtArmour) Unmarshal(buf *bytes.Buffer) error {
+func (pk *HurtArmour) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.Cause),
 		protocol.Varint32(buf, &pk.Damage),
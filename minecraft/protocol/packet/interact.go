@@ -48,7 +48,7 @@ func (pk *Interact) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Interact) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Interact) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
 		protocol.Varuint64(buf, &pk.TargetEntityRuntimeID),
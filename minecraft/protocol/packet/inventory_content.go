@@ -32,11 +32,11 @@ func (pk *InventoryContent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *InventoryContent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *InventoryContent) Unmarshal(buf *protocol.Reader) error {
 	var length uint32
 	if err := chainErr(
 		protocol.Varuint32(buf, &pk.WindowID),
-		protocol.Varuint32(buf, &length),
+		protocol.Count(buf, &length, "InventoryContent item"),
 	); err != nil {
 		return err
 	}
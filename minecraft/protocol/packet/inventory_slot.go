@@ -33,7 +33,7 @@ func (pk *InventorySlot) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *InventorySlot) Unmarshal(buf *bytes.Buffer) error {
+func (pk *InventorySlot) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.WindowID),
 		protocol.Varuint32(buf, &pk.Slot),
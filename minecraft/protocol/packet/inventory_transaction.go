@@ -83,13 +83,13 @@ func (pk *InventoryTransaction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *InventoryTransaction) Unmarshal(buf *bytes.Buffer) error {
+func (pk *InventoryTransaction) Unmarshal(buf *protocol.Reader) error {
 	var length, transactionType uint32
 	if err := protocol.Varint32(buf, &pk.LegacyRequestID); err != nil {
 		return err
 	}
 	if pk.LegacyRequestID != 0 {
-		if err := protocol.Varuint32(buf, &length); err != nil {
+		if err := protocol.Count(buf, &length, "InventoryTransaction legacy set item slot"); err != nil {
 			return err
 		}
 		pk.LegacySetItemSlots = make([]protocol.LegacySetItemSlot, length)
@@ -102,7 +102,7 @@ func (pk *InventoryTransaction) Unmarshal(buf *bytes.Buffer) error {
 	if err := chainErr(
 		protocol.Varuint32(buf, &transactionType),
 		binary.Read(buf, binary.LittleEndian, &pk.HasNetworkIDs),
-		protocol.Varuint32(buf, &length),
+		protocol.Count(buf, &length, "InventoryTransaction action"),
 	); err != nil {
 		return err
 	}
@@ -23,6 +23,6 @@ func (pk *ItemFrameDropItem) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ItemFrameDropItem) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ItemFrameDropItem) Unmarshal(buf *protocol.Reader) error {
 	return protocol.UBlockPosition(buf, &pk.Position)
 }
@@ -29,9 +29,9 @@ func (pk *ItemStackRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ItemStackRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ItemStackRequest) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "ItemStackRequest request"); err != nil {
 		return err
 	}
 	if count > 64 {
@@ -30,9 +30,9 @@ func (pk *ItemStackResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ItemStackResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ItemStackResponse) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "ItemStackResponse response"); err != nil {
 		return err
 	}
 	pk.Responses = make([]protocol.ItemStackResponse, count)
@@ -0,0 +1,589 @@
+package packet
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalJSON encodes the packet passed to JSON. The JSON produced is an object holding the ID of the packet,
+// the name of its type and the packet itself:
+//
+//	{"ID": 9, "Name": "Text", "Packet": {"TextType": 0, "NeedsTranslation": false, ...}}
+//
+// Structs are encoded as objects with their fields in order, byte slices as base64 strings and maps as
+// objects sorted by their keys. Values held by interfaces, such as the values of NBT maps, entity metadata and
+// the recipes of a CraftingData packet, are encoded as an object holding the name of their type and their
+// value, such as {"Type": "int32", "Value": 5}, so that UnmarshalJSON can decode them into the same type.
+// Floats that are NaN or infinite are encoded as the strings "NaN", "+Inf" and "-Inf".
+func MarshalJSON(pk Packet) ([]byte, error) {
+	if pk == nil {
+		return nil, fmt.Errorf("error encoding packet: packet is nil")
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	buf.WriteString(`{"ID":`)
+	buf.WriteString(strconv.FormatUint(uint64(pk.ID()), 10))
+	buf.WriteString(`,"Name":`)
+	writeJSONString(buf, packetName(pk))
+	buf.WriteString(`,"Packet":`)
+	if err := encodeJSON(buf, reflect.ValueOf(pk)); err != nil {
+		return nil, fmt.Errorf("error encoding packet %T: %v", pk, err)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes JSON produced by MarshalJSON into a new packet of the type found in the Pool passed for
+// the ID held by the JSON. If the pool is nil, NewPool() is used. If the pool holds no packet with the ID, an
+// *Unknown packet is returned.
+// Fields absent in the JSON are left zero, and fields that the packet does not have result in an error.
+func UnmarshalJSON(data []byte, pool Pool) (Packet, error) {
+	if pool == nil {
+		pool = NewPool()
+	}
+	var v struct {
+		ID     uint32
+		Name   string
+		Packet json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("error decoding packet JSON: %v", err)
+	}
+	var pk Packet = &Unknown{PacketID: v.ID}
+	if poolPk, ok := pool[v.ID]; ok {
+		pk = reflect.New(reflect.TypeOf(poolPk).Elem()).Interface().(Packet)
+	}
+	if v.Name != "" && v.Name != packetName(pk) {
+		return nil, fmt.Errorf("error decoding packet JSON: name %v does not match packet %v with ID %v", v.Name, packetName(pk), v.ID)
+	}
+	if len(v.Packet) == 0 {
+		return nil, fmt.Errorf("error decoding packet JSON: packet %v has no content", v.Name)
+	}
+	var content interface{}
+	dec := json.NewDecoder(bytes.NewReader(v.Packet))
+	dec.UseNumber()
+	if err := dec.Decode(&content); err != nil {
+		return nil, fmt.Errorf("error decoding packet JSON: %v", err)
+	}
+	if err := decodeJSON(reflect.ValueOf(pk).Elem(), content); err != nil {
+		return nil, fmt.Errorf("error decoding packet %T from JSON: %v", pk, err)
+	}
+	return pk, nil
+}
+
+// packetName returns the name of the type of the packet passed, such as 'StartGame'.
+func packetName(pk Packet) string {
+	t := reflect.TypeOf(pk)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// jsonTypes holds the named types, by their names, that values held by interfaces in packets may have. Types
+// that are not named, such as map[string]interface{} and [4]int32, are decoded from their names directly.
+var jsonTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		protocol.BlockPos{},
+		mgl32.Vec2{},
+		mgl32.Vec3{},
+
+		&protocol.ShapelessRecipe{},
+		&protocol.ShapedRecipe{},
+		&protocol.FurnaceRecipe{},
+		&protocol.FurnaceDataRecipe{},
+		&protocol.MultiRecipe{},
+		&protocol.ShulkerBoxRecipe{},
+		&protocol.ShapelessChemistryRecipe{},
+		&protocol.ShapedChemistryRecipe{},
+
+		&protocol.NormalTransactionData{},
+		&protocol.MismatchTransactionData{},
+		&protocol.UseItemTransactionData{},
+		&protocol.UseItemOnEntityTransactionData{},
+		&protocol.ReleaseItemTransactionData{},
+
+		&protocol.TakeStackRequestAction{},
+		&protocol.PlaceStackRequestAction{},
+		&protocol.SwapStackRequestAction{},
+		&protocol.DropStackRequestAction{},
+		&protocol.DestroyStackRequestAction{},
+		&protocol.ConsumeStackRequestAction{},
+		&protocol.CreateStackRequestAction{},
+		&protocol.LabTableCombineStackRequestAction{},
+		&protocol.BeaconPaymentStackRequestAction{},
+		&protocol.CraftRecipeStackRequestAction{},
+		&protocol.AutoCraftRecipeStackRequestAction{},
+		&protocol.CraftCreativeStackRequestAction{},
+		&protocol.CraftNonImplementedStackRequestAction{},
+		&protocol.CraftResultsDeprecatedStackRequestAction{},
+	} {
+		jsonTypes[reflect.TypeOf(v).String()] = reflect.TypeOf(v)
+	}
+}
+
+// basicTypes holds the types of the basic kinds that values held by interfaces may have, by their names.
+var basicTypes = map[string]reflect.Type{
+	"bool":         reflect.TypeOf(false),
+	"uint8":        reflect.TypeOf(uint8(0)),
+	"uint16":       reflect.TypeOf(uint16(0)),
+	"uint32":       reflect.TypeOf(uint32(0)),
+	"uint64":       reflect.TypeOf(uint64(0)),
+	"int8":         reflect.TypeOf(int8(0)),
+	"int16":        reflect.TypeOf(int16(0)),
+	"int32":        reflect.TypeOf(int32(0)),
+	"int64":        reflect.TypeOf(int64(0)),
+	"float32":      reflect.TypeOf(float32(0)),
+	"float64":      reflect.TypeOf(float64(0)),
+	"string":       reflect.TypeOf(""),
+	"interface {}": reflect.TypeOf((*interface{})(nil)).Elem(),
+}
+
+// typeByName returns the type with the name passed, as returned by reflect.Type.String. The name may be that
+// of a basic type, a type found in jsonTypes, or a pointer, slice, array or map of those.
+func typeByName(name string) (reflect.Type, error) {
+	if t, ok := basicTypes[name]; ok {
+		return t, nil
+	}
+	if t, ok := jsonTypes[name]; ok {
+		return t, nil
+	}
+	switch {
+	case strings.HasPrefix(name, "*"):
+		elem, err := typeByName(name[1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(elem), nil
+	case strings.HasPrefix(name, "[]"):
+		elem, err := typeByName(name[2:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case strings.HasPrefix(name, "["):
+		end := strings.IndexByte(name, ']')
+		if end == -1 {
+			break
+		}
+		n, err := strconv.Atoi(name[1:end])
+		if err != nil || n < 0 {
+			break
+		}
+		elem, err := typeByName(name[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ArrayOf(n, elem), nil
+	case strings.HasPrefix(name, "map["):
+		end := strings.IndexByte(name, ']')
+		if end == -1 {
+			break
+		}
+		key, err := typeByName(name[4:end])
+		if err != nil {
+			return nil, err
+		}
+		elem, err := typeByName(name[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, elem), nil
+	}
+	return nil, fmt.Errorf("unknown type %v", name)
+}
+
+// encodeJSON encodes the reflect.Value passed to JSON and writes it to the buffer.
+func encodeJSON(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && t.Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		writeJSONString(buf, string(text))
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			buf.WriteString(`"NaN"`)
+		case math.IsInf(f, 1):
+			buf.WriteString(`"+Inf"`)
+		case math.IsInf(f, -1):
+			buf.WriteString(`"-Inf"`)
+		default:
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, t.Bits()))
+		}
+	case reflect.String:
+		writeJSONString(buf, v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeJSON(buf, v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteString(`{"Type":`)
+		writeJSONString(buf, v.Elem().Type().String())
+		buf.WriteString(`,"Value":`)
+		if err := encodeJSON(buf, v.Elem()); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			writeJSONString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		keys, err := sortedKeys(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, k.s)
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, v.MapIndex(k.v)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		if err := encodeJSONFields(buf, v, &first); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode value of type %v", t)
+	}
+	return nil
+}
+
+// encodeJSONFields encodes the exported fields of the struct passed as the fields of a JSON object. The fields
+// of embedded structs are encoded as if they were fields of the struct itself.
+func encodeJSONFields(buf *bytes.Buffer, v reflect.Value, first *bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := encodeJSONFields(buf, v.Field(i), first); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported field.
+			continue
+		}
+		if !*first {
+			buf.WriteByte(',')
+		}
+		*first = false
+		writeJSONString(buf, field.Name)
+		buf.WriteByte(':')
+		if err := encodeJSON(buf, v.Field(i)); err != nil {
+			return fmt.Errorf("%v: %v", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// writeJSONString writes the string passed to the buffer as a JSON string.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+// mapKey is a key of a map along with its string representation.
+type mapKey struct {
+	v reflect.Value
+	s string
+}
+
+// sortedKeys returns the keys of the map passed with their string representations, sorted by their values.
+// Only maps with string and integer keys are supported.
+func sortedKeys(m reflect.Value) ([]mapKey, error) {
+	keys := make([]mapKey, 0, m.Len())
+	kind := m.Type().Key().Kind()
+	for _, k := range m.MapKeys() {
+		switch kind {
+		case reflect.String:
+			keys = append(keys, mapKey{v: k, s: k.String()})
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			keys = append(keys, mapKey{v: k, s: strconv.FormatInt(k.Int(), 10)})
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			keys = append(keys, mapKey{v: k, s: strconv.FormatUint(k.Uint(), 10)})
+		default:
+			return nil, fmt.Errorf("cannot encode map with keys of type %v", m.Type().Key())
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		switch kind {
+		case reflect.String:
+			return keys[i].s < keys[j].s
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return keys[i].v.Int() < keys[j].v.Int()
+		}
+		return keys[i].v.Uint() < keys[j].v.Uint()
+	})
+	return keys, nil
+}
+
+// decodeJSON decodes the JSON value passed, as decoded by a json.Decoder using numbers, into the settable
+// reflect.Value passed.
+func decodeJSON(v reflect.Value, data interface{}) error {
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected string for %v, got %T", t, data)
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool for %v, got %T", t, data)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number for %v, got %T", t, data)
+		}
+		i, err := strconv.ParseInt(string(n), 10, t.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v %v", t, n)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected number for %v, got %T", t, data)
+		}
+		u, err := strconv.ParseUint(string(n), 10, t.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v %v", t, n)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var s string
+		switch data := data.(type) {
+		case json.Number:
+			s = string(data)
+		case string:
+			s = data
+			if s != "NaN" && s != "+Inf" && s != "-Inf" {
+				return fmt.Errorf("invalid %v %q", t, s)
+			}
+		default:
+			return fmt.Errorf("expected number for %v, got %T", t, data)
+		}
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v %v", t, s)
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected string for %v, got %T", t, data)
+		}
+		v.SetString(s)
+	case reflect.Ptr:
+		if data == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := decodeJSON(elem.Elem(), data); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Interface:
+		if data == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %v, got %T", t, data)
+		}
+		name, ok := m["Type"].(string)
+		if !ok {
+			return fmt.Errorf("expected type name for %v", t)
+		}
+		valueType, err := typeByName(name)
+		if err != nil {
+			return err
+		}
+		if !valueType.Implements(t) {
+			return fmt.Errorf("type %v does not implement %v", valueType, t)
+		}
+		value := reflect.New(valueType).Elem()
+		if err := decodeJSON(value, m["Value"]); err != nil {
+			return err
+		}
+		v.Set(value)
+	case reflect.Slice:
+		if data == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			s, ok := data.(string)
+			if !ok {
+				return fmt.Errorf("expected base64 string for %v, got %T", t, data)
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return fmt.Errorf("invalid base64 for %v: %v", t, err)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		a, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array for %v, got %T", t, data)
+		}
+		v.Set(reflect.MakeSlice(t, len(a), len(a)))
+		for i, elem := range a {
+			if err := decodeJSON(v.Index(i), elem); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		a, ok := data.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array for %v, got %T", t, data)
+		}
+		if len(a) != v.Len() {
+			return fmt.Errorf("expected %v elements for %v, got %v", v.Len(), t, len(a))
+		}
+		for i, elem := range a {
+			if err := decodeJSON(v.Index(i), elem); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if data == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %v, got %T", t, data)
+		}
+		v.Set(reflect.MakeMapWithSize(t, len(m)))
+		for k, elem := range m {
+			key := reflect.New(t.Key()).Elem()
+			if err := decodeJSON(key, mapKeyData(t.Key(), k)); err != nil {
+				return err
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := decodeJSON(value, elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %v, got %T", t, data)
+		}
+		fields := make(map[string]reflect.Value)
+		structFields(v, fields)
+		for name, elem := range m {
+			field, ok := fields[name]
+			if !ok {
+				return fmt.Errorf("unknown field %v in %v", name, t)
+			}
+			if err := decodeJSON(field, elem); err != nil {
+				return fmt.Errorf("%v: %v", name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot decode value of type %v", t)
+	}
+	return nil
+}
+
+// mapKeyData returns the JSON value of a map key, which is always a string in JSON, as it would be decoded if
+// the key were a JSON value of the type passed.
+func mapKeyData(t reflect.Type, key string) interface{} {
+	if t.Kind() == reflect.String {
+		return key
+	}
+	return json.Number(key)
+}
+
+// structFields adds the exported fields of the struct passed to the map, including those of embedded structs.
+func structFields(v reflect.Value, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			structFields(v.Field(i), fields)
+			continue
+		}
+		if field.PkgPath == "" {
+			fields[field.Name] = v.Field(i)
+		}
+	}
+}
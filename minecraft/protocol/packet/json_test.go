@@ -0,0 +1,143 @@
+package packet
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// jsonRoundTripIterations is the amount of packets with random values that TestJSONRoundTrip encodes to and
+// decodes from JSON for every packet in the pool.
+const jsonRoundTripIterations = 10
+
+// jsonRoundTrip encodes the packet passed to JSON and decodes it into a new packet.
+func jsonRoundTrip(t *testing.T, pk Packet) Packet {
+	data, err := MarshalJSON(pk)
+	if err != nil {
+		t.Fatalf("error encoding packet to JSON: %v\n%v", err, Dump(pk))
+	}
+	decoded, err := UnmarshalJSON(data, nil)
+	if err != nil {
+		t.Fatalf("error decoding packet from JSON: %v\n%s", err, data)
+	}
+	return decoded
+}
+
+// TestJSONRoundTrip tests for every packet in NewPool that a packet filled with random values, including
+// values of every type that the interfaces in packets may hold, decodes from JSON into the same packet.
+func TestJSONRoundTrip(t *testing.T) {
+	for _, id := range poolIDs() {
+		pk := NewPool()[id]
+		t.Run(packetName(pk), func(t *testing.T) {
+			r := rand.New(rand.NewSource(int64(id)))
+			for i := 0; i < jsonRoundTripIterations; i++ {
+				pk := randomPacket(r, id)
+				decoded := jsonRoundTrip(t, pk)
+				if d := diff(reflect.ValueOf(pk).Elem(), reflect.ValueOf(decoded).Elem(), packetName(pk)); d != "" {
+					t.Fatalf("packet changed in JSON round trip: %v\n%v\nbecame\n%v", d, Dump(pk), Dump(decoded))
+				}
+			}
+		})
+	}
+}
+
+// TestJSONTypes tests that every type that the interfaces in packets may hold is registered in jsonTypes,
+// so that values of the type may be decoded from JSON.
+func TestJSONTypes(t *testing.T) {
+	for iface, types := range interfaceTypes {
+		for _, v := range types {
+			name := reflect.TypeOf(v).String()
+			if typ, err := typeByName(name); err != nil || typ != reflect.TypeOf(v) {
+				t.Errorf("type %v implementing %v is not registered in jsonTypes", name, iface)
+			}
+		}
+	}
+}
+
+func TestJSONInterfaceValues(t *testing.T) {
+	nbtData := map[string]interface{}{
+		"byte":       byte(1),
+		"int16":      int16(-2),
+		"int32":      int32(3),
+		"int64":      int64(-4),
+		"float32":    float32(0.5),
+		"float64":    1.25,
+		"string":     "value",
+		"byteArray":  [3]byte{1, 2, 3},
+		"int32Array": [2]int32{-1, 1},
+		"int64Array": [2]int64{-1, 1},
+		"list":       []interface{}{int32(1), int32(2)},
+		"compound":   map[string]interface{}{"nested": []interface{}{map[string]interface{}{"x": int32(5)}}},
+	}
+	for _, pk := range []Packet{
+		&BlockActorData{Position: protocol.BlockPos{1, 2, 3}, NBTData: nbtData},
+		&AddActor{EntityMetadata: map[uint32]interface{}{
+			0: byte(1),
+			1: int16(2),
+			2: int32(3),
+			3: float32(4),
+			4: "name",
+			5: map[string]interface{}{"x": int32(1)},
+			6: protocol.BlockPos{1, 2, 3},
+			7: int64(5),
+			8: mgl32.Vec3{1, 2, 3},
+		}},
+	} {
+		decoded := jsonRoundTrip(t, pk)
+		if d := diff(reflect.ValueOf(pk).Elem(), reflect.ValueOf(decoded).Elem(), packetName(pk)); d != "" {
+			t.Errorf("packet changed in JSON round trip: %v\n%v\nbecame\n%v", d, Dump(pk), Dump(decoded))
+		}
+	}
+
+	data, _ := MarshalJSON(&BlockActorData{NBTData: map[string]interface{}{"x": int32(5)}})
+	if !bytes.Contains(data, []byte(`"x":{"Type":"int32","Value":5}`)) {
+		t.Errorf("interface value not encoded with its type: %s", data)
+	}
+}
+
+func TestJSONFloats(t *testing.T) {
+	pk := &MovePlayer{Position: mgl32.Vec3{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1))}, Pitch: 1.5}
+	data, err := MarshalJSON(pk)
+	if err != nil {
+		t.Fatalf("error encoding packet to JSON: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"Position":["NaN","+Inf","-Inf"]`)) {
+		t.Errorf("NaN and infinite floats not encoded as strings: %s", data)
+	}
+	decoded, err := UnmarshalJSON(data, nil)
+	if err != nil {
+		t.Fatalf("error decoding packet from JSON: %v", err)
+	}
+	pos := decoded.(*MovePlayer).Position
+	if !math.IsNaN(float64(pos[0])) || !math.IsInf(float64(pos[1]), 1) || !math.IsInf(float64(pos[2]), -1) || decoded.(*MovePlayer).Pitch != 1.5 {
+		t.Errorf("decoded position %v and pitch %v, expected [NaN +Inf -Inf] and 1.5", pos, decoded.(*MovePlayer).Pitch)
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"name mismatch":    `{"ID":9,"Name":"Disconnect","Packet":{}}`,
+		"unknown field":    `{"ID":9,"Name":"Text","Packet":{"Unknown":1}}`,
+		"no content":       `{"ID":9,"Name":"Text"}`,
+		"unknown type":     `{"ID":56,"Name":"BlockActorData","Packet":{"NBTData":{"x":{"Type":"complex64","Value":1}}}}`,
+		"wrong interface":  `{"ID":52,"Name":"CraftingData","Packet":{"Recipes":[{"Type":"int32","Value":1}]}}`,
+		"invalid float":    `{"ID":19,"Name":"MovePlayer","Packet":{"Pitch":"Infinity"}}`,
+		"integer overflow": `{"ID":9,"Name":"Text","Packet":{"TextType":256}}`,
+	} {
+		if _, err := UnmarshalJSON([]byte(data), nil); err == nil {
+			t.Errorf("%v: expected error decoding %v", name, data)
+		}
+	}
+
+	data, _ := MarshalJSON(&Unknown{PacketID: 0xfff, Payload: []byte{1, 2}})
+	pk, err := UnmarshalJSON(data, nil)
+	if err != nil || !strings.Contains(Dump(pk), "0x0102") {
+		t.Errorf("unknown packet not decoded from JSON: %v, %v", err, Dump(pk))
+	}
+}
@@ -39,7 +39,7 @@ func (pk *LabTable) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LabTable) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LabTable) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
 		protocol.BlockPosition(buf, &pk.Position),
@@ -36,7 +36,7 @@ func (pk *LecternUpdate) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LecternUpdate) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LecternUpdate) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.Page),
 		binary.Read(buf, binary.LittleEndian, &pk.PageCount),
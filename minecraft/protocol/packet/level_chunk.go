@@ -55,7 +55,7 @@ func (pk *LevelChunk) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LevelChunk) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LevelChunk) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varint32(buf, &pk.ChunkX),
 		protocol.Varint32(buf, &pk.ChunkZ),
@@ -66,7 +66,7 @@ func (pk *LevelChunk) Unmarshal(buf *bytes.Buffer) error {
 	}
 	if pk.CacheEnabled {
 		var count uint32
-		if err := protocol.Varuint32(buf, &count); err != nil {
+		if err := protocol.Count(buf, &count, "LevelChunk blob hash"); err != nil {
 			return err
 		}
 		pk.BlobHashes = make([]uint64, count)
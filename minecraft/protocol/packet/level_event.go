@@ -115,7 +115,7 @@ func (pk *LevelEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LevelEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LevelEvent) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.EventType),
 		protocol.Vec3(buf, &pk.Position),
@@ -29,7 +29,7 @@ func (pk *LevelEventGeneric) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LevelEventGeneric) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LevelEventGeneric) Unmarshal(buf *protocol.Reader) error {
 	if err := protocol.Varint32(buf, &pk.EventID); err != nil {
 		return err
 	}
@@ -328,7 +328,7 @@ func (pk *LevelSoundEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *LevelSoundEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *LevelSoundEvent) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.SoundType),
 		protocol.Vec3(buf, &pk.Position),
@@ -0,0 +1,81 @@
+package packet
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// TestDecodeLimits tests that packets decoded from a protocol.Reader fail to decode with the typed
+// errors of the protocol package if they exceed the limits passed, rather than allocating or panicking.
+func TestDecodeLimits(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	(&UpdateSoftEnum{Options: make([]string, 100)}).Marshal(buf)
+	data := buf.Bytes()
+
+	if err := (&UpdateSoftEnum{}).Unmarshal(protocol.NewReader(bytes.NewBuffer(data), protocol.DecodeLimits{MaxElements: 100})); err != nil {
+		t.Fatalf("error decoding packet within limits: %v", err)
+	}
+
+	var budgetErr protocol.ElementBudgetError
+	err := (&UpdateSoftEnum{}).Unmarshal(protocol.NewReader(bytes.NewBuffer(data), protocol.DecodeLimits{MaxElements: 99}))
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected protocol.ElementBudgetError decoding 100 elements with a maximum of 99, got %v", err)
+	}
+
+	// A count of 2^32-1 elements, without any data to back it up.
+	var limitErr protocol.LimitHitError
+	err = (&InventoryContent{}).Unmarshal(&protocol.Reader{Buffer: bytes.NewBuffer([]byte{0, 0xff, 0xff, 0xff, 0xff, 0x0f})})
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected protocol.LimitHitError decoding a count exceeding the data, got %v", err)
+	}
+
+	var panicErr protocol.DecodePanicError
+	err = protocol.Decode(protocol.NewReader(bytes.NewBuffer(nil), protocol.DecodeLimits{}), func() error {
+		panic("decoding failed")
+	})
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected protocol.DecodePanicError decoding with a panic, got %v", err)
+	}
+}
+
+// TestInvalidValues tests that values that could not be decoded by the other end fail to encode and decode
+// with a protocol.InvalidValueError, rather than panicking and reaching the recover of protocol.Decode.
+func TestInvalidValues(t *testing.T) {
+	var invalidErr protocol.InvalidValueError
+	var panicErr protocol.DecodePanicError
+
+	// An item instance with an empty stack must have a stack network ID of 0.
+	buf := bytes.NewBuffer(nil)
+	if err := protocol.WriteItemInst(buf, protocol.ItemInstance{StackNetworkID: 5}); !errors.As(err, &invalidErr) || buf.Len() != 0 {
+		t.Errorf("expected protocol.InvalidValueError and no data encoding an empty stack with a network ID, got %v and %v bytes", err, buf.Len())
+	}
+	_ = protocol.WriteVaruint32(buf, 0)
+	_ = protocol.WriteVaruint32(buf, 0)
+	_ = protocol.WriteVarint32(buf, 5)
+	_ = protocol.WriteVarint32(buf, 0)
+	r := protocol.NewReader(buf, protocol.DecodeLimits{})
+	err := protocol.Decode(r, func() error { return (&InventorySlot{}).Unmarshal(r) })
+	if !errors.As(err, &invalidErr) || errors.As(err, &panicErr) {
+		t.Errorf("expected protocol.InvalidValueError decoding an empty stack with a network ID, got %v", err)
+	}
+
+	// The data of a skin must match its dimensions.
+	skin := protocol.Skin{SkinImageWidth: 2, SkinImageHeight: 2, SkinData: make([]byte, 4)}
+	buf = bytes.NewBuffer(nil)
+	if err := protocol.WriteSerialisedSkin(buf, skin); !errors.As(err, &invalidErr) || buf.Len() != 0 {
+		t.Errorf("expected protocol.InvalidValueError and no data encoding a skin with invalid dimensions, got %v and %v bytes", err, buf.Len())
+	}
+	skin.SkinImageWidth, skin.SkinImageHeight = 1, 1
+	(&PlayerSkin{Skin: skin}).Marshal(buf)
+	data := buf.Bytes()
+	// The width of the skin follows the UUID, the empty skin ID and the empty skin resource patch.
+	data[18] = 2
+	r = protocol.NewReader(bytes.NewBuffer(data), protocol.DecodeLimits{})
+	err = protocol.Decode(r, func() error { return (&PlayerSkin{}).Unmarshal(r) })
+	if !errors.As(err, &invalidErr) || errors.As(err, &panicErr) {
+		t.Errorf("expected protocol.InvalidValueError decoding a skin with invalid dimensions, got %v", err)
+	}
+}
@@ -30,7 +30,7 @@ func (pk *Login) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Login) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Login) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.BigEndian, &pk.ClientProtocol),
 		protocol.ByteSlice(buf, &pk.ConnectionRequest),
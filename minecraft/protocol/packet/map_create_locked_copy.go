@@ -28,7 +28,7 @@ func (pk *MapCreateLockedCopy) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MapCreateLockedCopy) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MapCreateLockedCopy) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint64(buf, &pk.OriginalMapID),
 		protocol.Varint64(buf, &pk.NewMapID),
@@ -24,6 +24,6 @@ func (pk *MapInfoRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MapInfoRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MapInfoRequest) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint64(buf, &pk.MapID)
 }
@@ -39,7 +39,7 @@ func (pk *MobArmourEquipment) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MobArmourEquipment) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MobArmourEquipment) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Item(buf, &pk.Helmet),
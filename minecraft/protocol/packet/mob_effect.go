@@ -81,7 +81,7 @@ func (pk *MobEffect) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MobEffect) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MobEffect) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		binary.Read(buf, binary.LittleEndian, &pk.Operation),
@@ -42,7 +42,7 @@ func (pk *MobEquipment) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MobEquipment) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MobEquipment) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Item(buf, &pk.NewItem),
@@ -28,7 +28,7 @@ func (pk *ModalFormRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ModalFormRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ModalFormRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.FormID),
 		protocol.ByteSlice(buf, &pk.FormData),
@@ -31,7 +31,7 @@ func (pk *ModalFormResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ModalFormResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ModalFormResponse) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.FormID),
 		protocol.ByteSlice(buf, &pk.ResponseData),
@@ -43,7 +43,7 @@ func (pk *MoveActorAbsolute) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MoveActorAbsolute) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MoveActorAbsolute) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		binary.Read(buf, binary.LittleEndian, &pk.Flags),
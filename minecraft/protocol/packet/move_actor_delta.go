@@ -66,7 +66,7 @@ func (pk *MoveActorDelta) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MoveActorDelta) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MoveActorDelta) Unmarshal(buf *protocol.Reader) error {
 	pk.DeltaPosition = mgl32.Vec3{}
 	pk.Rotation = mgl32.Vec3{}
 
@@ -77,7 +77,7 @@ func (pk *MovePlayer) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MovePlayer) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MovePlayer) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Vec3(buf, &pk.Position),
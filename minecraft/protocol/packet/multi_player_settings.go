@@ -32,6 +32,6 @@ func (pk *MultiPlayerSettings) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *MultiPlayerSettings) Unmarshal(buf *bytes.Buffer) error {
+func (pk *MultiPlayerSettings) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.ActionType)
 }
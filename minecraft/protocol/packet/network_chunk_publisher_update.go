@@ -34,7 +34,7 @@ func (pk *NetworkChunkPublisherUpdate) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *NetworkChunkPublisherUpdate) Unmarshal(buf *bytes.Buffer) error {
+func (pk *NetworkChunkPublisherUpdate) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.BlockPosition(buf, &pk.Position),
 		protocol.Varuint32(buf, &pk.Radius),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // NetworkSettings is sent by the server to update a variety of network settings. These settings modify the
@@ -12,6 +14,12 @@ type NetworkSettings struct {
 	// packet is under this value, it is not compressed.
 	// When set to 0, all packets will be left uncompressed.
 	CompressionThreshold uint16
+	// CompressionAlgorithm is the ID of the Compression that batches are compressed with after the packet,
+	// such as the ID of FlateCompression, which is 0. The field is not part of the Minecraft protocol: It is
+	// only written if it is not 0, in which case the other end must be a gophertunnel connection with the
+	// Compression registered. Setting it to anything other than 0 breaks compatibility with Minecraft
+	// clients, which cannot read the packet with the field.
+	CompressionAlgorithm uint16
 }
 
 // ID ...
@@ -22,9 +30,19 @@ func (*NetworkSettings) ID() uint32 {
 // Marshal ...
 func (pk *NetworkSettings) Marshal(buf *bytes.Buffer) {
 	_ = binary.Write(buf, binary.LittleEndian, pk.CompressionThreshold)
+	if pk.CompressionAlgorithm != 0 {
+		_ = binary.Write(buf, binary.LittleEndian, pk.CompressionAlgorithm)
+	}
 }
 
 // Unmarshal ...
-func (pk *NetworkSettings) Unmarshal(buf *bytes.Buffer) error {
-	return binary.Read(buf, binary.LittleEndian, &pk.CompressionThreshold)
+func (pk *NetworkSettings) Unmarshal(buf *protocol.Reader) error {
+	if err := binary.Read(buf, binary.LittleEndian, &pk.CompressionThreshold); err != nil {
+		return err
+	}
+	pk.CompressionAlgorithm = 0
+	if buf.Len() == 0 {
+		return nil
+	}
+	return binary.Read(buf, binary.LittleEndian, &pk.CompressionAlgorithm)
 }
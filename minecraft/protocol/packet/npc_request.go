@@ -37,7 +37,7 @@ func (pk *NPCRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *NPCRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *NPCRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		binary.Read(buf, binary.LittleEndian, &pk.RequestType),
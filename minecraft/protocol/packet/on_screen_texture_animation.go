@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // OnScreenTextureAnimation is sent by the server to show a certain animation on the screen of the player.
@@ -24,6 +26,6 @@ func (pk *OnScreenTextureAnimation) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *OnScreenTextureAnimation) Unmarshal(buf *bytes.Buffer) error {
+func (pk *OnScreenTextureAnimation) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &pk.AnimationType)
 }
@@ -14,7 +14,7 @@ type Packet interface {
 	Marshal(buf *bytes.Buffer)
 	// Unmarshal decodes a serialised packet in buf into the Packet instance. The serialised packet passed
 	// into Unmarshal will not have a header in it.
-	Unmarshal(buf *bytes.Buffer) error
+	Unmarshal(buf *protocol.Reader) error
 }
 
 // Header is the header of a packet. It exists out of a single varuint32 which is composed of a packet ID and
@@ -31,7 +31,7 @@ func (header *Header) Write(buf *bytes.Buffer) error {
 }
 
 // Read reads a varuint32 from buf and sets the corresponding values to the Header.
-func (header *Header) Read(buf *bytes.Buffer) error {
+func (header *Header) Read(buf *protocol.Reader) error {
 	var value uint32
 	if err := protocol.Varuint32(buf, &value); err != nil {
 		return err
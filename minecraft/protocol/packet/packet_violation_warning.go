@@ -44,7 +44,7 @@ func (pk *PacketViolationWarning) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PacketViolationWarning) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PacketViolationWarning) Unmarshal(buf *protocol.Reader) error {
 	var t int32
 	err := chainErr(
 		protocol.Varint32(buf, &t),
@@ -35,7 +35,7 @@ func (pk *PhotoTransfer) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PhotoTransfer) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PhotoTransfer) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.PhotoName),
 		protocol.ByteSlice(buf, &pk.PhotoData),
@@ -37,7 +37,7 @@ func (pk *PlaySound) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlaySound) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlaySound) Unmarshal(buf *protocol.Reader) error {
 	b := protocol.BlockPos{}
 	if err := chainErr(
 		protocol.String(buf, &pk.SoundName),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 const (
@@ -34,6 +36,6 @@ func (pk *PlayStatus) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayStatus) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayStatus) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.BigEndian, &pk.Status)
 }
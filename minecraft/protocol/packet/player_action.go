@@ -65,7 +65,7 @@ func (pk *PlayerAction) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerAction) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerAction) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Varint32(buf, &pk.ActionType),
@@ -54,7 +54,7 @@ func (pk *PlayerArmourDamage) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerArmourDamage) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerArmourDamage) Unmarshal(buf *protocol.Reader) error {
 	pk.HelmetDamage, pk.ChestplateDamage, pk.LeggingsDamage, pk.BootsDamage = 0, 0, 0, 0
 	bitset, err := buf.ReadByte()
 	if err != nil {
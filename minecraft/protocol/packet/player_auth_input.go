@@ -103,7 +103,7 @@ func (pk *PlayerAuthInput) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerAuthInput) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerAuthInput) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		protocol.Float32(buf, &pk.Pitch),
 		protocol.Float32(buf, &pk.Yaw),
@@ -31,9 +31,9 @@ func (pk *PlayerEnchantOptions) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerEnchantOptions) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerEnchantOptions) Unmarshal(buf *protocol.Reader) error {
 	var l uint32
-	if err := protocol.Varuint32(buf, &l); err != nil {
+	if err := protocol.Count(buf, &l, "PlayerEnchantOptions option"); err != nil {
 		return err
 	}
 	pk.Options = make([]protocol.EnchantmentOption, l)
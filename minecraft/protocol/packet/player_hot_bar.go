@@ -32,7 +32,7 @@ func (pk *PlayerHotBar) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerHotBar) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerHotBar) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.SelectedHotBarSlot),
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
@@ -37,7 +37,7 @@ func (pk *PlayerInput) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerInput) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerInput) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Vec2(buf, &pk.Movement),
 		binary.Read(buf, binary.LittleEndian, &pk.Jumping),
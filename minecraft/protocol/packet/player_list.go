@@ -53,11 +53,11 @@ func (pk *PlayerList) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerList) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerList) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
-		protocol.Varuint32(buf, &count),
+		protocol.Count(buf, &count, "PlayerList entry"),
 	); err != nil {
 		return err
 	}
@@ -38,7 +38,7 @@ func (pk *PlayerSkin) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PlayerSkin) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PlayerSkin) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.UUID(buf, &pk.UUID),
 		protocol.SerialisedSkin(buf, &pk.Skin),
@@ -37,7 +37,7 @@ func (pk *PositionTrackingDBClientRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PositionTrackingDBClientRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PositionTrackingDBClientRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.RequestAction),
 		protocol.Varint32(buf, &pk.TrackingID),
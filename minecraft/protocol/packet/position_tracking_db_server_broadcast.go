@@ -60,7 +60,7 @@ func (pk *PositionTrackingDBServerBroadcast) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PositionTrackingDBServerBroadcast) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PositionTrackingDBServerBroadcast) Unmarshal(buf *protocol.Reader) error {
 	if err := binary.Read(buf, binary.LittleEndian, &pk.BroadcastAction); err != nil {
 		return err
 	}
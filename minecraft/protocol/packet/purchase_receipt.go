@@ -27,9 +27,9 @@ func (pk *PurchaseReceipt) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *PurchaseReceipt) Unmarshal(buf *bytes.Buffer) error {
+func (pk *PurchaseReceipt) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
-	if err := protocol.Varuint32(buf, &count); err != nil {
+	if err := protocol.Count(buf, &count, "PurchaseReceipt receipt"); err != nil {
 		return err
 	}
 	if count > 64 {
@@ -25,6 +25,6 @@ func (pk *RemoveActor) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *RemoveActor) Unmarshal(buf *bytes.Buffer) error {
+func (pk *RemoveActor) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint64(buf, &pk.EntityUniqueID)
 }
@@ -23,6 +23,6 @@ func (pk *RemoveEntity) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *RemoveEntity) Unmarshal(buf *bytes.Buffer) error {
+func (pk *RemoveEntity) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varuint64(buf, &pk.EntityNetworkID)
 }
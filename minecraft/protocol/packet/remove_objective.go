@@ -24,6 +24,6 @@ func (pk *RemoveObjective) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *RemoveObjective) Unmarshal(buf *bytes.Buffer) error {
+func (pk *RemoveObjective) Unmarshal(buf *protocol.Reader) error {
 	return protocol.String(buf, &pk.ObjectiveName)
 }
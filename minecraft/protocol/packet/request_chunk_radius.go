@@ -25,6 +25,6 @@ func (pk *RequestChunkRadius) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *RequestChunkRadius) Unmarshal(buf *bytes.Buffer) error {
+func (pk *RequestChunkRadius) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.ChunkRadius)
 }
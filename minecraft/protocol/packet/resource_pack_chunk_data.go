@@ -37,7 +37,7 @@ func (pk *ResourcePackChunkData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePackChunkData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePackChunkData) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.UUID),
 		binary.Read(buf, binary.LittleEndian, &pk.ChunkIndex),
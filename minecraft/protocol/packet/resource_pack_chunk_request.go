@@ -28,7 +28,7 @@ func (pk *ResourcePackChunkRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePackChunkRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePackChunkRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.UUID),
 		binary.Read(buf, binary.LittleEndian, &pk.ChunkIndex),
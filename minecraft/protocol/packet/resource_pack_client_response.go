@@ -39,7 +39,7 @@ func (pk *ResourcePackClientResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePackClientResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePackClientResponse) Unmarshal(buf *protocol.Reader) error {
 	var length uint16
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.Response),
@@ -47,6 +47,9 @@ func (pk *ResourcePackClientResponse) Unmarshal(buf *bytes.Buffer) error {
 	); err != nil {
 		return err
 	}
+	if err := protocol.CheckCount(buf, uint32(length), "ResourcePackClientResponse pack"); err != nil {
+		return err
+	}
 	for i := uint16(0); i < length; i++ {
 		var pack string
 		if err := protocol.String(buf, &pack); err != nil {
@@ -59,7 +59,7 @@ func (pk *ResourcePackDataInfo) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePackDataInfo) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePackDataInfo) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.UUID),
 		binary.Read(buf, binary.LittleEndian, &pk.DataChunkSize),
@@ -48,11 +48,11 @@ func (pk *ResourcePackStack) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePackStack) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePackStack) Unmarshal(buf *protocol.Reader) error {
 	var length uint32
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.TexturePackRequired),
-		protocol.Varuint32(buf, &length),
+		protocol.Count(buf, &length, "ResourcePackStack behaviour pack"),
 	); err != nil {
 		return err
 	}
@@ -62,7 +62,7 @@ func (pk *ResourcePackStack) Unmarshal(buf *bytes.Buffer) error {
 			return err
 		}
 	}
-	if err := protocol.Varuint32(buf, &length); err != nil {
+	if err := protocol.Count(buf, &length, "ResourcePackStack texture pack"); err != nil {
 		return err
 	}
 	pk.TexturePacks = make([]protocol.StackResourcePack, length)
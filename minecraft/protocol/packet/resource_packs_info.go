@@ -45,7 +45,7 @@ func (pk *ResourcePacksInfo) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ResourcePacksInfo) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ResourcePacksInfo) Unmarshal(buf *protocol.Reader) error {
 	var length uint16
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.TexturePackRequired),
@@ -54,6 +54,9 @@ func (pk *ResourcePacksInfo) Unmarshal(buf *bytes.Buffer) error {
 	); err != nil {
 		return err
 	}
+	if err := protocol.CheckCount(buf, uint32(length), "ResourcePacksInfo behaviour pack"); err != nil {
+		return err
+	}
 	pk.BehaviourPacks = make([]protocol.ResourcePackInfo, length)
 	for i := uint16(0); i < length; i++ {
 		if err := protocol.PackInfo(buf, &pk.BehaviourPacks[i]); err != nil {
@@ -63,6 +66,9 @@ func (pk *ResourcePacksInfo) Unmarshal(buf *bytes.Buffer) error {
 	if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
 		return err
 	}
+	if err := protocol.CheckCount(buf, uint32(length), "ResourcePacksInfo texture pack"); err != nil {
+		return err
+	}
 	pk.TexturePacks = make([]protocol.ResourcePackInfo, length)
 	for i := uint16(0); i < length; i++ {
 		if err := protocol.PackInfo(buf, &pk.TexturePacks[i]); err != nil {
@@ -45,7 +45,7 @@ func (pk *Respawn) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Respawn) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Respawn) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Vec3(buf, &pk.Position),
 		binary.Read(buf, binary.LittleEndian, &pk.State),
@@ -23,6 +23,6 @@ func (pk *RiderJump) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *RiderJump) Unmarshal(buf *bytes.Buffer) error {
+func (pk *RiderJump) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.JumpStrength)
 }
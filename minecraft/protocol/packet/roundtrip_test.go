@@ -0,0 +1,154 @@
+package packet
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// roundTripIterations is the amount of packets with random values that TestRoundTrip encodes and decodes for
+// every packet in the pool.
+const roundTripIterations = 50
+
+// TestRoundTrip tests for every packet in NewPool that a packet filled with random values decodes into the
+// same packet after it is encoded, and that decoding consumes all bytes encoded. The random values are fixed
+// up by typeFixes and packetFixes to satisfy the constraints of the encoding, such as fields that are only
+// encoded depending on the values of others.
+func TestRoundTrip(t *testing.T) {
+	for _, id := range poolIDs() {
+		pk := NewPool()[id]
+		t.Run(packetName(pk), func(t *testing.T) {
+			r := rand.New(rand.NewSource(int64(id)))
+			for i := 0; i < roundTripIterations; i++ {
+				pk := randomPacket(r, id)
+				decoded, err := roundTrip(pk)
+				if err != nil {
+					t.Fatalf("error in round trip of random packet: %v\n%v", err, Dump(pk))
+				}
+				if d := diff(reflect.ValueOf(pk).Elem(), reflect.ValueOf(decoded).Elem(), packetName(pk)); d != "" {
+					t.Fatalf("packet changed in round trip: %v\n%v\nbecame\n%v", d, Dump(pk), Dump(decoded))
+				}
+			}
+		})
+	}
+}
+
+// diff compares the values passed like reflect.DeepEqual, except that nil and empty slices and maps are
+// considered equal, as encoding does not distinguish between the two. If the values are not equal, a
+// description of the first difference found is returned, starting with the path passed. Otherwise an empty
+// string is returned.
+func diff(a, b reflect.Value, path string) string {
+	if a.IsValid() != b.IsValid() || (a.IsValid() && a.Type() != b.Type()) {
+		return fmt.Sprintf("%v: %v became %v", path, a, b)
+	}
+	if !a.IsValid() {
+		return ""
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return fmt.Sprintf("%v: %v became %v", path, a, b)
+			}
+			return ""
+		}
+		return diff(a.Elem(), b.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%v: length %v became %v", path, a.Len(), b.Len())
+		}
+		for i := 0; i < a.Len(); i++ {
+			if d := diff(a.Index(i), b.Index(i), fmt.Sprintf("%v[%v]", path, i)); d != "" {
+				return d
+			}
+		}
+		return ""
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%v: length %v became %v", path, a.Len(), b.Len())
+		}
+		for _, key := range a.MapKeys() {
+			if d := diff(a.MapIndex(key), b.MapIndex(key), fmt.Sprintf("%v[%v]", path, key)); d != "" {
+				return d
+			}
+		}
+		return ""
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if d := diff(a.Field(i), b.Field(i), path+"."+a.Type().Field(i).Name); d != "" {
+				return d
+			}
+		}
+		return ""
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return ""
+	}
+	if x, y := valueOf(a), valueOf(b); !reflect.DeepEqual(x, y) {
+		return fmt.Sprintf("%v: %v became %v", path, x, y)
+	}
+	return ""
+}
+
+// valueOf returns the value held by the reflect.Value passed, including values of unexported fields, which
+// reflect.Value.Interface does not allow obtaining.
+func valueOf(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.String:
+		return v.String()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex()
+	}
+	return v.Interface()
+}
+
+// poolIDs returns the IDs of all packets in NewPool, sorted.
+func poolIDs() []uint32 {
+	pool := NewPool()
+	ids := make([]uint32, 0, len(pool))
+	for id := range pool {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// newPacket returns a new packet of the type found in NewPool for the ID passed.
+func newPacket(id uint32) Packet {
+	return reflect.New(reflect.TypeOf(NewPool()[id]).Elem()).Interface().(Packet)
+}
+
+// roundTrip encodes the packet passed and decodes it into a new packet of the same type. An error is returned
+// if encoding panics, if decoding fails or if decoding does not consume all bytes encoded.
+func roundTrip(pk Packet) (decoded Packet, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	buf := bytes.NewBuffer(nil)
+	pk.Marshal(buf)
+
+	decoded = newPacket(pk.ID())
+	if err := decoded.Unmarshal(protocol.NewReader(buf, protocol.DecodeLimits{})); err != nil {
+		return nil, fmt.Errorf("error decoding packet: %v", err)
+	}
+	if buf.Len() != 0 {
+		return nil, fmt.Errorf("%v unread bytes left after decoding", buf.Len())
+	}
+	return decoded, nil
+}
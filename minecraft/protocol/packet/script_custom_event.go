@@ -29,7 +29,7 @@ func (pk *ScriptCustomEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ScriptCustomEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ScriptCustomEvent) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.EventName),
 		protocol.ByteSlice(buf, &pk.EventData),
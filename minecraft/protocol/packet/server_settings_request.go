@@ -1,6 +1,10 @@
 package packet
 
-import "bytes"
+import (
+	"bytes"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
 
 // ServerSettingsRequest is sent by the client to request the settings specific to the server. These settings
 // are shown in a separate tab client-side, and have the same structure as a custom form.
@@ -19,6 +23,6 @@ func (*ServerSettingsRequest) Marshal(*bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (*ServerSettingsRequest) Unmarshal(*bytes.Buffer) error {
+func (*ServerSettingsRequest) Unmarshal(*protocol.Reader) error {
 	return nil
 }
@@ -31,7 +31,7 @@ func (pk *ServerSettingsResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ServerSettingsResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ServerSettingsResponse) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint32(buf, &pk.FormID),
 		protocol.ByteSlice(buf, &pk.FormData),
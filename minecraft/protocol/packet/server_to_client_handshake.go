@@ -25,6 +25,6 @@ func (pk *ServerToClientHandshake) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ServerToClientHandshake) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ServerToClientHandshake) Unmarshal(buf *protocol.Reader) error {
 	return protocol.ByteSlice(buf, &pk.JWT)
 }
@@ -29,7 +29,7 @@ func (pk *SetActorData) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetActorData) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetActorData) Unmarshal(buf *protocol.Reader) error {
 	pk.EntityMetadata = map[uint32]interface{}{}
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
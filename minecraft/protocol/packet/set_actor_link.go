@@ -25,6 +25,6 @@ func (pk *SetActorLink) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetActorLink) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetActorLink) Unmarshal(buf *protocol.Reader) error {
 	return protocol.EntityLinkAction(buf, &pk.EntityLink)
 }
@@ -29,7 +29,7 @@ func (pk *SetActorMotion) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetActorMotion) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetActorMotion) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Vec3(buf, &pk.Velocity),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // SetCommandsEnabled is sent by the server to enable or disable the ability to execute commands for the
@@ -23,6 +25,6 @@ func (pk *SetCommandsEnabled) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetCommandsEnabled) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetCommandsEnabled) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &pk.Enabled)
 }
@@ -25,6 +25,6 @@ func (pk *SetDefaultGameType) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetDefaultGameType) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetDefaultGameType) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.GameType)
 }
@@ -23,6 +23,6 @@ func (pk *SetDifficulty) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetDifficulty) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetDifficulty) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varuint32(buf, &pk.Difficulty)
 }
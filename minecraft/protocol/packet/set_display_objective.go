@@ -51,7 +51,7 @@ func (pk *SetDisplayObjective) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetDisplayObjective) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetDisplayObjective) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.DisplaySlot),
 		protocol.String(buf, &pk.ObjectiveName),
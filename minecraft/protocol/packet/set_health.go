@@ -24,6 +24,6 @@ func (pk *SetHealth) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetHealth) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetHealth) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.Health)
 }
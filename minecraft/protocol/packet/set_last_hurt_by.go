@@ -24,6 +24,6 @@ func (pk *SetLastHurtBy) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetLastHurtBy) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetLastHurtBy) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.EntityType)
 }
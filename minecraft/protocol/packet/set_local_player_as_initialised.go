@@ -25,6 +25,6 @@ func (pk *SetLocalPlayerAsInitialised) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetLocalPlayerAsInitialised) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetLocalPlayerAsInitialised) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varuint64(buf, &pk.EntityRuntimeID)
 }
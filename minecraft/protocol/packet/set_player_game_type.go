@@ -33,6 +33,6 @@ func (pk *SetPlayerGameType) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetPlayerGameType) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetPlayerGameType) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.GameType)
 }
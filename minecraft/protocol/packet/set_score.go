@@ -43,11 +43,11 @@ func (pk *SetScore) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetScore) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetScore) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
-		protocol.Varuint32(buf, &count),
+		protocol.Count(buf, &count, "SetScore entry"),
 	); err != nil {
 		return err
 	}
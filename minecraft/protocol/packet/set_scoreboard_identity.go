@@ -43,11 +43,11 @@ func (pk *SetScoreboardIdentity) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetScoreboardIdentity) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetScoreboardIdentity) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ActionType),
-		protocol.Varuint32(buf, &count),
+		protocol.Count(buf, &count, "SetScoreboardIdentity entry"),
 	); err != nil {
 		return err
 	}
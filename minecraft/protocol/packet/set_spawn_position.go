@@ -43,7 +43,7 @@ func (pk *SetSpawnPosition) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetSpawnPosition) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetSpawnPosition) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.SpawnType),
 		protocol.UBlockPosition(buf, &pk.Position),
@@ -25,6 +25,6 @@ func (pk *SetTime) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetTime) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetTime) Unmarshal(buf *protocol.Reader) error {
 	return protocol.Varint32(buf, &pk.Time)
 }
@@ -49,7 +49,7 @@ func (pk *SetTitle) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SetTitle) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SetTitle) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.ActionType),
 		protocol.String(buf, &pk.Text),
@@ -30,7 +30,7 @@ func (pk *SettingsCommand) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SettingsCommand) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SettingsCommand) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.CommandLine),
 		binary.Read(buf, binary.LittleEndian, &pk.SuppressOutput),
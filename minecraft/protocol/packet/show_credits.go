@@ -28,7 +28,7 @@ func (pk *ShowCredits) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ShowCredits) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ShowCredits) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.PlayerRuntimeID),
 		protocol.Varint32(buf, &pk.StatusType),
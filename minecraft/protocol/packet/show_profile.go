@@ -23,6 +23,6 @@ func (pk *ShowProfile) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ShowProfile) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ShowProfile) Unmarshal(buf *protocol.Reader) error {
 	return protocol.String(buf, &pk.XUID)
 }
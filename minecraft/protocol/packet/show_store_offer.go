@@ -33,7 +33,7 @@ func (pk *ShowStoreOffer) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *ShowStoreOffer) Unmarshal(buf *bytes.Buffer) error {
+func (pk *ShowStoreOffer) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.OfferID),
 		binary.Read(buf, binary.LittleEndian, &pk.ShowAll),
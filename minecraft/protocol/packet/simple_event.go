@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 const (
@@ -28,6 +30,6 @@ func (pk *SimpleEvent) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SimpleEvent) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SimpleEvent) Unmarshal(buf *protocol.Reader) error {
 	return binary.Read(buf, binary.LittleEndian, &pk.EventType)
 }
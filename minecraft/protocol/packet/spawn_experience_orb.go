@@ -30,7 +30,7 @@ func (pk *SpawnExperienceOrb) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SpawnExperienceOrb) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SpawnExperienceOrb) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Vec3(buf, &pk.Position),
 		protocol.Varint32(buf, &pk.ExperienceAmount),
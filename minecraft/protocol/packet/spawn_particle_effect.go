@@ -41,7 +41,7 @@ func (pk *SpawnParticleEffect) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SpawnParticleEffect) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SpawnParticleEffect) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.Dimension),
 		protocol.Varint64(buf, &pk.EntityUniqueID),
@@ -267,7 +267,7 @@ func (pk *StartGame) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *StartGame) Unmarshal(buf *bytes.Buffer) error {
+func (pk *StartGame) Unmarshal(buf *protocol.Reader) error {
 	if pk.GameRules == nil {
 		pk.GameRules = make(map[string]interface{})
 	}
@@ -333,7 +333,7 @@ func (pk *StartGame) Unmarshal(buf *bytes.Buffer) error {
 		binary.Read(buf, binary.LittleEndian, &pk.Time),
 		protocol.Varint32(buf, &pk.EnchantmentSeed),
 		nbt.NewDecoder(buf).Decode(&pk.Blocks),
-		protocol.Varuint32(buf, &itemCount),
+		protocol.Count(buf, &itemCount, "StartGame item"),
 	); err != nil {
 		return err
 	}
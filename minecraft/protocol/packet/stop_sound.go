@@ -29,7 +29,7 @@ func (pk *StopSound) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *StopSound) Unmarshal(buf *bytes.Buffer) error {
+func (pk *StopSound) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.SoundName),
 		binary.Read(buf, binary.LittleEndian, &pk.StopAll),
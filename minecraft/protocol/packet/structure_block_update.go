@@ -64,7 +64,7 @@ func (pk *StructureBlockUpdate) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *StructureBlockUpdate) Unmarshal(buf *bytes.Buffer) error {
+func (pk *StructureBlockUpdate) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.UBlockPosition(buf, &pk.Position),
 		protocol.String(buf, &pk.StructureName),
@@ -35,7 +35,7 @@ func (pk *StructureTemplateDataRequest) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *StructureTemplateDataRequest) Unmarshal(buf *bytes.Buffer) error {
+func (pk *StructureTemplateDataRequest) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.StructureName),
 		protocol.UBlockPosition(buf, &pk.Position),
@@ -32,7 +32,7 @@ func (pk *StructureTemplateDataExportResponse) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *StructureTemplateDataExportResponse) Unmarshal(buf *bytes.Buffer) error {
+func (pk *StructureTemplateDataExportResponse) Unmarshal(buf *protocol.Reader) error {
 	var hasData bool
 	if err := chainErr(
 		protocol.String(buf, &pk.StructureName),
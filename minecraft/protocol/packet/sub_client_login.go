@@ -28,6 +28,6 @@ func (pk *SubClientLogin) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *SubClientLogin) Unmarshal(buf *bytes.Buffer) error {
+func (pk *SubClientLogin) Unmarshal(buf *protocol.Reader) error {
 	return protocol.ByteSlice(buf, &pk.ConnectionRequest)
 }
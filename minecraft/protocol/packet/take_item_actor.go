@@ -28,7 +28,7 @@ func (pk *TakeItemActor) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *TakeItemActor) Unmarshal(buf *bytes.Buffer) error {
+func (pk *TakeItemActor) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.ItemEntityRuntimeID),
 		protocol.Varuint64(buf, &pk.TakerEntityRuntimeID),
@@ -76,7 +76,7 @@ func (pk *Text) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Text) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Text) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.TextType),
 		binary.Read(buf, binary.LittleEndian, &pk.NeedsTranslation),
@@ -99,7 +99,7 @@ func (pk *Text) Unmarshal(buf *bytes.Buffer) error {
 		var length uint32
 		if err := chainErr(
 			protocol.String(buf, &pk.Message),
-			protocol.Varuint32(buf, &length),
+			protocol.Count(buf, &length, "Text parameter"),
 		); err != nil {
 			return err
 		}
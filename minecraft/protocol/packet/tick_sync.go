@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // TickSync is sent by the client and the server to maintain a synchronized, server-authoritative tick between
@@ -32,7 +34,7 @@ func (pk *TickSync) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *TickSync) Unmarshal(buf *bytes.Buffer) error {
+func (pk *TickSync) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.ClientRequestTimestamp),
 		binary.Read(buf, binary.LittleEndian, &pk.ServerReceptionTimestamp),
@@ -27,7 +27,7 @@ func (pk *Transfer) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Transfer) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Transfer) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.String(buf, &pk.Address),
 		binary.Read(buf, binary.LittleEndian, &pk.Port),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"fmt"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // Unknown is an implementation of the Packet interface for unknown/unimplemented packets. It holds the packet
@@ -26,7 +28,7 @@ func (pk *Unknown) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *Unknown) Unmarshal(buf *bytes.Buffer) error {
+func (pk *Unknown) Unmarshal(buf *protocol.Reader) error {
 	pk.Payload = buf.Bytes()
 	buf.Reset()
 	return nil
@@ -29,7 +29,7 @@ func (pk *UpdateAttributes) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateAttributes) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateAttributes) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varuint64(buf, &pk.EntityRuntimeID),
 		protocol.Attributes(buf, &pk.Attributes),
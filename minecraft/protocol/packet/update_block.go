@@ -42,7 +42,7 @@ func (pk *UpdateBlock) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateBlock) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateBlock) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.UBlockPosition(buf, &pk.Position),
 		protocol.Varuint32(buf, &pk.NewBlockRuntimeID),
@@ -3,6 +3,8 @@ package packet
 import (
 	"bytes"
 	"math"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 // UpdateBlockProperties is sent by the server to update the available block properties.
@@ -23,7 +25,7 @@ func (pk *UpdateBlockProperties) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateBlockProperties) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateBlockProperties) Unmarshal(buf *protocol.Reader) error {
 	pk.SerialisedBlockProperties = buf.Next(math.MaxInt32)
 	return nil
 }
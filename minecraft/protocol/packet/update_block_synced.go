@@ -52,7 +52,7 @@ func (pk *UpdateBlockSynced) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateBlockSynced) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateBlockSynced) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.UBlockPosition(buf, &pk.Position),
 		protocol.Varuint32(buf, &pk.NewBlockRuntimeID),
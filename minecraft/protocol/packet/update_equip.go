@@ -42,7 +42,7 @@ func (pk *UpdateEquip) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateEquip) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateEquip) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
 		binary.Read(buf, binary.LittleEndian, &pk.WindowType),
@@ -51,7 +51,9 @@ func (pk *UpdateEquip) Unmarshal(buf *bytes.Buffer) error {
 	); err != nil {
 		return err
 	}
+	// The data must be read from the buffer rather than copied from buf.Bytes(): Otherwise it is left unread
+	// and the packet is reported as having unread bytes.
 	pk.SerialisedInventoryData = make([]byte, buf.Len())
-	copy(pk.SerialisedInventoryData, buf.Bytes())
-	return nil
+	_, err := buf.Read(pk.SerialisedInventoryData)
+	return err
 }
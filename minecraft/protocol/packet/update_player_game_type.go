@@ -29,7 +29,7 @@ func (pk *UpdatePlayerGameType) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdatePlayerGameType) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdatePlayerGameType) Unmarshal(buf *protocol.Reader) error {
 	return chainErr(
 		protocol.Varint32(buf, &pk.GameType),
 		protocol.Varint64(buf, &pk.PlayerUniqueID),
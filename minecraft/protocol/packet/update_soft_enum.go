@@ -46,11 +46,11 @@ func (pk *UpdateSoftEnum) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateSoftEnum) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateSoftEnum) Unmarshal(buf *protocol.Reader) error {
 	var count uint32
 	if err := chainErr(
 		protocol.String(buf, &pk.EnumType),
-		protocol.Varuint32(buf, &count),
+		protocol.Count(buf, &count, "UpdateSoftEnum option"),
 	); err != nil {
 		return err
 	}
@@ -60,7 +60,7 @@ func (pk *UpdateTrade) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (pk *UpdateTrade) Unmarshal(buf *bytes.Buffer) error {
+func (pk *UpdateTrade) Unmarshal(buf *protocol.Reader) error {
 	if err := chainErr(
 		binary.Read(buf, binary.LittleEndian, &pk.WindowID),
 		binary.Read(buf, binary.LittleEndian, &pk.WindowType),
@@ -53,7 +53,7 @@ func WritePlayerAddEntry(buf *bytes.Buffer, x PlayerListEntry) error {
 }
 
 // PlayerAddEntry reads a PlayerListEntry x from Buffer buf in a way that adds a player to the list.
-func PlayerAddEntry(buf *bytes.Buffer, x *PlayerListEntry) error {
+func PlayerAddEntry(buf *Reader, x *PlayerListEntry) error {
 	return chainErr(
 		UUID(buf, &x.UUID),
 		Varint64(buf, &x.EntityUniqueID),
@@ -74,6 +74,6 @@ func WritePlayerRemoveEntry(buf *bytes.Buffer, x PlayerListEntry) error {
 }
 
 // PlayerRemoveEntry reads a PlayerListEntry x from Buffer buf in a way that removes a player from the list.
-func PlayerRemoveEntry(buf *bytes.Buffer, x *PlayerListEntry) error {
+func PlayerRemoveEntry(buf *Reader, x *PlayerListEntry) error {
 	return UUID(buf, &x.UUID)
 }
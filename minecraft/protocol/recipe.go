@@ -31,7 +31,7 @@ func WritePotContainerChangeRecipe(dst *bytes.Buffer, x PotionContainerChangeRec
 }
 
 // PotContainerChangeRecipe reads a PotionContainerChangeRecipe x from Buffer src.
-func PotContainerChangeRecipe(src *bytes.Buffer, x *PotionContainerChangeRecipe) error {
+func PotContainerChangeRecipe(src *Reader, x *PotionContainerChangeRecipe) error {
 	return chainErr(
 		Varint32(src, &x.InputItemID),
 		Varint32(src, &x.ReagentItemID),
@@ -72,7 +72,7 @@ func WritePotRecipe(dst *bytes.Buffer, x PotionRecipe) error {
 }
 
 // PotRecipe reads a PotionRecipe x from Buffer src.
-func PotRecipe(src *bytes.Buffer, x *PotionRecipe) error {
+func PotRecipe(src *Reader, x *PotionRecipe) error {
 	return chainErr(
 		Varint32(src, &x.InputPotionID),
 		Varint32(src, &x.InputPotionMetadata),
@@ -100,7 +100,7 @@ type Recipe interface {
 	// Marshal encodes the recipe data to its binary representation into buf.
 	Marshal(buf *bytes.Buffer)
 	// Unmarshal decodes a serialised recipe in buf into the recipe instance.
-	Unmarshal(buf *bytes.Buffer) error
+	Unmarshal(buf *Reader) error
 }
 
 // ShapelessRecipe is a recipe that has no particular shape. Its functionality is shared with the
@@ -212,7 +212,7 @@ func (recipe *ShapelessRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *ShapelessRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *ShapelessRecipe) Unmarshal(buf *Reader) error {
 	return unmarshalShapeless(buf, recipe)
 }
 
@@ -223,7 +223,7 @@ func (recipe *ShulkerBoxRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *ShulkerBoxRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *ShulkerBoxRecipe) Unmarshal(buf *Reader) error {
 	r := ShapelessRecipe{}
 	if err := unmarshalShapeless(buf, &r); err != nil {
 		return err
@@ -239,7 +239,7 @@ func (recipe *ShapelessChemistryRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *ShapelessChemistryRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *ShapelessChemistryRecipe) Unmarshal(buf *Reader) error {
 	r := ShapelessRecipe{}
 	if err := unmarshalShapeless(buf, &r); err != nil {
 		return err
@@ -254,7 +254,7 @@ func (recipe *ShapedRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *ShapedRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *ShapedRecipe) Unmarshal(buf *Reader) error {
 	return unmarshalShaped(buf, recipe)
 }
 
@@ -265,7 +265,7 @@ func (recipe *ShapedChemistryRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *ShapedChemistryRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *ShapedChemistryRecipe) Unmarshal(buf *Reader) error {
 	r := ShapedRecipe{}
 	if err := unmarshalShaped(buf, &r); err != nil {
 		return err
@@ -282,7 +282,7 @@ func (recipe *FurnaceRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *FurnaceRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *FurnaceRecipe) Unmarshal(buf *Reader) error {
 	return chainErr(
 		Varint32(buf, &recipe.InputType.NetworkID),
 		Item(buf, &recipe.Output),
@@ -299,7 +299,7 @@ func (recipe *FurnaceDataRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *FurnaceDataRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *FurnaceDataRecipe) Unmarshal(buf *Reader) error {
 	var dataValue int32
 	if err := chainErr(
 		Varint32(buf, &recipe.InputType.NetworkID),
@@ -320,7 +320,7 @@ func (recipe *MultiRecipe) Marshal(buf *bytes.Buffer) {
 }
 
 // Unmarshal ...
-func (recipe *MultiRecipe) Unmarshal(buf *bytes.Buffer) error {
+func (recipe *MultiRecipe) Unmarshal(buf *Reader) error {
 	return chainErr(
 		UUID(buf, &recipe.UUID),
 		Varuint32(buf, &recipe.RecipeNetworkID),
@@ -352,7 +352,7 @@ func marshalShaped(buf *bytes.Buffer, recipe *ShapedRecipe) {
 }
 
 // unmarshalShaped ...
-func unmarshalShaped(buf *bytes.Buffer, recipe *ShapedRecipe) error {
+func unmarshalShaped(buf *Reader, recipe *ShapedRecipe) error {
 	if err := chainErr(
 		String(buf, &recipe.RecipeID),
 		Varint32(buf, &recipe.Width),
@@ -368,6 +368,9 @@ func unmarshalShaped(buf *bytes.Buffer, recipe *ShapedRecipe) error {
 	if recipe.Width > lowerLimit || recipe.Height > lowerLimit {
 		return LimitHitError{Type: "shaped recipe dimensions", Limit: lowerLimit}
 	}
+	if err := CheckCount(buf, uint32(recipe.Width*recipe.Height), "shaped recipe input"); err != nil {
+		return err
+	}
 	itemCount := int(recipe.Width * recipe.Height)
 	recipe.Input = make([]ItemStack, itemCount)
 	for i := 0; i < itemCount; i++ {
@@ -376,7 +379,7 @@ func unmarshalShaped(buf *bytes.Buffer, recipe *ShapedRecipe) error {
 		}
 	}
 	var outputCount uint32
-	if err := Varuint32(buf, &outputCount); err != nil {
+	if err := Count(buf, &outputCount, "shaped recipe output"); err != nil {
 		return err
 	}
 	if outputCount > lowerLimit {
@@ -414,11 +417,11 @@ func marshalShapeless(buf *bytes.Buffer, recipe *ShapelessRecipe) {
 }
 
 // unmarshalShapeless ...
-func unmarshalShapeless(buf *bytes.Buffer, recipe *ShapelessRecipe) error {
+func unmarshalShapeless(buf *Reader, recipe *ShapelessRecipe) error {
 	var count uint32
 	if err := chainErr(
 		String(buf, &recipe.RecipeID),
-		Varuint32(buf, &count),
+		Count(buf, &count, "shapeless recipe input"),
 	); err != nil {
 		return err
 	}
@@ -431,7 +434,7 @@ func unmarshalShapeless(buf *bytes.Buffer, recipe *ShapelessRecipe) error {
 			return wrap(err)
 		}
 	}
-	if err := Varuint32(buf, &count); err != nil {
+	if err := Count(buf, &count, "shapeless recipe output"); err != nil {
 		return wrap(err)
 	}
 	if count > lowerLimit {
@@ -58,7 +58,7 @@ func WritePackInfo(buf *bytes.Buffer, x ResourcePackInfo) error {
 }
 
 // PackInfo reads a resource pack info entry from the bytes.Buffer passed.
-func PackInfo(buf *bytes.Buffer, x *ResourcePackInfo) error {
+func PackInfo(buf *Reader, x *ResourcePackInfo) error {
 	return chainErr(
 		String(buf, &x.UUID),
 		String(buf, &x.Version),
@@ -80,7 +80,7 @@ func WriteStackPack(buf *bytes.Buffer, x StackResourcePack) error {
 }
 
 // StackPack reads a StackResourcePack x to Buffer buf.
-func StackPack(buf *bytes.Buffer, x *StackResourcePack) error {
+func StackPack(buf *Reader, x *StackResourcePack) error {
 	return chainErr(
 		String(buf, &x.UUID),
 		String(buf, &x.Version),
@@ -8,7 +8,7 @@ import (
 
 // Rotation reads a rotation object from buffer src and stores it in Vec3 x. The rotation object exists out
 // of 3 bytes.
-func Rotation(src *bytes.Buffer, x *mgl32.Vec3) error {
+func Rotation(src *Reader, x *mgl32.Vec3) error {
 	data := src.Next(3)
 	if len(data) != 3 {
 		return fmt.Errorf("%v: expected exactly 3 bytes for byte rotation", callFrame())
@@ -80,7 +80,7 @@ func WriteScoreEntry(dst *bytes.Buffer, x ScoreboardEntry, modify bool) error {
 
 // ScoreEntry reads a ScoreboardEntry x from Buffer src. It reads the display information if modify is true,
 // as expected when the SetScore packet is sent to modify entries.
-func ScoreEntry(src *bytes.Buffer, x *ScoreboardEntry, modify bool) error {
+func ScoreEntry(src *Reader, x *ScoreboardEntry, modify bool) error {
 	if err := chainErr(
 		Varint64(src, &x.EntryID),
 		String(src, &x.ObjectiveName),
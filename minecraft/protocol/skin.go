@@ -65,11 +65,12 @@ type Skin struct {
 	Trusted bool
 }
 
-// WriteSerialisedSkin writes a Skin x to Buffer dst. WriteSerialisedSkin panics if the fields of the skin
-// have invalid values, usually indicating that the dimensions of the skin images are incorrect.
+// WriteSerialisedSkin writes a Skin x to Buffer dst. If the fields of the skin have invalid values, usually
+// indicating that the dimensions of the skin images are incorrect, an InvalidValueError is returned and
+// nothing is written.
 func WriteSerialisedSkin(dst *bytes.Buffer, x Skin) error {
 	if err := x.validate(); err != nil {
-		panic(err)
+		return err
 	}
 	if err := chainErr(
 		WriteString(dst, x.SkinID),
@@ -120,7 +121,7 @@ func WriteSerialisedSkin(dst *bytes.Buffer, x Skin) error {
 }
 
 // SerialisedSkin reads a Skin x from Buffer src.
-func SerialisedSkin(src *bytes.Buffer, x *Skin) error {
+func SerialisedSkin(src *Reader, x *Skin) error {
 	var animationCount uint32
 	var c uint32
 	if err := chainErr(
@@ -133,6 +134,9 @@ func SerialisedSkin(src *bytes.Buffer, x *Skin) error {
 	); err != nil {
 		return err
 	}
+	if err := CheckCount(src, animationCount, "skin animation"); err != nil {
+		return err
+	}
 	x.Animations = make([]SkinAnimation, animationCount)
 
 	for i := uint32(0); i < animationCount; i++ {
@@ -157,6 +161,9 @@ func SerialisedSkin(src *bytes.Buffer, x *Skin) error {
 	); err != nil {
 		return err
 	}
+	if err := CheckCount(src, c, "persona piece"); err != nil {
+		return err
+	}
 	x.PersonaPieces = make([]PersonaPiece, c)
 	for i := uint32(0); i < c; i++ {
 		if err := SkinPiece(src, &x.PersonaPieces[i]); err != nil {
@@ -166,6 +173,9 @@ func SerialisedSkin(src *bytes.Buffer, x *Skin) error {
 	if err := binary.Read(src, binary.LittleEndian, &c); err != nil {
 		return err
 	}
+	if err := CheckCount(src, c, "persona piece tint colour"); err != nil {
+		return err
+	}
 	x.PieceTintColours = make([]PersonaPieceTintColour, c)
 	for i := uint32(0); i < c; i++ {
 		if err := SkinPieceTint(src, &x.PieceTintColours[i]); err != nil {
@@ -179,14 +189,14 @@ func SerialisedSkin(src *bytes.Buffer, x *Skin) error {
 // and makes sure they match the image size of the skin, cape and the skin's animations.
 func (skin Skin) validate() error {
 	if skin.SkinImageHeight*skin.SkinImageWidth*4 != uint32(len(skin.SkinData)) {
-		return fmt.Errorf("expected size of skin is %vx%v (%v bytes total), but got %v bytes", skin.SkinImageWidth, skin.SkinImageHeight, skin.SkinImageHeight*skin.SkinImageWidth*4, len(skin.SkinData))
+		return InvalidValueError{Type: "skin", Reason: fmt.Sprintf("expected size of skin is %vx%v (%v bytes total), but got %v bytes", skin.SkinImageWidth, skin.SkinImageHeight, skin.SkinImageHeight*skin.SkinImageWidth*4, len(skin.SkinData))}
 	}
 	if skin.CapeImageHeight*skin.CapeImageWidth*4 != uint32(len(skin.CapeData)) {
-		return fmt.Errorf("expected size of cape is %vx%v (%v bytes total), but got %v bytes", skin.CapeImageWidth, skin.CapeImageHeight, skin.CapeImageHeight*skin.CapeImageWidth*4, len(skin.CapeData))
+		return InvalidValueError{Type: "skin", Reason: fmt.Sprintf("expected size of cape is %vx%v (%v bytes total), but got %v bytes", skin.CapeImageWidth, skin.CapeImageHeight, skin.CapeImageHeight*skin.CapeImageWidth*4, len(skin.CapeData))}
 	}
 	for i, animation := range skin.Animations {
 		if animation.ImageHeight*animation.ImageWidth*4 != uint32(len(animation.ImageData)) {
-			return fmt.Errorf("expected size of animation %v is %vx%v (%v bytes total), but got %v bytes", i, animation.ImageWidth, animation.ImageHeight, animation.ImageHeight*animation.ImageWidth*4, len(animation.ImageData))
+			return InvalidValueError{Type: "skin", Reason: fmt.Sprintf("expected size of animation %v is %vx%v (%v bytes total), but got %v bytes", i, animation.ImageWidth, animation.ImageHeight, animation.ImageHeight*animation.ImageWidth*4, len(animation.ImageData))}
 		}
 	}
 	return nil
@@ -231,7 +241,7 @@ func WriteAnimation(dst *bytes.Buffer, x SkinAnimation) error {
 }
 
 // Animation reads a SkinAnimation x from Buffer src.
-func Animation(src *bytes.Buffer, x *SkinAnimation) error {
+func Animation(src *Reader, x *SkinAnimation) error {
 	return chainErr(
 		binary.Read(src, binary.LittleEndian, &x.ImageWidth),
 		binary.Read(src, binary.LittleEndian, &x.ImageHeight),
@@ -279,7 +289,7 @@ func WriteSkinPiece(dst *bytes.Buffer, x PersonaPiece) error {
 }
 
 // SkinPiece reads a PersonaPiece x from Buffer src.
-func SkinPiece(src *bytes.Buffer, x *PersonaPiece) error {
+func SkinPiece(src *Reader, x *PersonaPiece) error {
 	return chainErr(
 		String(src, &x.PieceID),
 		String(src, &x.PieceType),
@@ -325,7 +335,7 @@ func WriteSkinPieceTint(dst *bytes.Buffer, x PersonaPieceTintColour) error {
 }
 
 // SkinPieceTint reads a PersonaPieceTintColour x from Buffer src.
-func SkinPieceTint(src *bytes.Buffer, x *PersonaPieceTintColour) error {
+func SkinPieceTint(src *Reader, x *PersonaPieceTintColour) error {
 	var c uint32
 	if err := chainErr(
 		String(src, &x.PieceType),
@@ -333,6 +343,9 @@ func SkinPieceTint(src *bytes.Buffer, x *PersonaPieceTintColour) error {
 	); err != nil {
 		return err
 	}
+	if err := CheckCount(src, c, "persona piece tint colour"); err != nil {
+		return err
+	}
 	x.Colours = make([]string, c)
 	for i := uint32(0); i < c; i++ {
 		if err := String(src, &x.Colours[i]); err != nil {
@@ -9,7 +9,9 @@ import (
 
 // String reads a string from Buffer src, setting the result to the pointer to a string passed. The string
 // read is prefixed by a varuint32.
-func String(src *bytes.Buffer, x *string) error {
+// The string is not copied: It points directly into the data of src, so that data must not be modified while
+// the string is in use.
+func String(src *Reader, x *string) error {
 	var length uint32
 	if err := Varuint32(src, &length); err != nil {
 		return fmt.Errorf("%v: error reading string length: %v", callFrame(), err)
@@ -41,7 +43,7 @@ func WriteString(dst *bytes.Buffer, x string) error {
 
 // ByteSlice reads a []byte x from Buffer src, setting the result to the pointer passed. The []byte read is
 // prefixed by its length.
-func ByteSlice(src *bytes.Buffer, x *[]byte) error {
+func ByteSlice(src *Reader, x *[]byte) error {
 	var length uint32
 	if err := Varuint32(src, &length); err != nil {
 		return fmt.Errorf("%v: error reading []byte] length: %v", callFrame(), err)
@@ -48,7 +48,7 @@ type StructureSettings struct {
 }
 
 // StructSettings reads StructureSettings x from Buffer src.
-func StructSettings(src *bytes.Buffer, x *StructureSettings) error {
+func StructSettings(src *Reader, x *StructureSettings) error {
 	return chainErr(
 		String(src, &x.PaletteName),
 		binary.Read(src, binary.LittleEndian, &x.IgnoreEntities),
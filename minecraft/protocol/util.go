@@ -1,21 +1,25 @@
 package protocol
 
 import (
-	"errors"
 	"fmt"
 	"runtime"
 	"strings"
 )
 
 // chainErr chains together a variadic amount of errors into a single error and returns it. If all errors
-// passed are nil, the error returned will also be nil.
+// passed are nil, the error returned will also be nil. The error returned unwraps into the first non-nil error
+// passed, so that errors such as LimitHitError may still be found using errors.As.
 func chainErr(err ...error) error {
 	var msg string
+	var first error
 	hasEOF := true
 	for _, e := range err {
 		if e == nil {
 			continue
 		}
+		if first == nil {
+			first = e
+		}
 		if strings.Contains(msg, "EOF") {
 			if hasEOF {
 				// No need to log multiple EOFs.
@@ -28,7 +32,23 @@ func chainErr(err ...error) error {
 	if msg == "" {
 		return nil
 	}
-	return errors.New(strings.TrimRight(msg, "\n"))
+	return chainedError{msg: strings.TrimRight(msg, "\n"), first: first}
+}
+
+// chainedError is an error returned by chainErr.
+type chainedError struct {
+	msg   string
+	first error
+}
+
+// Error ...
+func (err chainedError) Error() string {
+	return err.msg
+}
+
+// Unwrap returns the first error that was chained.
+func (err chainedError) Unwrap() error {
+	return err.first
 }
 
 // callFrame obtains a call frame and formats it so that it includes the file, function and line.
@@ -57,5 +77,5 @@ func wrap(e error) error {
 	if e == nil {
 		return nil
 	}
-	return fmt.Errorf("%v: %v", callFrame(), e)
+	return fmt.Errorf("%v: %w", callFrame(), e)
 }
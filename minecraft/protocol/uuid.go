@@ -7,7 +7,7 @@ import (
 )
 
 // UUID reads a little endian UUID from buffer src into UUID id.
-func UUID(src *bytes.Buffer, id *uuid.UUID) error {
+func UUID(src *Reader, id *uuid.UUID) error {
 	b := make([]byte, 16)
 	if _, err := src.Read(b); err != nil {
 		return fmt.Errorf("%v: need exactly 16 bytes to decode a UUID", callFrame())
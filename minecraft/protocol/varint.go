@@ -6,7 +6,7 @@ import (
 )
 
 // Varint64 reads up to 10 bytes from the source buffer passed and sets the integer produced to a pointer.
-func Varint64(src *bytes.Buffer, x *int64) error {
+func Varint64(src *Reader, x *int64) error {
 	var ux uint64
 	if err := Varuint64(src, &ux); err != nil {
 		return wrap(err)
@@ -19,7 +19,7 @@ func Varint64(src *bytes.Buffer, x *int64) error {
 }
 
 // Varuint64 reads up to 10 bytes from the source buffer passed and sets the integer produced to a pointer.
-func Varuint64(src *bytes.Buffer, x *uint64) error {
+func Varuint64(src *Reader, x *uint64) error {
 	var v uint64
 	for i := uint(0); i < 70; i += 7 {
 		b, err := src.ReadByte()
@@ -36,7 +36,7 @@ func Varuint64(src *bytes.Buffer, x *uint64) error {
 }
 
 // Varint32 reads up to 5 bytes from the source buffer passed and sets the integer produced to a pointer.
-func Varint32(src *bytes.Buffer, x *int32) error {
+func Varint32(src *Reader, x *int32) error {
 	var ux uint32
 	if err := Varuint32(src, &ux); err != nil {
 		return wrap(err)
@@ -49,7 +49,7 @@ func Varint32(src *bytes.Buffer, x *int32) error {
 }
 
 // Varuint32 reads up to 5 bytes from the source buffer passed and sets the integer produced to a pointer.
-func Varuint32(src *bytes.Buffer, x *uint32) error {
+func Varuint32(src *Reader, x *uint32) error {
 	var v uint32
 	for i := uint(0); i < 35; i += 7 {
 		b, err := src.ReadByte()
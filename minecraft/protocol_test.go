@@ -0,0 +1,158 @@
+package minecraft
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// testProtocol is a Protocol with the protocol number passed. It converts Text packets written by adding
+// ' (converted)' to their message, so that it is visible if packets were converted by it.
+type testProtocol struct {
+	id int32
+}
+
+// ID ...
+func (p testProtocol) ID() int32 {
+	return p.id
+}
+
+// Ver ...
+func (testProtocol) Ver() string {
+	return "1.0.0"
+}
+
+// Packets ...
+func (testProtocol) Packets() packet.Pool {
+	return packet.NewPool()
+}
+
+// ConvertToLatest ...
+func (testProtocol) ConvertToLatest(pk packet.Packet, _ *Conn) []packet.Packet {
+	return []packet.Packet{pk}
+}
+
+// ConvertFromLatest ...
+func (testProtocol) ConvertFromLatest(pk packet.Packet, _ *Conn) []packet.Packet {
+	if text, ok := pk.(*packet.Text); ok {
+		converted := *text
+		converted.Message += " (converted)"
+		return []packet.Packet{&converted}
+	}
+	return []packet.Packet{pk}
+}
+
+func TestConnProtocolConvert(t *testing.T) {
+	c1, c2 := net.Pipe()
+	proto := testProtocol{id: protocol.CurrentProtocol + 1}
+	writer, reader := newConn(c1, nil, NopLogger()), newConn(c2, nil, NopLogger())
+	writer.setProtocol(proto)
+	defer writer.Close()
+	defer reader.Close()
+
+	received := make(chan []packet.Packet, 1)
+	go func() {
+		packets, err := reader.decoder.Decode()
+		if err != nil {
+			t.Errorf("error decoding batch: %v", err)
+			close(received)
+			return
+		}
+		var pks []packet.Packet
+		for _, data := range packets {
+			converted, err := reader.parsePacket(data, false)
+			if err != nil {
+				t.Errorf("error parsing packet: %v", err)
+			}
+			pks = append(pks, converted...)
+		}
+		received <- pks
+	}()
+
+	if err := writer.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "Hello"}); err != nil {
+		t.Fatalf("error writing packet: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("error flushing packet: %v", err)
+	}
+	select {
+	case pks := <-received:
+		if len(pks) != 1 {
+			t.Fatalf("expected 1 packet, got %v", len(pks))
+		}
+		text, ok := pks[0].(*packet.Text)
+		if !ok {
+			t.Fatalf("expected *packet.Text, got %T", pks[0])
+		}
+		if text.Message != "Hello (converted)" {
+			t.Fatalf("expected message %q, got %q", "Hello (converted)", text.Message)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("no packet received")
+	}
+	if writer.Protocol().ID() != proto.ID() {
+		t.Fatalf("expected protocol %v, got %v", proto.ID(), writer.Protocol().ID())
+	}
+}
+
+func TestMemoryProtocol(t *testing.T) {
+	proto := testProtocol{id: protocol.CurrentProtocol + 1}
+	listener := &Listener{AuthenticationDisabled: true, AcceptedProtocols: []Protocol{proto}}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := Dialer{ErrorLog: NopLogger(), Protocol: proto}.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	var server *Conn
+	select {
+	case server = <-conns:
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	}
+	defer server.Close()
+
+	if id := server.Protocol().ID(); id != proto.id {
+		t.Errorf("server negotiated protocol %v, expected %v", id, proto.id)
+	}
+	// Both ends convert the packets they write using the protocol.
+	_ = client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "client to server"})
+	if msg := readText(t, server); msg != "client to server (converted)" {
+		t.Errorf("server read message %q, expected %q", msg, "client to server (converted)")
+	}
+	_ = server.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "server to client"})
+	if msg := readText(t, client); msg != "server to client (converted)" {
+		t.Errorf("client read message %q, expected %q", msg, "server to client (converted)")
+	}
+}
+
+func TestMemoryProtocolError(t *testing.T) {
+	listener := &Listener{AuthenticationDisabled: true}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+
+	for _, outdated := range []bool{true, false} {
+		proto := testProtocol{id: protocol.CurrentProtocol + 1}
+		if outdated {
+			proto.id = protocol.CurrentProtocol - 1
+		}
+		_, err := Dialer{ErrorLog: NopLogger(), Protocol: proto}.DialTimeout("memory", address, testTimeout)
+		var protoErr *ProtocolError
+		if !errors.As(err, &protoErr) {
+			t.Fatalf("expected *ProtocolError dialing with protocol %v, got %v", proto.id, err)
+		}
+		if protoErr.Protocol != proto.id || protoErr.Version != "1.0.0" || protoErr.ClientOutdated != outdated {
+			t.Errorf("got protocol error %+v, expected protocol %v, version %q and client outdated %v", protoErr, proto.id, "1.0.0", outdated)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -175,6 +176,36 @@ func (pack *Pack) ReadAt(b []byte, off int64) (n int, err error) {
 	return pack.content.ReadAt(b, off)
 }
 
+// Open opens the file at the path passed in the resource pack. The path is relative to the root of the pack,
+// which is the directory holding its manifest.json, and uses forward slashes, for example
+// 'textures/blocks/stone.png'. An error wrapping os.ErrNotExist is returned if the pack has no such file.
+// The io.ReadCloser returned must be closed after use.
+func (pack *Pack) Open(name string) (io.ReadCloser, error) {
+	r, err := zip.NewReader(pack.content, pack.content.Size())
+	if err != nil {
+		return nil, fmt.Errorf("error opening zip reader: %v", err)
+	}
+	root := archiveRoot(r)
+	name = path.Clean(strings.TrimPrefix(strings.Replace(name, "\\", "/", -1), "/"))
+	for _, file := range r.File {
+		if file.Name == root+name {
+			return file.Open()
+		}
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// HasFile checks if the resource pack has a file at the path passed. Like Open, the path is relative to the
+// root of the pack.
+func (pack *Pack) HasFile(name string) bool {
+	f, err := pack.Open(name)
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+	return true
+}
+
 // Manifest returns the manifest found in the manifest.json of the resource pack. It contains information
 // about the pack such as its name.
 func (pack *Pack) Manifest() Manifest {
@@ -288,6 +319,24 @@ func (reader packReader) find(fileName string) (io.ReadCloser, error) {
 	return nil, fmt.Errorf("could not find '%v' in zip", fileName)
 }
 
+// archiveRoot returns the directory in the zip archive passed that holds the manifest.json of the resource
+// pack, including a trailing slash. If the manifest is in the root of the archive, an empty string is
+// returned.
+func archiveRoot(r *zip.Reader) string {
+	root, found := "", false
+	for _, file := range r.File {
+		if path.Base(file.Name) != "manifest.json" {
+			continue
+		}
+		// Resource packs may hold other manifests deeper down in the archive, so we pick the one closest to
+		// the root of the archive.
+		if dir := strings.TrimSuffix(file.Name, "manifest.json"); !found || len(dir) < len(root) {
+			root, found = dir, true
+		}
+	}
+	return root
+}
+
 // readManifest reads the manifest from the resource pack located at the path passed. If not found in the root
 // of the resource pack, it will also attempt to find it deeper down into the archive.
 func readManifest(path string) (*Manifest, error) {
@@ -0,0 +1,36 @@
+package resource
+
+import (
+	"io"
+	"os"
+)
+
+// Stack is an ordered stack of resource packs, such as the texture packs applied by a client. The first pack
+// in the Stack is the topmost pack: Its files take precedence over the files of packs lower in the Stack.
+type Stack []*Pack
+
+// Resolve finds the topmost pack in the Stack that has a file at the path passed. The path is relative to
+// the root of each pack, for example 'textures/blocks/stone.png'. If none of the packs have the file, Resolve
+// returns false.
+func (stack Stack) Resolve(name string) (*Pack, bool) {
+	for _, pack := range stack {
+		if pack.HasFile(name) {
+			return pack, true
+		}
+	}
+	return nil, false
+}
+
+// Open opens the file at the path passed in the topmost pack of the Stack that has it. An error wrapping
+// os.ErrNotExist is returned if none of the packs have the file. The io.ReadCloser returned must be closed
+// after use.
+func (stack Stack) Open(name string) (io.ReadCloser, error) {
+	for _, pack := range stack {
+		f, err := pack.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
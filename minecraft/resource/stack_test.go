@@ -0,0 +1,135 @@
+package resource
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// testPack creates a resource pack from a zip archive holding the files passed, mapped by their name in the
+// archive. The manifest of the pack is written to the directory root passed, which must be empty or end with
+// a slash.
+func testPack(t *testing.T, uuid, root string, files map[string]string) *Pack {
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	manifest := `{"format_version":1,"header":{"name":"test","description":"","uuid":"` + uuid + `","version":[1,0,0],"min_engine_version":[1,16,0]},"modules":[{"uuid":"643b347d-0449-432a-9f63-4603f098cb4f","description":"","type":"resources","version":[1,0,0]}]}`
+	f, err := w.Create(root + "manifest.json")
+	if err != nil {
+		t.Fatalf("error creating manifest: %v", err)
+	}
+	_, _ = f.Write([]byte(manifest))
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("error creating file %v: %v", name, err)
+		}
+		_, _ = f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing zip writer: %v", err)
+	}
+	pack, err := FromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("error compiling pack: %v", err)
+	}
+	return pack
+}
+
+// testStack returns a Stack of three packs. The middle pack is nested in a directory of its archive, and has
+// a manifest of a sub pack deeper down.
+func testStack(t *testing.T) Stack {
+	return Stack{
+		testPack(t, "304017b5-f1a4-4241-a702-d47c06d146cb", "", map[string]string{
+			"textures/stone.png": "top stone",
+		}),
+		testPack(t, "8a1f3c52-7d2e-4b6a-9c0d-5e4f3a2b1c0d", "middle/", map[string]string{
+			"middle/textures/stone.png":    "middle stone",
+			"middle/textures/dirt.png":     "middle dirt",
+			"middle/sub/manifest.json":     "{}",
+			"middle/sub/textures/sand.png": "middle sand",
+		}),
+		testPack(t, "b7e2d9a4-3c1f-4e8b-a6d5-0f9c8e7d6b5a", "", map[string]string{
+			"textures/grass.png": "bottom grass",
+			"textures/dirt.png":  "bottom dirt",
+		}),
+	}
+}
+
+func TestStackResolve(t *testing.T) {
+	stack := testStack(t)
+	tests := []struct {
+		name string
+		// pack is the index in the stack of the pack that the name resolves to, or -1 if none has it.
+		pack int
+	}{
+		{name: "textures/stone.png", pack: 0},
+		{name: "textures/dirt.png", pack: 1},
+		{name: "textures/grass.png", pack: 2},
+		{name: "/textures/grass.png", pack: 2},
+		{name: `textures\grass.png`, pack: 2},
+		{name: "sub/textures/sand.png", pack: 1},
+		{name: "textures/missing.png", pack: -1},
+		{name: "middle/textures/stone.png", pack: -1},
+	}
+	for _, test := range tests {
+		pack, ok := stack.Resolve(test.name)
+		if test.pack == -1 {
+			if ok {
+				t.Errorf("%v: resolved to %v, expected no pack", test.name, pack.UUID())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%v: not resolved, expected pack %v", test.name, test.pack)
+			continue
+		}
+		if pack != stack[test.pack] {
+			t.Errorf("%v: resolved to %v, expected pack %v (%v)", test.name, pack.UUID(), test.pack, stack[test.pack].UUID())
+		}
+	}
+}
+
+func TestStackOpen(t *testing.T) {
+	stack := testStack(t)
+	tests := []struct {
+		name string
+		// content is the content of the file opened, or empty if none of the packs have it.
+		content string
+	}{
+		{name: "textures/stone.png", content: "top stone"},
+		{name: "textures/dirt.png", content: "middle dirt"},
+		{name: "textures/grass.png", content: "bottom grass"},
+		{name: "sub/textures/sand.png", content: "middle sand"},
+		{name: "textures/missing.png"},
+		{name: "middle/textures/dirt.png"},
+	}
+	for _, test := range tests {
+		f, err := stack.Open(test.name)
+		if test.content == "" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%v: expected error wrapping os.ErrNotExist, got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: error opening file: %v", test.name, err)
+			continue
+		}
+		data, err := ioutil.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			t.Errorf("%v: error reading file: %v", test.name, err)
+			continue
+		}
+		if string(data) != test.content {
+			t.Errorf("%v: read %q, expected %q", test.name, data, test.content)
+		}
+	}
+
+	// An empty stack has no files.
+	if _, err := (Stack{}).Open("textures/stone.png"); !os.IsNotExist(err) {
+		t.Errorf("expected error wrapping os.ErrNotExist opening a file in an empty stack, got %v", err)
+	}
+}
@@ -2,37 +2,41 @@ package minecraft
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
 )
 
-// resourcePackQueue is used to aid in the handling of resource pack queueing and downloading. Only one
-// resource pack is downloaded at a time.
+// resourcePackQueue is used to aid in the handling of resource pack queueing and downloading. The chunks of
+// the resource packs sent may be requested in any order.
 type resourcePackQueue struct {
 	packs           []*resource.Pack
 	packsToDownload map[string]*resource.Pack
-	currentPack     *resource.Pack
-	currentOffset   uint64
+	// sendingPacks holds all resource packs for which a ResourcePackDataInfo packet was sent, indexed by
+	// their UUID. Chunks of these packs may be requested in any order and more than once.
+	sendingPacks map[string]*sendingPack
 
 	packAmount       int
-	downloadingPacks map[string]downloadingPack
+	downloadingPacks map[string]*downloadingPack
 	awaitingPacks    map[string]*downloadingPack
 }
 
-// downloadingPack is a resource pack that is being downloaded by a client connection.
-type downloadingPack struct {
-	buf           *bytes.Buffer
-	chunkSize     uint32
-	size          uint64
-	expectedIndex uint32
-	newFrag       chan []byte
+// sendingPack is a resource pack that is being sent to a client connection.
+type sendingPack struct {
+	pack       *resource.Pack
+	chunkCount uint32
 }
 
 // Request 'requests' all resource packs passed, provided they all exist in the resourcePackQueue. If not,
 // an error is returned.
 func (queue *resourcePackQueue) Request(packs []string) error {
 	queue.packsToDownload = make(map[string]*resource.Pack)
+	queue.sendingPacks = make(map[string]*sendingPack)
 	for _, packUUID := range packs {
 		found := false
 		for _, pack := range queue.packs {
@@ -51,14 +55,14 @@ func (queue *resourcePackQueue) Request(packs []string) error {
 	return nil
 }
 
-// NextPack assigns the next resource pack to the current pack and returns true if successful. If there were
-// no more packs to assign, false is returned. If ok is true, a packet with data info is returned.
+// NextPack assigns the next resource pack to the packs being sent and returns true if successful. If there
+// were no more packs to assign, false is returned. If ok is true, a packet with data info is returned.
 func (queue *resourcePackQueue) NextPack() (pk *packet.ResourcePackDataInfo, ok bool) {
 	for index, pack := range queue.packsToDownload {
 		delete(queue.packsToDownload, index)
 
-		queue.currentPack = pack
-		queue.currentOffset = 0
+		chunkCount := uint32(pack.DataChunkCount(packChunkSize))
+		queue.sendingPacks[pack.UUID()] = &sendingPack{pack: pack, chunkCount: chunkCount}
 		checksum := pack.Checksum()
 
 		var packType byte
@@ -77,7 +81,7 @@ func (queue *resourcePackQueue) NextPack() (pk *packet.ResourcePackDataInfo, ok
 		return &packet.ResourcePackDataInfo{
 			UUID:          pack.UUID(),
 			DataChunkSize: packChunkSize,
-			ChunkCount:    uint32(pack.DataChunkCount(packChunkSize)),
+			ChunkCount:    chunkCount,
 			Size:          uint64(pack.Len()),
 			Hash:          checksum[:],
 			PackType:      packType,
@@ -86,7 +90,160 @@ func (queue *resourcePackQueue) NextPack() (pk *packet.ResourcePackDataInfo, ok
 	return nil, false
 }
 
-// AllDownloaded checks if all resource packs in the queue are downloaded.
-func (queue *resourcePackQueue) AllDownloaded() bool {
-	return len(queue.packsToDownload) == 0
+// defaultMaxPackSize is the default maximum size of a single resource pack downloaded from a server.
+const defaultMaxPackSize = 256 << 20
+
+// maxChunkRequests is the maximum amount of times a single chunk of a resource pack is requested by a client
+// before the download is considered failed.
+const maxChunkRequests = 4
+
+// downloadingPack is a resource pack that is being downloaded by a client connection.
+type downloadingPack struct {
+	size       uint64
+	chunkSize  uint32
+	chunkCount uint32
+	newFrag    chan packChunk
+	// done is closed once the download of the pack is no longer in progress, after which chunks sent to
+	// newFrag are no longer received.
+	done chan struct{}
+	// checksum is the SHA256 checksum of the resource pack as sent by the server.
+	checksum []byte
+
+	// data holds the data of the resource pack if it is downloaded into memory. It is nil if the pack is
+	// downloaded to file.
+	data []byte
+	// file is the temporary file that the resource pack is downloaded into, if a directory to download to
+	// was set. It is nil if the pack is downloaded into memory.
+	file *os.File
+}
+
+// packChunk is a chunk of resource pack data received from the server.
+type packChunk struct {
+	index uint32
+	data  []byte
+}
+
+// init initialises the downloadingPack using the info found in the ResourcePackDataInfo packet passed. The
+// amount of chunk requests that may be in flight at the same time is equal to concurrency. If dir is
+// non-empty, the pack is downloaded to a temporary file in that directory. Otherwise, it is downloaded into
+// memory. An error is returned if the size of the pack exceeds maxSize.
+func (pack *downloadingPack) init(pk *packet.ResourcePackDataInfo, concurrency int, dir string, maxSize uint64) error {
+	if pk.DataChunkSize == 0 {
+		return fmt.Errorf("resource pack data chunk size must not be 0")
+	}
+	if pk.Size > maxSize {
+		return fmt.Errorf("resource pack size %v exceeds maximum of %v", pk.Size, maxSize)
+	}
+	pack.size = pk.Size
+	pack.chunkSize = pk.DataChunkSize
+	pack.checksum = pk.Hash
+	// We compute the chunk count from the size rather than trusting the ChunkCount in the packet, so that
+	// chunks can never be written outside of the pack's data.
+	pack.chunkCount = uint32((pk.Size + uint64(pk.DataChunkSize) - 1) / uint64(pk.DataChunkSize))
+	pack.newFrag = make(chan packChunk, concurrency*2)
+	pack.done = make(chan struct{})
+	if dir == "" {
+		pack.data = make([]byte, pack.size)
+		return nil
+	}
+	f, err := ioutil.TempFile(dir, "resource_pack_download-*.mcpack")
+	if err != nil {
+		return fmt.Errorf("error creating resource pack download file: %v", err)
+	}
+	pack.file = f
+	return nil
+}
+
+// expectedLen returns the length that the data of the chunk with the index passed is expected to have.
+func (pack *downloadingPack) expectedLen(index uint32) int {
+	offset := uint64(index) * uint64(pack.chunkSize)
+	if remaining := pack.size - offset; remaining < uint64(pack.chunkSize) {
+		return int(remaining)
+	}
+	return int(pack.chunkSize)
+}
+
+// write writes the chunk passed to the memory buffer or file of the downloadingPack.
+func (pack *downloadingPack) write(chunk packChunk) error {
+	offset := int64(chunk.index) * int64(pack.chunkSize)
+	if pack.file == nil {
+		copy(pack.data[offset:], chunk.data)
+		return nil
+	}
+	if _, err := pack.file.WriteAt(chunk.data, offset); err != nil {
+		return fmt.Errorf("error writing resource pack chunk to file: %v", err)
+	}
+	return nil
+}
+
+// verify checks if the SHA256 checksum of the data downloaded matches the checksum sent by the server. An
+// error is returned if it does not. If the server did not send a checksum, the data cannot be verified and
+// false is returned.
+func (pack *downloadingPack) verify() (bool, error) {
+	if len(pack.checksum) == 0 {
+		return false, nil
+	}
+	h := sha256.New()
+	if pack.file == nil {
+		_, _ = h.Write(pack.data)
+	} else if _, err := io.Copy(h, io.NewSectionReader(pack.file, 0, int64(pack.size))); err != nil {
+		return false, fmt.Errorf("error reading resource pack download file: %v", err)
+	}
+	if sum := h.Sum(nil); !bytes.Equal(sum, pack.checksum) {
+		return false, fmt.Errorf("checksum %x of resource pack data does not match checksum %x sent by the server", sum, pack.checksum)
+	}
+	return true, nil
+}
+
+// compile compiles the data downloaded into a resource pack. If the pack was downloaded to a file, the file is
+// removed after compiling.
+func (pack *downloadingPack) compile() (*resource.Pack, error) {
+	if pack.file == nil {
+		return resource.FromBytes(pack.data)
+	}
+	defer func() {
+		_ = os.Remove(pack.file.Name())
+	}()
+	if err := pack.file.Close(); err != nil {
+		return nil, fmt.Errorf("error closing resource pack download file: %v", err)
+	}
+	return resource.Compile(pack.file.Name())
+}
+
+// discard discards any data downloaded so far, removing the file that the pack was being downloaded to, if
+// any.
+func (pack *downloadingPack) discard() {
+	if pack.file != nil {
+		_ = pack.file.Close()
+		_ = os.Remove(pack.file.Name())
+	}
+}
+
+// chunkRequests keeps track of the chunk requests of a downloadingPack that are in flight, so that requests
+// that time out may be sent again.
+type chunkRequests struct {
+	timeout  time.Duration
+	sent     map[uint32]time.Time
+	attempts map[uint32]int
+}
+
+// add registers a request for the chunk index passed. An error is returned if the chunk was requested too
+// often already.
+func (requests *chunkRequests) add(index uint32) error {
+	requests.attempts[index]++
+	if requests.attempts[index] > maxChunkRequests {
+		return fmt.Errorf("chunk %v not received after %v requests", index, maxChunkRequests)
+	}
+	requests.sent[index] = time.Now()
+	return nil
+}
+
+// expired returns all chunk indices of which the request timed out.
+func (requests *chunkRequests) expired() (indices []uint32) {
+	for index, t := range requests.sent {
+		if time.Since(t) >= requests.timeout {
+			indices = append(indices, index)
+		}
+	}
+	return indices
 }
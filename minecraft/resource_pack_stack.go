@@ -0,0 +1,78 @@
+package minecraft
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/resource"
+)
+
+// ResourcePackStack is the order in which the resource packs of a connection are applied, as sent in the
+// ResourcePackStack packet during login. It may be obtained by calling Conn.ResourcePackStack().
+type ResourcePackStack struct {
+	// TexturePacks holds the texture packs on the stack, ordered as they were sent in the ResourcePackStack
+	// packet. The first pack is the topmost pack and takes precedence over the packs after it.
+	TexturePacks []StackedPack
+	// BehaviourPacks holds the behaviour packs on the stack, ordered as they were sent in the
+	// ResourcePackStack packet.
+	BehaviourPacks []StackedPack
+	// TexturePackRequired specifies if the client had to accept the texture packs of the server in order to
+	// join it.
+	TexturePackRequired bool
+	// Experimental specifies if the resource packs on the stack use experimental features.
+	Experimental bool
+	// BaseGameVersion is the vanilla version that the resource pack stack is based on.
+	BaseGameVersion string
+}
+
+// StackedPack is a resource pack on a ResourcePackStack.
+type StackedPack struct {
+	// UUID, Version and SubPackName identify the resource pack as sent in the ResourcePackStack packet.
+	UUID, Version, SubPackName string
+	// Pack is the resource pack with the UUID and version above. It is nil if the pack is one of the vanilla
+	// packs that clients always have, and therefore never need to download.
+	Pack *resource.Pack
+}
+
+// Textures returns a resource.Stack of the texture packs on the ResourcePackStack, which may be used to
+// resolve files through the stacked packs. Vanilla packs, which have no Pack set, are left out.
+func (stack ResourcePackStack) Textures() resource.Stack {
+	return packStack(stack.TexturePacks)
+}
+
+// Behaviours returns a resource.Stack of the behaviour packs on the ResourcePackStack, which may be used to
+// resolve files through the stacked packs. Vanilla packs, which have no Pack set, are left out.
+func (stack ResourcePackStack) Behaviours() resource.Stack {
+	return packStack(stack.BehaviourPacks)
+}
+
+// packStack returns a resource.Stack holding the packs of all StackedPacks passed that have one.
+func packStack(packs []StackedPack) resource.Stack {
+	stack := make(resource.Stack, 0, len(packs))
+	for _, pack := range packs {
+		if pack.Pack != nil {
+			stack = append(stack, pack.Pack)
+		}
+	}
+	return stack
+}
+
+// stackPacket returns a ResourcePackStack packet holding the packs on the stack.
+func (stack ResourcePackStack) stackPacket() *packet.ResourcePackStack {
+	pk := &packet.ResourcePackStack{
+		TexturePackRequired: stack.TexturePackRequired,
+		Experimental:        stack.Experimental,
+		BaseGameVersion:     stack.BaseGameVersion,
+	}
+	for _, pack := range stack.TexturePacks {
+		pk.TexturePacks = append(pk.TexturePacks, pack.stackPack())
+	}
+	for _, pack := range stack.BehaviourPacks {
+		pk.BehaviourPacks = append(pk.BehaviourPacks, pack.stackPack())
+	}
+	return pk
+}
+
+// stackPack converts the StackedPack to its protocol representation.
+func (pack StackedPack) stackPack() protocol.StackResourcePack {
+	return protocol.StackResourcePack{UUID: pack.UUID, Version: pack.Version, SubPackName: pack.SubPackName}
+}
@@ -0,0 +1,111 @@
+package minecraft
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultSendBufferSize is the maximum amount of bytes buffered for sending by a Conn if no size is set on
+// the Dialer or Listener: 16 MB.
+const defaultSendBufferSize = 1024 * 1024 * 16
+
+// defaultCloseTimeout is the maximum duration that closing a Conn spends writing the packets that remain.
+const defaultCloseTimeout = time.Second * 5
+
+// sendQueue is a queue of encoded packet batches that are waiting to be written to the underlying connection
+// of a Conn. Batches are pushed to it when the Conn flushes and it is drained by a separate goroutine, so
+// that a slow connection does not block the goroutine flushing packets. Its methods may be called from
+// multiple goroutines simultaneously.
+type sendQueue struct {
+	mu      sync.Mutex
+	batches []queuedBatch
+	// size is the total size in bytes of the packets of all batches in the queue.
+	size int
+
+	// notEmpty is sent a value when a batch is added to the queue. space is sent a value when a batch is
+	// removed from the queue.
+	notEmpty, space chan struct{}
+}
+
+// newSendQueue returns a new, empty sendQueue.
+func newSendQueue() *sendQueue {
+	return &sendQueue{notEmpty: make(chan struct{}, 1), space: make(chan struct{}, 1)}
+}
+
+// queuedBatch is an encoded batch held by a sendQueue.
+type queuedBatch struct {
+	data []byte
+	// size is the total size in bytes of the packets in the batch before they were encoded. The queue is
+	// limited in terms of these sizes, so that it is limited the same way as the packets buffered by a Conn,
+	// regardless of how well the batch compressed.
+	size int
+}
+
+// push adds a copy of the encoded batch passed to the queue, which held packets with a total size of the
+// size passed before encoding.
+func (queue *sendQueue) push(batch []byte, size int) {
+	queue.mu.Lock()
+	queue.batches = append(queue.batches, queuedBatch{data: append([]byte(nil), batch...), size: size})
+	queue.size += size
+	queue.mu.Unlock()
+
+	signal(queue.notEmpty)
+}
+
+// Len returns the total size in bytes of the packets of all batches currently in the queue.
+func (queue *sendQueue) Len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return queue.size
+}
+
+// drain writes all batches in the queue to the io.Writer passed, until the queue is empty or an error occurs.
+func (queue *sendQueue) drain(w io.Writer) error {
+	for {
+		queue.mu.Lock()
+		if len(queue.batches) == 0 {
+			queue.mu.Unlock()
+			return nil
+		}
+		b := queue.batches[0]
+		queue.batches[0] = queuedBatch{}
+		queue.batches = queue.batches[1:]
+		queue.mu.Unlock()
+
+		_, err := w.Write(b.data)
+
+		queue.mu.Lock()
+		queue.size -= b.size
+		queue.mu.Unlock()
+		signal(queue.space)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// waitSpace blocks until n more bytes fit in the queue without the total size exceeding the limit passed, the
+// deadline passed is reached or the done channel is closed. If the space was freed in time, ok is true. If
+// the deadline was reached, timeout is true. If n alone exceeds the limit, waitSpace returns once the queue
+// is empty.
+func (queue *sendQueue) waitSpace(n, limit int, d *deadline, done <-chan struct{}) (ok, timeout bool) {
+	for size := queue.Len(); size > 0 && size+n > limit; size = queue.Len() {
+		select {
+		case <-queue.space:
+		case <-d.wait():
+			return false, true
+		case <-done:
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// signal sends a value to the channel passed without blocking, if it does not yet hold one.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
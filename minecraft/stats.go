@@ -0,0 +1,205 @@
+package minecraft
+
+import (
+	"bytes"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"net"
+	"sync"
+	"time"
+)
+
+// ConnStats holds statistics of the traffic of a Conn. It may be obtained by calling Conn.Stats(), or, for
+// all connections of a Listener combined, by calling Listener.Stats().
+type ConnStats struct {
+	// PacketsIn and PacketsOut are the amount of packets received and sent.
+	PacketsIn, PacketsOut uint64
+	// BytesIn and BytesOut are the amount of bytes of packet data received and sent, before compression and
+	// encryption. It includes the header of each packet.
+	BytesIn, BytesOut uint64
+	// WireBytesIn and WireBytesOut are the amount of bytes read from and written to the underlying
+	// connection, which is after compression and encryption.
+	WireBytesIn, WireBytesOut uint64
+	// Batches is the amount of batches of packets sent by calls to Conn.Flush(), either explicitly or by the
+	// Conn itself.
+	Batches uint64
+	// DecodeFailures is the amount of packets received that could not be decoded.
+	DecodeFailures uint64
+	// PacketsInByID and PacketsOutByID hold the amount of packets received and sent for each packet ID.
+	PacketsInByID, PacketsOutByID map[uint32]uint64
+	// StageDurations holds the time spent in each stage of the login sequence. For a Conn of which the login
+	// sequence is not yet complete, the time spent in the current stage so far is included.
+	StageDurations map[DialStage]time.Duration
+}
+
+// add adds the statistics of the ConnStats passed to the ConnStats.
+func (stats *ConnStats) add(other ConnStats) {
+	stats.PacketsIn += other.PacketsIn
+	stats.PacketsOut += other.PacketsOut
+	stats.BytesIn += other.BytesIn
+	stats.BytesOut += other.BytesOut
+	stats.WireBytesIn += other.WireBytesIn
+	stats.WireBytesOut += other.WireBytesOut
+	stats.Batches += other.Batches
+	stats.DecodeFailures += other.DecodeFailures
+	for id, n := range other.PacketsInByID {
+		stats.PacketsInByID[id] += n
+	}
+	for id, n := range other.PacketsOutByID {
+		stats.PacketsOutByID[id] += n
+	}
+	for stage, d := range other.StageDurations {
+		stats.StageDurations[stage] += d
+	}
+}
+
+// newConnStats returns a ConnStats with all of its maps initialised.
+func newConnStats() ConnStats {
+	return ConnStats{
+		PacketsInByID:  make(map[uint32]uint64),
+		PacketsOutByID: make(map[uint32]uint64),
+		StageDurations: make(map[DialStage]time.Duration),
+	}
+}
+
+// connStats keeps track of the statistics of a Conn. Its methods may be called from multiple goroutines
+// simultaneously.
+type connStats struct {
+	mu    sync.Mutex
+	stats ConnStats
+
+	// inLogin specifies if the Conn is currently in the login sequence, in which case stage holds the stage
+	// it is in and stageStart the time at which the stage was entered. loginEnded is set once the login
+	// sequence was completed or the Conn was closed, after which no more stages are entered.
+	inLogin    bool
+	loginEnded bool
+	stage      DialStage
+	stageStart time.Time
+}
+
+// newStats returns a new connStats with all of its maps initialised.
+func newStats() *connStats {
+	return &connStats{stats: newConnStats()}
+}
+
+// packetIn registers a packet with the data passed as received.
+func (s *connStats) packetIn(data []byte) {
+	id, ok := packetID(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.PacketsIn++
+	s.stats.BytesIn += uint64(len(data))
+	if ok {
+		s.stats.PacketsInByID[id]++
+	}
+}
+
+// packetOut registers a packet with the data passed as sent.
+func (s *connStats) packetOut(data []byte) {
+	id, ok := packetID(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.PacketsOut++
+	s.stats.BytesOut += uint64(len(data))
+	if ok {
+		s.stats.PacketsOutByID[id]++
+	}
+}
+
+// batch registers a batch of packets as sent.
+func (s *connStats) batch() {
+	s.mu.Lock()
+	s.stats.Batches++
+	s.mu.Unlock()
+}
+
+// decodeFailure registers a packet that failed to decode.
+func (s *connStats) decodeFailure() {
+	s.mu.Lock()
+	s.stats.DecodeFailures++
+	s.mu.Unlock()
+}
+
+// wireIn registers n bytes read from the underlying connection.
+func (s *connStats) wireIn(n int) {
+	s.mu.Lock()
+	s.stats.WireBytesIn += uint64(n)
+	s.mu.Unlock()
+}
+
+// wireOut registers n bytes written to the underlying connection.
+func (s *connStats) wireOut(n int) {
+	s.mu.Lock()
+	s.stats.WireBytesOut += uint64(n)
+	s.mu.Unlock()
+}
+
+// enterStage registers the stage passed as entered at time t. The time since the previous stage was entered is
+// added to the duration of that stage.
+func (s *connStats) enterStage(stage DialStage, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loginEnded {
+		return
+	}
+	if s.inLogin {
+		s.stats.StageDurations[s.stage] += t.Sub(s.stageStart)
+	}
+	s.inLogin, s.stage, s.stageStart = true, stage, t
+}
+
+// endLogin registers the login sequence as ended, either because it was completed or because the Conn was
+// closed before that. The time since the last stage was entered is added to the duration of that stage.
+func (s *connStats) endLogin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inLogin {
+		s.stats.StageDurations[s.stage] += time.Since(s.stageStart)
+		s.inLogin = false
+	}
+	s.loginEnded = true
+}
+
+// snapshot returns a copy of the current statistics.
+func (s *connStats) snapshot() ConnStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := newConnStats()
+	stats.add(s.stats)
+	if s.inLogin {
+		stats.StageDurations[s.stage] += time.Since(s.stageStart)
+	}
+	return stats
+}
+
+// packetID reads the packet ID from the header of the packet data passed. If the header could not be read,
+// false is returned.
+func packetID(data []byte) (uint32, bool) {
+	header := &packet.Header{}
+	if err := header.Read(&protocol.Reader{Buffer: bytes.NewBuffer(data)}); err != nil {
+		return 0, false
+	}
+	return header.PacketID, true
+}
+
+// statsConn wraps around a net.Conn to count the bytes read from and written to it.
+type statsConn struct {
+	net.Conn
+	stats *connStats
+}
+
+// Read ...
+func (conn statsConn) Read(b []byte) (n int, err error) {
+	n, err = conn.Conn.Read(b)
+	conn.stats.wireIn(n)
+	return n, err
+}
+
+// Write ...
+func (conn statsConn) Write(b []byte) (n int, err error) {
+	n, err = conn.Conn.Write(b)
+	conn.stats.wireOut(n)
+	return n, err
+}
@@ -0,0 +1,214 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// maxSubClientID is the highest ID that a sub-client may have. The ID is encoded in two bits of the header
+// of a packet, and 0 is the ID of the primary client, so up to three sub-clients may join over a single
+// connection.
+const maxSubClientID = 3
+
+// SubClientID returns the ID of the sub-client that the Conn represents, which is 1, 2 or 3. If the Conn is
+// not a sub-client, but the primary client of its connection, 0 is returned.
+func (conn *Conn) SubClientID() byte {
+	return conn.subClientID
+}
+
+// SubClients returns the sub-clients that joined over the Conn and are still connected, including those that
+// are still logging in. The order of the sub-clients in the slice returned is undefined.
+func (conn *Conn) SubClients() []*Conn {
+	conn.subClientMu.Lock()
+	defer conn.subClientMu.Unlock()
+
+	subs := make([]*Conn, 0, len(conn.subClients))
+	for _, sub := range conn.subClients {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// transport returns the Conn that owns the underlying connection of the Conn. For a sub-client, this is the
+// Conn it joined over. For other connections, it is the Conn itself.
+func (conn *Conn) transport() *Conn {
+	if conn.parent != nil {
+		return conn.parent
+	}
+	return conn
+}
+
+// newSubClient creates a sub-client of the Conn with the ID passed and adds it to the sub-clients of the
+// Conn. If the ID passed is 0, the lowest ID that is not yet in use is picked. The key passed is used to
+// sign the login request of a sub-client dialed. newSubClient must only be called while holding the
+// subClientMu.
+func (conn *Conn) newSubClient(id byte, key *ecdsa.PrivateKey) (*Conn, error) {
+	if conn.subClients == nil {
+		conn.subClients = make(map[byte]*Conn)
+	}
+	for i := byte(1); id == 0 && i <= maxSubClientID; i++ {
+		if _, ok := conn.subClients[i]; !ok {
+			id = i
+		}
+	}
+	if id == 0 {
+		return nil, fmt.Errorf("connection already has the maximum of %v sub-clients", maxSubClientID)
+	}
+	closeCtx, cancel := context.WithCancel(conn.closeCtx)
+	proto := conn.Protocol()
+	sub := &Conn{
+		conn:          conn.conn,
+		parent:        conn,
+		subClientID:   id,
+		clientSide:    conn.clientSide,
+		login:         make(chan error, 1),
+		readDeadline:  newDeadline(),
+		writeDeadline: newDeadline(),
+		stats:         newStats(),
+		proto:         proto,
+		pool:          proto.Packets(),
+		packets:       make(chan []byte, 256),
+		writeBuf:      bytes.NewBuffer(make([]byte, 0, 1024)),
+		close:         cancel,
+		closeCtx:      closeCtx,
+		spawn:         make(chan bool),
+		privateKey:    key,
+		log:           conn.log.With("subClient", id),
+		chunkRadius:   16,
+
+		authEnabled:          conn.authEnabled,
+		admitFunc:            conn.admitFunc,
+		packetFunc:           conn.packetFunc,
+		cacheEnabled:         conn.cacheEnabled,
+		sendPacketViolations: conn.sendPacketViolations,
+		ownedPackets:         conn.ownedPackets,
+		decodeLimits:         conn.decodeLimits,
+		// Sub-clients use the resource packs that were already applied by the primary client.
+		resourcePacks: conn.ResourcePacks(),
+		packStack:     conn.ResourcePackStack(),
+	}
+	sub.gameData.WorldName = conn.gameData.WorldName
+	sub.disconnectMessage.Store("")
+	sub.waitingForSpawn.Store(false)
+	if conn.clientSide {
+		sub.expect(packet.IDPlayStatus)
+	} else {
+		sub.expect(packet.IDSubClientLogin)
+	}
+	sub.setStage(DialStageHandshake)
+
+	conn.subClients[id] = sub
+	return sub, nil
+}
+
+// removeSubClient removes the sub-client passed from the sub-clients of the Conn.
+func (conn *Conn) removeSubClient(sub *Conn) {
+	conn.subClientMu.Lock()
+	defer conn.subClientMu.Unlock()
+	if conn.subClients[sub.subClientID] == sub {
+		delete(conn.subClients, sub.subClientID)
+	}
+}
+
+// closeSubClient closes the sub-client without notifying the other end and removes it from the sub-clients
+// of the Conn it joined over.
+func (conn *Conn) closeSubClient() {
+	conn.close()
+	conn.parent.removeSubClient(conn)
+}
+
+// subClientOf returns the ID of the sub-client that the packet data passed belongs to. For packets that
+// belong to the primary client, or packets of which the header could not be read, 0 is returned.
+func (conn *Conn) subClientOf(data []byte) byte {
+	header := &packet.Header{}
+	if err := header.Read(&protocol.Reader{Buffer: bytes.NewBuffer(data)}); err != nil {
+		return 0
+	}
+	if conn.clientSide {
+		return header.TargetSubClient
+	}
+	return header.SenderSubClient
+}
+
+// handleSubClientPacket handles a packet received for the sub-client with the ID passed. If no such
+// sub-client exists and the packet is a SubClientLogin packet sent to a server side connection, the
+// sub-client is created. Errors that occur while handling the packet only close the sub-client, so that the
+// Conn it joined over remains connected.
+func (conn *Conn) handleSubClientPacket(id byte, data []byte) error {
+	conn.subClientMu.Lock()
+	sub, ok := conn.subClients[id]
+	created := false
+	if !ok && !conn.clientSide && conn.loggedIn {
+		if pkID, _ := packetID(data); pkID == packet.IDSubClientLogin {
+			sub, _ = conn.newSubClient(id, nil)
+			created = true
+		}
+	}
+	conn.subClientMu.Unlock()
+
+	if sub == nil {
+		conn.log.Debug("dropping packet of unknown sub-client", conn.logContext("subClient", id)...)
+		return nil
+	}
+	if created && conn.subClientFunc != nil {
+		conn.subClientFunc(sub)
+		if isClosed(sub.closeCtx.Done()) {
+			// The sub-client was refused by the function.
+			return nil
+		}
+	}
+	loggedInBefore := sub.loggedIn
+	err := sub.handleIncoming(data)
+	if err != nil {
+		sub.logHandleError(err)
+		_ = sub.Close()
+	} else if pkID, _ := packetID(data); pkID == packet.IDDisconnect && loggedInBefore {
+		// The other end closed the sub-client. Unlike the Conn it joined over, the sub-client has no
+		// connection of its own that is closed, so we close it here.
+		sub.closeSubClient()
+	}
+	if !loggedInBefore {
+		if sub.loggedIn {
+			sub.loginDone(nil)
+		} else if isClosed(sub.closeCtx.Done()) {
+			if err == nil {
+				err = fmt.Errorf("connection closed")
+				if msg := sub.disconnectMessage.Load().(string); msg != "" {
+					err = fmt.Errorf("disconnected while connecting: %v", msg)
+				}
+			}
+			sub.loginDone(err)
+		}
+	}
+	return nil
+}
+
+// handleSubClientLogin handles an incoming SubClientLogin packet. Like the Login packet, it holds a login
+// request which is verified and decoded. The sub-client shares the encryption and resource packs of the
+// connection it joined over, so it is logged in immediately after.
+func (conn *Conn) handleSubClientLogin(pk *packet.SubClientLogin) error {
+	if conn.parent == nil {
+		return fmt.Errorf("SubClientLogin packet received for primary client")
+	}
+	if _, err := conn.verifyLogin(pk.ConnectionRequest); err != nil {
+		return err
+	}
+	if err := conn.WritePacket(&packet.PlayStatus{Status: packet.PlayStatusLoginSuccess}); err != nil {
+		return fmt.Errorf("error sending play status login success: %v", err)
+	}
+	conn.loggedIn = true
+	conn.stats.endLogin()
+	return nil
+}
+
+// loginDone passes the result of the login sequence of a sub-client to the goroutine waiting for it.
+func (conn *Conn) loginDone(err error) {
+	select {
+	case conn.login <- err:
+	default:
+	}
+}
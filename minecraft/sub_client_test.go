@@ -0,0 +1,127 @@
+package minecraft
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// waitFor calls f until it returns true, failing the test with the message passed if it does not within the
+// test timeout.
+func waitFor(t *testing.T, message string, f func() bool) {
+	deadline := time.Now().Add(testTimeout)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// acceptConn accepts a connection from the channels passed, as returned by acceptAndStart.
+func acceptConn(t *testing.T, conns <-chan *Conn, errs <-chan error) *Conn {
+	select {
+	case conn := <-conns:
+		return conn
+	case err := <-errs:
+		t.Fatalf("error accepting connection: %v", err)
+	case <-time.After(testTimeout):
+		t.Fatalf("no connection accepted")
+	}
+	return nil
+}
+
+func TestMemorySubClient(t *testing.T) {
+	// Text packets are recorded with the sub-client IDs of their header, as read by both ends.
+	var mu sync.Mutex
+	var headers []packet.Header
+	recordText := func(header packet.Header, _ []byte, _, _ net.Addr) {
+		if header.PacketID == packet.IDText {
+			mu.Lock()
+			headers = append(headers, header)
+			mu.Unlock()
+		}
+	}
+	lastHeader := func() packet.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers[len(headers)-1]
+	}
+
+	listener := &Listener{AuthenticationDisabled: true, PacketFunc: recordText}
+	address := listenMemory(t, listener)
+	defer listener.Close()
+	dialer := Dialer{ErrorLog: NopLogger(), PacketFunc: recordText}
+
+	conns, errs := acceptAndStart(listener, GameData{EntityRuntimeID: 1})
+	client, err := dialer.DialTimeout("memory", address, testTimeout)
+	if err != nil {
+		t.Fatalf("error dialing listener: %v", err)
+	}
+	defer client.Close()
+	if err := client.DoSpawn(); err != nil {
+		t.Fatalf("error spawning client: %v", err)
+	}
+	server := acceptConn(t, conns, errs)
+
+	// The sub-client is accepted by the Listener as a *Conn of its own.
+	conns, errs = acceptAndStart(listener, GameData{EntityRuntimeID: 2})
+	sub, err := dialer.DialSubClient(client)
+	if err != nil {
+		t.Fatalf("error dialing sub-client: %v", err)
+	}
+	if err := sub.DoSpawn(); err != nil {
+		t.Fatalf("error spawning sub-client: %v", err)
+	}
+	serverSub := acceptConn(t, conns, errs)
+	if sub.SubClientID() != 1 || serverSub.SubClientID() != 1 {
+		t.Fatalf("expected sub-client ID 1 on both ends, got %v and %v", sub.SubClientID(), serverSub.SubClientID())
+	}
+	if subs := server.SubClients(); len(subs) != 1 || subs[0] != serverSub {
+		t.Fatalf("sub-client accepted was not a sub-client of the primary connection: %v", subs)
+	}
+	if n := len(listener.Conns()); n != 2 {
+		t.Fatalf("expected 2 connections accepted by the listener, got %v", n)
+	}
+
+	// Packets are routed to the sub-client on both ends using the sub-client IDs of the header.
+	_ = sub.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "from sub-client"})
+	_ = sub.Flush()
+	if msg := readText(t, serverSub); msg != "from sub-client" {
+		t.Errorf("server side sub-client read %q, expected %q", msg, "from sub-client")
+	}
+	if header := lastHeader(); header.SenderSubClient != 1 || header.TargetSubClient != 0 {
+		t.Errorf("packet written by sub-client had sender %v and target %v, expected 1 and 0", header.SenderSubClient, header.TargetSubClient)
+	}
+	_ = serverSub.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "to sub-client"})
+	_ = serverSub.Flush()
+	if msg := readText(t, sub); msg != "to sub-client" {
+		t.Errorf("client side sub-client read %q, expected %q", msg, "to sub-client")
+	}
+	if header := lastHeader(); header.SenderSubClient != 0 || header.TargetSubClient != 1 {
+		t.Errorf("packet written to sub-client had sender %v and target %v, expected 0 and 1", header.SenderSubClient, header.TargetSubClient)
+	}
+
+	// Closing the sub-client leaves the primary connection open and removes the sub-client on the other end.
+	if err := sub.Close(); err != nil {
+		t.Fatalf("error closing sub-client: %v", err)
+	}
+	waitFor(t, "server side sub-client was not closed", func() bool {
+		return isClosed(serverSub.closeCtx.Done())
+	})
+	waitFor(t, "sub-client was not removed from the listener", func() bool {
+		return len(listener.Conns()) == 1 && atomic.LoadInt32(listener.playerCount) == 1
+	})
+	if subs := server.SubClients(); len(subs) != 0 {
+		t.Errorf("sub-client not removed from the primary connection: %v", subs)
+	}
+	_ = client.WritePacket(&packet.Text{TextType: packet.TextTypeRaw, Message: "still connected"})
+	_ = client.Flush()
+	if msg := readText(t, server); msg != "still connected" {
+		t.Errorf("server read %q, expected %q", msg, "still connected")
+	}
+}
@@ -0,0 +1,424 @@
+package minecraft
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wsNetwork implements a network over WebSocket connections, as described in RFC 6455. Every batch is sent as
+// a single binary message, so that the boundaries of batches are preserved.
+// Addresses are either of the form 'host:port' or a URL of the form 'ws://host:port/path'. When listening on
+// an address without a path, connections are accepted on any path. Secure WebSockets ('wss') are not
+// supported: TLS may be terminated by a proxy in front of the Listener instead.
+type wsNetwork struct{}
+
+const (
+	// wsGUID is the GUID appended to the key of a WebSocket handshake to produce the accept key of the
+	// response.
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// wsHandshakeTimeout is the maximum duration that the handshake of a WebSocket connection accepted may
+	// take before the connection is closed.
+	wsHandshakeTimeout = time.Second * 10
+)
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+// Dial ...
+func (wsNetwork) Dial(ctx context.Context, address string) (net.Conn, error) {
+	host, path, err := wsAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = "/"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	// The handshake is aborted when the context is cancelled by closing the connection.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+path, nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", encodedKey)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, wsDialErr(ctx, fmt.Errorf("error writing websocket handshake: %v", err))
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, wsDialErr(ctx, fmt.Errorf("error reading websocket handshake response: %v", err))
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, fmt.Errorf("websocket handshake refused: %v", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(encodedKey) {
+		_ = conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed: invalid Sec-WebSocket-Accept header")
+	}
+	return &wsConn{Conn: conn, r: r, client: true}, nil
+}
+
+// Listen ...
+func (wsNetwork) Listen(address string) (net.Listener, error) {
+	host, path, err := wsAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	listener := &wsListener{Listener: l, path: path, incoming: make(chan net.Conn), closed: make(chan struct{})}
+	go listener.listen()
+	return listener, nil
+}
+
+// wsAddress parses the address passed into the host to connect to or listen on, and the path of the
+// WebSocket endpoint, which is empty if the address did not hold one.
+func wsAddress(address string) (host, path string, err error) {
+	if !strings.Contains(address, "://") {
+		return address, "", nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("error parsing websocket address: %v", err)
+	}
+	if u.Scheme != "ws" {
+		return "", "", fmt.Errorf("unsupported websocket scheme %v: only ws is supported", u.Scheme)
+	}
+	return u.Host, u.Path, nil
+}
+
+// wsAcceptKey returns the value of the Sec-WebSocket-Accept header that a server responds with to a
+// handshake with the key passed.
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsDialErr returns the error of the context passed if it was cancelled, as that is the reason of the error
+// passed, or the error passed otherwise.
+func wsDialErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// wsListener implements a net.Listener that accepts WebSocket connections. The handshakes of connections are
+// performed concurrently, so that a slow client does not hold up others.
+type wsListener struct {
+	net.Listener
+	path string
+
+	incoming  chan net.Conn
+	err       error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for and returns the next connection that completed its WebSocket handshake. If the listener
+// is closed, the error that the underlying net.Listener returned is returned.
+func (listener *wsListener) Accept() (net.Conn, error) {
+	conn, ok := <-listener.incoming
+	if !ok {
+		return nil, listener.err
+	}
+	return conn, nil
+}
+
+// Close closes the listener. Connections that are still performing their handshake are closed.
+func (listener *wsListener) Close() error {
+	listener.closeOnce.Do(func() {
+		close(listener.closed)
+	})
+	return listener.Listener.Close()
+}
+
+// listen accepts connections from the underlying net.Listener and starts their handshake, until the
+// listener is closed.
+func (listener *wsListener) listen() {
+	for {
+		conn, err := listener.Listener.Accept()
+		if err != nil {
+			listener.err = err
+			close(listener.incoming)
+			return
+		}
+		go func() {
+			wsConn, err := listener.handshake(conn)
+			if err != nil {
+				_ = conn.Close()
+				return
+			}
+			select {
+			case listener.incoming <- wsConn:
+			case <-listener.closed:
+				_ = conn.Close()
+			}
+		}()
+	}
+}
+
+// handshake performs the server side of the WebSocket handshake of the connection passed.
+func (listener *wsListener) handshake(conn net.Conn) (net.Conn, error) {
+	_ = conn.SetDeadline(time.Now().Add(wsHandshakeTimeout))
+	r := bufio.NewReader(conn)
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading websocket handshake: %v", err)
+	}
+	_ = req.Body.Close()
+	if listener.path != "" && req.URL.Path != listener.path {
+		wsRefuse(conn, http.StatusNotFound)
+		return nil, fmt.Errorf("websocket handshake for unknown path %v", req.URL.Path)
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if req.Method != http.MethodGet || !wsHeaderHas(req.Header, "Connection", "upgrade") ||
+		!wsHeaderHas(req.Header, "Upgrade", "websocket") || req.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		wsRefuse(conn, http.StatusBadRequest)
+		return nil, fmt.Errorf("invalid websocket handshake")
+	}
+	if _, err := fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %v\r\n\r\n", wsAcceptKey(key)); err != nil {
+		return nil, fmt.Errorf("error writing websocket handshake response: %v", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &wsConn{Conn: conn, r: r}, nil
+}
+
+// wsRefuse writes an HTTP response with the status code passed to refuse a WebSocket handshake.
+func wsRefuse(conn net.Conn, status int) {
+	_, _ = fmt.Fprintf(conn, "HTTP/1.1 %v %v\r\nConnection: close\r\nContent-Length: 0\r\n\r\n", status, http.StatusText(status))
+}
+
+// wsHeaderHas checks if the comma separated values of the header with the name passed hold the value passed,
+// ignoring case.
+func wsHeaderHas(header http.Header, name, value string) bool {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn implements a net.Conn over a WebSocket connection. Each call to Write sends a binary message, and
+// each call to Read returns the data of one message.
+type wsConn struct {
+	net.Conn
+	closeTracker
+	r *bufio.Reader
+	// client specifies if the connection is the client side of the WebSocket connection. Clients must mask
+	// the frames they send, and servers must not.
+	client bool
+
+	writeMu sync.Mutex
+	buf     []byte
+}
+
+// Read reads the data of one binary message into the byte slice passed. Control frames received are handled
+// while reading. If the message does not fit in the byte slice, or if a frame that is not valid at its
+// position in the message is received, an error is returned. If the other end closed the WebSocket
+// connection, io.EOF is returned. If the connection was closed by us, errClosed is returned.
+func (conn *wsConn) Read(b []byte) (n int, err error) {
+	n, err = conn.readMessage(b)
+	return n, conn.closedErr(err)
+}
+
+// readMessage reads the data of one binary message into the byte slice passed, as described for Read.
+func (conn *wsConn) readMessage(b []byte) (n int, err error) {
+	var header [14]byte
+	// started specifies if the first frame of the message was read, after which only continuation frames and
+	// control frames may follow.
+	started := false
+	for {
+		if _, err := io.ReadFull(conn.r, header[:2]); err != nil {
+			if err == io.EOF {
+				return 0, err
+			}
+			return 0, fmt.Errorf("error reading websocket frame header: %w", err)
+		}
+		fin, opcode, masked := header[0]&0x80 != 0, header[0]&0x0f, header[1]&0x80 != 0
+		if header[0]&0x70 != 0 {
+			// No extensions are negotiated, so the reserved bits must not be set.
+			return 0, fmt.Errorf("websocket frame has reserved bits set: %08b", header[0])
+		}
+		length := uint64(header[1] & 0x7f)
+		switch length {
+		case 126:
+			if _, err := io.ReadFull(conn.r, header[2:4]); err != nil {
+				return 0, fmt.Errorf("error reading websocket frame length: %w", err)
+			}
+			length = uint64(binary.BigEndian.Uint16(header[2:4]))
+		case 127:
+			if _, err := io.ReadFull(conn.r, header[2:10]); err != nil {
+				return 0, fmt.Errorf("error reading websocket frame length: %w", err)
+			}
+			length = binary.BigEndian.Uint64(header[2:10])
+		}
+		if masked == conn.client {
+			return 0, fmt.Errorf("websocket frame masking invalid: masked=%v", masked)
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(conn.r, mask[:]); err != nil {
+				return 0, fmt.Errorf("error reading websocket frame mask: %w", err)
+			}
+		}
+
+		if opcode >= wsOpClose {
+			// Control frames may be sent in between the frames of a message, and must have a payload of 125
+			// bytes or less.
+			if !fin || length > 125 {
+				return 0, fmt.Errorf("invalid websocket control frame of %v bytes", length)
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(conn.r, payload); err != nil {
+				return 0, fmt.Errorf("error reading websocket control frame: %w", err)
+			}
+			wsMask(payload, mask)
+			switch opcode {
+			case wsOpPing:
+				if err := conn.writeFrame(wsOpPong, payload); err != nil {
+					return 0, err
+				}
+			case wsOpClose:
+				// We echo the close frame to complete the closing handshake.
+				_ = conn.writeFrame(wsOpClose, payload)
+				return 0, io.EOF
+			}
+			continue
+		}
+		switch opcode {
+		case wsOpBinary:
+			if started {
+				return 0, fmt.Errorf("websocket message started before the previous message was finished")
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				return 0, fmt.Errorf("websocket continuation frame without a message to continue")
+			}
+		case wsOpText:
+			// Batches are always sent in binary messages.
+			return 0, fmt.Errorf("unexpected websocket text message")
+		default:
+			return 0, fmt.Errorf("unknown websocket opcode %x", opcode)
+		}
+		if length > uint64(len(b)-n) {
+			return 0, fmt.Errorf("websocket message exceeds the maximum of %v bytes", len(b))
+		}
+		frame := b[n : n+int(length)]
+		if _, err := io.ReadFull(conn.r, frame); err != nil {
+			return 0, fmt.Errorf("error reading websocket frame: %w", err)
+		}
+		wsMask(frame, mask)
+		n += int(length)
+		if fin {
+			return n, nil
+		}
+	}
+}
+
+// Write writes the data passed as a single binary message.
+func (conn *wsConn) Write(b []byte) (n int, err error) {
+	if err := conn.writeFrame(wsOpBinary, b); err != nil {
+		return 0, conn.closedErr(err)
+	}
+	return len(b), nil
+}
+
+// Close sends a close frame to the other end of the connection and closes the underlying net.Conn.
+func (conn *wsConn) Close() error {
+	// 1000 is the status code of a normal closure.
+	_ = conn.writeFrame(wsOpClose, []byte{0x03, 0xe8})
+	conn.markClosed()
+	return conn.Conn.Close()
+}
+
+// writeFrame writes a single, final frame with the opcode and payload passed to the underlying net.Conn.
+func (conn *wsConn) writeFrame(opcode byte, payload []byte) error {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+
+	conn.buf = append(conn.buf[:0], 0x80|opcode)
+	var maskBit byte
+	if conn.client {
+		maskBit = 0x80
+	}
+	switch l := len(payload); {
+	case l <= 125:
+		conn.buf = append(conn.buf, maskBit|byte(l))
+	case l <= 0xffff:
+		conn.buf = append(conn.buf, maskBit|126, byte(l>>8), byte(l))
+	default:
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(l))
+		conn.buf = append(append(conn.buf, maskBit|127), length[:]...)
+	}
+	if !conn.client {
+		conn.buf = append(conn.buf, payload...)
+	} else {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		conn.buf = append(conn.buf, mask[:]...)
+		start := len(conn.buf)
+		conn.buf = append(conn.buf, payload...)
+		wsMask(conn.buf[start:], mask)
+	}
+	_, err := conn.Conn.Write(conn.buf)
+	return err
+}
+
+// wsMask masks or unmasks the data passed in place using the masking key passed.
+func wsMask(data []byte, mask [4]byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
@@ -0,0 +1,206 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+)
+
+// wsTestMask is the masking key of the frames produced by wsFrame.
+var wsTestMask = [4]byte{0x12, 0x34, 0x56, 0x78}
+
+// wsFrame returns a frame with the opcode and payload passed, masked like frames sent by a client. The
+// payload must be shorter than 64 KB.
+func wsFrame(fin bool, opcode byte, payload []byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	if len(payload) > 125 {
+		frame = []byte{first, 0x80 | 126, byte(len(payload) >> 8), byte(len(payload))}
+	}
+	frame = append(frame, wsTestMask[:]...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	wsMask(frame[start:], wsTestMask)
+	return frame
+}
+
+// newWSTestConn returns the server side of a WebSocket connection over a TCP connection on the loopback
+// interface and the raw other end of it, to which frames may be written directly.
+func newWSTestConn(t *testing.T) (*wsConn, net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := l.Accept()
+		accepted <- conn
+	}()
+	b, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	a := <-accepted
+	if a == nil {
+		t.Fatalf("connection was not accepted")
+	}
+	return &wsConn{Conn: a, r: bufio.NewReader(a)}, b
+}
+
+// readRaw reads one frame written by the other end from the raw net.Conn passed.
+func readRaw(t *testing.T, conn net.Conn) []byte {
+	b := make([]byte, 256)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatalf("error reading from raw connection: %v", err)
+	}
+	return b[:n]
+}
+
+func TestWebSocketFragmentation(t *testing.T) {
+	conn, raw := newWSTestConn(t)
+	defer conn.Close()
+	defer raw.Close()
+
+	for _, frame := range [][]byte{
+		wsFrame(false, wsOpBinary, []byte("abc")),
+		// Control frames may be sent in between the frames of a message.
+		wsFrame(true, wsOpPing, []byte("ping")),
+		wsFrame(false, wsOpContinuation, []byte("de")),
+		wsFrame(true, wsOpContinuation, []byte("f")),
+		wsFrame(true, wsOpBinary, []byte("ghi")),
+	} {
+		_, _ = raw.Write(frame)
+	}
+
+	b := make([]byte, 64)
+	for _, expected := range []string{"abcdef", "ghi"} {
+		n, err := conn.Read(b)
+		if err != nil {
+			t.Fatalf("error reading message: %v", err)
+		}
+		if string(b[:n]) != expected {
+			t.Errorf("read message %q, expected %q", b[:n], expected)
+		}
+	}
+	if pong, expected := readRaw(t, raw), append([]byte{0x80 | wsOpPong, 4}, "ping"...); !bytes.Equal(pong, expected) {
+		t.Errorf("got pong frame %x, expected %x", pong, expected)
+	}
+}
+
+func TestWebSocketInvalidFrames(t *testing.T) {
+	tests := map[string][][]byte{
+		"continuation without message": {wsFrame(true, wsOpContinuation, []byte("a"))},
+		"text message":                 {wsFrame(true, wsOpText, []byte("a"))},
+		"message in message":           {wsFrame(false, wsOpBinary, []byte("a")), wsFrame(true, wsOpBinary, []byte("b"))},
+		"fragmented control frame":     {wsFrame(false, wsOpPing, nil)},
+		"large control frame":          {wsFrame(true, wsOpPing, make([]byte, 126))},
+		"reserved bits":                {wsFrame(true, wsOpBinary|0x40, []byte("a"))},
+		"unknown opcode":               {wsFrame(true, 0x3, []byte("a"))},
+		"unmasked frame":               {{0x80 | wsOpBinary, 1, 'a'}},
+		"message too large":            {wsFrame(true, wsOpBinary, make([]byte, 65))},
+	}
+	for name, frames := range tests {
+		frames := frames
+		t.Run(name, func(t *testing.T) {
+			conn, raw := newWSTestConn(t)
+			defer conn.Close()
+			defer raw.Close()
+			for _, frame := range frames {
+				_, _ = raw.Write(frame)
+			}
+			if _, err := conn.Read(make([]byte, 64)); err == nil || err == io.EOF {
+				t.Fatalf("expected error reading invalid frames, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	conn, raw := newWSTestConn(t)
+	defer conn.Close()
+	defer raw.Close()
+
+	// 1000 is the status code of a normal closure.
+	_, _ = raw.Write(wsFrame(true, wsOpClose, []byte{0x03, 0xe8}))
+	if _, err := conn.Read(make([]byte, 64)); err != io.EOF {
+		t.Fatalf("expected io.EOF reading close frame, got %v", err)
+	}
+	if echo, expected := readRaw(t, raw), []byte{0x80 | wsOpClose, 2, 0x03, 0xe8}; !bytes.Equal(echo, expected) {
+		t.Errorf("got close frame %x in response, expected %x", echo, expected)
+	}
+
+	_ = conn.Close()
+	if frame, expected := readRaw(t, raw), []byte{0x80 | wsOpClose, 2, 0x03, 0xe8}; !bytes.Equal(frame, expected) {
+		t.Errorf("got close frame %x when closing, expected %x", frame, expected)
+	}
+}
+
+func TestWebSocketDial(t *testing.T) {
+	l, err := wsNetwork{}.Listen("ws://127.0.0.1:0/gophertunnel")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+	address := "ws://" + l.Addr().String() + "/gophertunnel"
+
+	if _, err := (wsNetwork{}).Dial(context.Background(), "ws://"+l.Addr().String()+"/unknown"); err == nil {
+		t.Errorf("expected error dialing an unknown path")
+	}
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			accepted <- conn
+		}
+		close(accepted)
+	}()
+	client, err := wsNetwork{}.Dial(context.Background(), address)
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer client.Close()
+	server, ok := <-accepted
+	if !ok {
+		t.Fatalf("connection was not accepted")
+	}
+	defer server.Close()
+
+	// The lengths below are encoded in 7 bits, 16 bits and 64 bits respectively.
+	b := make([]byte, 1<<17)
+	for _, length := range []int{100, 300, 70000} {
+		data := make([]byte, length)
+		rand.Read(data)
+		for _, c := range [][2]net.Conn{{client, server}, {server, client}} {
+			// The message is written on another goroutine, as writing may block until it is read.
+			written := make(chan error, 1)
+			go func(w net.Conn) {
+				_, err := w.Write(data)
+				written <- err
+			}(c[0])
+			n, err := c[1].Read(b)
+			if err != nil {
+				t.Fatalf("error reading message of %v bytes: %v", length, err)
+			}
+			if err := <-written; err != nil {
+				t.Fatalf("error writing message of %v bytes: %v", length, err)
+			}
+			if !bytes.Equal(b[:n], data) {
+				t.Fatalf("message of %v bytes was not read correctly", length)
+			}
+		}
+	}
+
+	_ = client.Close()
+	if _, err := server.Read(b); err != io.EOF {
+		t.Errorf("expected io.EOF reading from a connection closed by the client, got %v", err)
+	}
+}